@@ -1,27 +1,121 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strconv"
+	"time"
 
+	"github.com/dariomba/url-shortener/src/internal/config"
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/handlers/openapi"
 	urlshortener "github.com/dariomba/url-shortener/src/internal/handlers/url_shortener"
+	"github.com/dariomba/url-shortener/src/internal/requestid"
+	"github.com/dariomba/url-shortener/src/internal/safehttp"
+	"github.com/dariomba/url-shortener/src/internal/services/analytics"
+	"github.com/dariomba/url-shortener/src/internal/services/audit"
+	"github.com/dariomba/url-shortener/src/internal/services/geoip"
+	"github.com/dariomba/url-shortener/src/internal/services/health"
+	"github.com/dariomba/url-shortener/src/internal/services/idempotency"
+	"github.com/dariomba/url-shortener/src/internal/services/keys"
+	"github.com/dariomba/url-shortener/src/internal/services/lockout"
+	"github.com/dariomba/url-shortener/src/internal/services/qrcode"
+	"github.com/dariomba/url-shortener/src/internal/services/quota"
 	"github.com/dariomba/url-shortener/src/internal/services/shortener"
 	"github.com/dariomba/url-shortener/src/internal/services/storage"
+	"github.com/dariomba/url-shortener/src/internal/services/titles"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
 )
 
 func main() {
 	godotenv.Load()
 
 	router := gin.Default()
+	router.Use(requestid.Middleware())
+	// Only these proxies may set X-Forwarded-For. None are trusted by
+	// default, so the client IP is the address of the connection.
+	if err := router.SetTrustedProxies(config.Strings("TRUSTED_PROXIES")); err != nil {
+		panic(fmt.Errorf("invalid TRUSTED_PROXIES --> %w", err))
+	}
+
+	redisClient := buildRedisClient()
+
+	spec, err := openapi.Load()
+	if err != nil {
+		panic(err)
+	}
+	validateRequests, err := openapi.ValidateRequests(spec)
+	if err != nil {
+		panic(err)
+	}
+	openapi.NewOpenAPIHandler(router.Group("/"))
+
+	handlerConfig, err := urlshortener.ConfigFromEnv()
+	if err != nil {
+		panic(err)
+	}
+	reserved := domain.NewReservedWords(domain.DefaultReservedWords...)
+	storageService := storage.NewStorageService(redisClient)
 
-	v1 := router.Group("/")
-	urlshortener.NewURLShortenerHandler(v1, *storage.NewStorageService(buildRedisClient()), &shortener.ShortenerService{})
+	geoIPService, err := geoip.NewGeoIPService(os.Getenv("GEOIP_DATABASE"))
+	if err != nil {
+		panic(err)
+	}
+	go geoIPService.Watch(context.Background(), config.Duration("GEOIP_RELOAD_INTERVAL", time.Minute))
+
+	titleService := titles.NewTitleService(storageService,
+		safehttp.NewClient(config.Duration("TITLE_FETCH_TIMEOUT", 5*time.Second)),
+		int64(config.Int("TITLE_FETCH_MAX_BYTES", 512*1024)), config.Int("TITLE_FETCH_QUEUE", 1000))
+	go titleService.Run(context.Background(), config.Int("TITLE_FETCH_WORKERS", 4))
+
+	healthInterval := config.Duration("HEALTH_CHECK_INTERVAL", 6*time.Hour)
+	healthService := health.NewHealthService(redisClient, storageService,
+		safehttp.NewClient(config.Duration("HEALTH_CHECK_TIMEOUT", 10*time.Second)), health.Options{
+			Interval:    healthInterval,
+			Concurrency: config.Int("HEALTH_CHECK_CONCURRENCY", 8),
+			HostDelay:   config.Duration("HEALTH_CHECK_HOST_DELAY", time.Second),
+			Threshold:   config.Int("HEALTH_FAILURE_THRESHOLD", 3),
+			WebhookURL:  os.Getenv("HEALTH_WEBHOOK_URL"),
+		})
+	// A zero interval turns the checks off.
+	if healthInterval > 0 {
+		go healthService.Run(context.Background())
+	}
+
+	urlshortener.NewURLShortenerHandler(
+		router.Group("/"),
+		*storageService,
+		shortener.NewShortenerService(reserved),
+		idempotency.NewIdempotencyService(redisClient, config.Duration("IDEMPOTENCY_WINDOW", 24*time.Hour)),
+		qrcode.NewQRCodeService(config.Int("QR_CACHE_SIZE", 512)),
+		lockout.NewLockoutService(redisClient,
+			config.Int("PASSWORD_MAX_ATTEMPTS", 5), config.Duration("PASSWORD_LOCKOUT_WINDOW", 15*time.Minute)),
+		analytics.NewAnalyticsService(redisClient, config.Duration("ANALYTICS_RETENTION", 30*24*time.Hour)),
+		geoIPService,
+		quota.NewQuotaService(redisClient),
+		keys.NewKeyService(redisClient),
+		audit.NewAuditService(redisClient, int64(config.Int("AUDIT_MAX_EVENTS", 1000000))),
+		titleService,
+		healthService,
+		handlerConfig,
+		reserved,
+		validateRequests,
+	)
+
+	urlshortener.ReserveRoutes(router.Routes(), reserved)
+	shadowed, err := urlshortener.CheckReservedCodes(context.Background(), storageService, handlerConfig.Domains, reserved)
+	if err != nil {
+		log.Error(fmt.Errorf("checking reserved words against existing links --> %w", err))
+	}
+	for _, shortURL := range shadowed {
+		log.Warnf("short link %q uses a reserved word as its code and should be moved to a new one", shortURL)
+	}
 
-	err := router.Run(":8080") // listen and serve on 0.0.0.0:8080
+	err = router.Run(":8080") // listen and serve on 0.0.0.0:8080
 	if err != nil {
 		panic(fmt.Errorf("failed to start web server -> %w", err))
 	}
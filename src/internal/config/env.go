@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Int reads an integer environment variable, falling back to def when it is
+// unset or malformed.
+func Int(key string, def int) int {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		log.Warnf("%s must be an integer, using default %d", key, def)
+		return def
+	}
+	return value
+}
+
+// Duration reads a time.Duration environment variable (e.g. "24h"), falling
+// back to def when it is unset or malformed.
+func Duration(key string, def time.Duration) time.Duration {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		log.Warnf("%s must be a duration, using default %s", key, def)
+		return def
+	}
+	return value
+}
+
+// Strings reads a comma separated environment variable, dropping blank
+// entries. It returns nil when the variable is unset.
+func Strings(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
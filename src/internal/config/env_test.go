@@ -0,0 +1,97 @@
+package config_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected int
+	}{
+		{
+			name:     "WhenVariableIsUnset_ThenReturnsDefault",
+			value:    "",
+			expected: 10,
+		},
+		{
+			name:     "WhenVariableIsMalformed_ThenReturnsDefault",
+			value:    "ten",
+			expected: 10,
+		},
+		{
+			name:     "WhenVariableIsValid_ThenReturnsValue",
+			value:    "25",
+			expected: 25,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_TEST_INT", tt.value)
+			assert.Equal(t, tt.expected, config.Int("CONFIG_TEST_INT", 10))
+		})
+	}
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{
+			name:     "WhenVariableIsUnset_ThenReturnsDefault",
+			value:    "",
+			expected: time.Hour,
+		},
+		{
+			name:     "WhenVariableIsMalformed_ThenReturnsDefault",
+			value:    "one hour",
+			expected: time.Hour,
+		},
+		{
+			name:     "WhenVariableIsValid_ThenReturnsValue",
+			value:    "90s",
+			expected: 90 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_TEST_DURATION", tt.value)
+			assert.Equal(t, tt.expected, config.Duration("CONFIG_TEST_DURATION", time.Hour))
+		})
+	}
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{
+			name:     "WhenVariableIsUnset_ThenReturnsNil",
+			value:    "",
+			expected: nil,
+		},
+		{
+			name:     "WhenVariableHasBlankEntries_ThenDropsThem",
+			value:    " example.com, ,acme.link,",
+			expected: []string{"example.com", "acme.link"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CONFIG_TEST_STRINGS", tt.value)
+			assert.Equal(t, tt.expected, config.Strings("CONFIG_TEST_STRINGS"))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+)
+
+var (
+	ErrForbidden     = errors.New("the API key is not allowed to do this")
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrKeyNameTaken  = errors.New("an API key with this name already exists")
+	ErrKeyNotFound   = errors.New("API key not found")
+)
+
+var keyNamePattern = regexp.MustCompile(`^[A-Za-z0-9@._-]{1,64}$`)
+
+// Roles an API key can have in its workspace. Each one can do everything the
+// previous one can.
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var roles = []string{RoleViewer, RoleEditor, RoleAdmin}
+
+// Actions an API key is authorized for, which the audit log also records.
+const (
+	ActionRead      = "read"
+	ActionCreate    = "create"
+	ActionUpdate    = "update"
+	ActionRollback  = "rollback"
+	ActionDisable   = "disable"
+	ActionEnable    = "enable"
+	ActionDelete    = "delete"
+	ActionListKeys  = "list_keys"
+	ActionCreateKey = "create_key"
+	ActionRevokeKey = "revoke_key"
+	ActionReadAudit = "read_audit"
+)
+
+// APIKey is a key of a workspace. Keys are only ever stored hashed.
+type APIKey struct {
+	// Name identifies the key in the links it creates and in the audit log.
+	Name string `json:"name"`
+	Role string `json:"role"`
+	// Team is the team the key works for. Editors can change the links of
+	// their team as well as their own.
+	Team string `json:"team,omitempty"`
+	// Hash is the hex SHA-256 of the key, see HashAPIKey.
+	Hash string `json:"hash,omitempty"`
+}
+
+// ValidateAPIKey checks the name and role of a key.
+func ValidateAPIKey(key APIKey) error {
+	if !keyNamePattern.MatchString(key.Name) {
+		return Detailed(ErrInvalidAPIKey, "name %q must be 1-64 letters, digits, '@', '.', '-' or '_'", key.Name)
+	}
+	if !slices.Contains(roles, key.Role) {
+		return Detailed(ErrInvalidAPIKey, "role of %s must be viewer, editor or admin", key.Name)
+	}
+	return nil
+}
+
+// Actor is the API key an authenticated request was sent with.
+type Actor struct {
+	Workspace Workspace
+	Key       APIKey
+}
+
+// Can reports whether the actor may perform action on link, nil for actions
+// on no link in particular. Viewers only read. Editors also create links for
+// their own team and change the links they created or that belong to their
+// team. Admins can do anything, including managing the keys of the
+// workspace and reading its audit log.
+func (a Actor) Can(action string, link *Link) bool {
+	switch a.Key.Role {
+	case RoleAdmin:
+		return true
+	case RoleEditor:
+		switch action {
+		case ActionRead:
+			return true
+		case ActionCreate:
+			return link == nil || a.Key.Team == "" || link.Team == a.Key.Team
+		case ActionUpdate, ActionRollback, ActionDisable, ActionEnable, ActionDelete:
+			return link == nil || a.owns(*link)
+		}
+	case RoleViewer:
+		return action == ActionRead
+	}
+	return false
+}
+
+// owns reports whether the actor created link or it belongs to its team.
+func (a Actor) owns(link Link) bool {
+	return link.CreatedBy == a.Key.Name || (a.Key.Team != "" && link.Team == a.Key.Team)
+}
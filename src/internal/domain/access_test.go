@@ -0,0 +1,54 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActorCan(t *testing.T) {
+	own := &domain.Link{Code: "a", CreatedBy: "alice"}
+	team := &domain.Link{Code: "b", CreatedBy: "bob", Team: "growth"}
+	other := &domain.Link{Code: "c", CreatedBy: "carol", Team: "sales"}
+
+	tests := []struct {
+		name   string
+		key    domain.APIKey
+		action string
+		link   *domain.Link
+		want   bool
+	}{
+		{name: "WhenViewerReads_ThenAllows", key: domain.APIKey{Name: "alice", Role: domain.RoleViewer},
+			action: domain.ActionRead, want: true},
+		{name: "WhenViewerCreates_ThenDenies", key: domain.APIKey{Name: "alice", Role: domain.RoleViewer},
+			action: domain.ActionCreate, link: &domain.Link{CreatedBy: "alice"}},
+		{name: "WhenEditorUpdatesItsOwnLink_ThenAllows", key: domain.APIKey{Name: "alice", Role: domain.RoleEditor},
+			action: domain.ActionUpdate, link: own, want: true},
+		{name: "WhenEditorUpdatesALinkOfItsTeam_ThenAllows", key: domain.APIKey{Name: "alice", Role: domain.RoleEditor, Team: "growth"},
+			action: domain.ActionUpdate, link: team, want: true},
+		{name: "WhenEditorDisablesItsOwnLink_ThenAllows", key: domain.APIKey{Name: "alice", Role: domain.RoleEditor},
+			action: domain.ActionDisable, link: own, want: true},
+		{name: "WhenEditorReadsTheAuditLog_ThenDenies", key: domain.APIKey{Name: "alice", Role: domain.RoleEditor},
+			action: domain.ActionReadAudit},
+		{name: "WhenEditorDeletesALinkOfAnotherTeam_ThenDenies", key: domain.APIKey{Name: "alice", Role: domain.RoleEditor, Team: "growth"},
+			action: domain.ActionDelete, link: other},
+		{name: "WhenEditorCreatesALinkForAnotherTeam_ThenDenies", key: domain.APIKey{Name: "alice", Role: domain.RoleEditor, Team: "growth"},
+			action: domain.ActionCreate, link: &domain.Link{CreatedBy: "alice", Team: "sales"}},
+		{name: "WhenEditorManagesKeys_ThenDenies", key: domain.APIKey{Name: "alice", Role: domain.RoleEditor},
+			action: domain.ActionCreateKey},
+		{name: "WhenAdminDeletesAnyLink_ThenAllows", key: domain.APIKey{Name: "alice", Role: domain.RoleAdmin},
+			action: domain.ActionDelete, link: other, want: true},
+		{name: "WhenAdminManagesKeys_ThenAllows", key: domain.APIKey{Name: "alice", Role: domain.RoleAdmin},
+			action: domain.ActionRevokeKey, want: true},
+		{name: "WhenRoleIsUnknown_ThenDenies", key: domain.APIKey{Name: "alice", Role: "owner"},
+			action: domain.ActionRead},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actor := domain.Actor{Workspace: domain.Workspace{ID: "marketing"}, Key: tt.key}
+			assert.Equal(t, tt.want, actor.Can(tt.action, tt.link))
+		})
+	}
+}
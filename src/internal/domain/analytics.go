@@ -0,0 +1,47 @@
+package domain
+
+// Click is one redirect of a link, as recorded in analytics.
+type Click struct {
+	// Target labels the targeting rule that chose the destination, or
+	// DefaultTarget.
+	Target string
+	// Country of the visitor, empty when unknown.
+	Country string
+	// Variant the visitor was sent to, empty when the link has none.
+	Variant string
+	// Path is why the visitor was sent where they were, one of the Path
+	// constants. Empty means PathPrimary.
+	Path string
+}
+
+// ClickStats counts the clicks of a link, in total and per dimension.
+type ClickStats struct {
+	Total   int64            `json:"total"`
+	Targets map[string]int64 `json:"targets,omitempty"`
+	// Countries counts clicks per ISO country code, UnknownCountry holding
+	// those whose country could not be resolved.
+	Countries map[string]int64 `json:"countries,omitempty"`
+	// Variants counts clicks per variant of a split link.
+	Variants map[string]int64 `json:"variants,omitempty"`
+	// Paths counts clicks per path taken, see Click.Path.
+	Paths map[string]int64 `json:"paths,omitempty"`
+}
+
+// UnknownCountry labels clicks from IPs without a known country.
+const UnknownCountry = "unknown"
+
+// The paths a click can take: to the destination of the link, or to a
+// fallback URL because the link could not send the visitor there.
+const (
+	PathPrimary = "primary"
+	// PathPending and PathExpired are clicks before the activation window
+	// of the link opens and after it closes, or after the link itself
+	// expired.
+	PathPending   = "fallback_pending"
+	PathExpired   = "fallback_expired"
+	PathDisabled  = "fallback_disabled"
+	PathExhausted = "fallback_exhausted"
+	// PathBroken is a click on a link whose destination failed its health
+	// checks, see LinkHealth.
+	PathBroken = "fallback_broken"
+)
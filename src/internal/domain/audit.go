@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var ErrInvalidAuditQuery = errors.New("invalid audit query")
+
+// Outcomes of an audited action.
+const (
+	AuditSucceeded = "succeeded"
+	AuditDenied    = "denied"
+)
+
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+// redactedValue replaces secrets in the changes of an event, so that the log
+// shows that they changed without holding them.
+var redactedValue = json.RawMessage(`"[redacted]"`)
+
+// redactedFields are the JSON fields of links and keys holding secrets.
+var redactedFields = map[string]bool{"password_hash": true, "hash": true}
+
+var auditCursorPattern = regexp.MustCompile(`^[0-9]+-[0-9]+$`)
+
+// AuditEvent records an action taken, or attempted, through the API.
+type AuditEvent struct {
+	// ID orders the events of the log. It is assigned when the event is
+	// recorded.
+	ID        string    `json:"id,omitempty"`
+	Time      time.Time `json:"time"`
+	Workspace string    `json:"workspace,omitempty"`
+	// Actor is the name of the API key the request was sent with.
+	Actor string `json:"actor,omitempty"`
+	// Action is one of the Action constants.
+	Action string `json:"action"`
+	// Target is the storage key of the link, or the name of the API key,
+	// the action was on.
+	Target    string `json:"target,omitempty"`
+	Outcome   string `json:"outcome"`
+	RequestID string `json:"request_id,omitempty"`
+	// Changes holds the fields the action changed, by JSON name.
+	Changes map[string]Change `json:"changes,omitempty"`
+}
+
+// Change is the value of a field before and after an action. A side is
+// missing when the field was not set.
+type Change struct {
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// Diff compares the JSON encodings of before and after field by field, nil
+// standing for something that did not exist or no longer does. Secrets are
+// redacted.
+func Diff(before any, after any) (map[string]Change, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for name, value := range beforeFields {
+		if !bytes.Equal(value, afterFields[name]) {
+			changes[name] = Change{Before: value, After: afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			changes[name] = Change{After: value}
+		}
+	}
+	for name, change := range changes {
+		if !redactedFields[name] {
+			continue
+		}
+		if change.Before != nil {
+			change.Before = redactedValue
+		}
+		if change.After != nil {
+			change.After = redactedValue
+		}
+		changes[name] = change
+	}
+	return changes, nil
+}
+
+func jsonFields(value any) (map[string]json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("encoding the audited value --> %w", err)
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, fmt.Errorf("decoding the audited value --> %w", err)
+	}
+	return fields, nil
+}
+
+// AuditQuery selects the events of the audit log of a workspace. Empty
+// filters match every event.
+type AuditQuery struct {
+	Workspace string
+	Actor     string
+	Action    string
+	Target    string
+	Outcome   string
+	// From and To bound the time of the events, both included.
+	From *time.Time
+	To   *time.Time
+	// Cursor continues a previous query after the events it returned.
+	Cursor string
+	Limit  int
+}
+
+// Validate checks the query and fills in its default limit.
+func (q *AuditQuery) Validate() error {
+	if q.Limit == 0 {
+		q.Limit = DefaultAuditLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxAuditLimit {
+		return Detailed(ErrInvalidAuditQuery, "limit must be between 1 and %d", MaxAuditLimit)
+	}
+	if q.Cursor != "" && !auditCursorPattern.MatchString(q.Cursor) {
+		return Detailed(ErrInvalidAuditQuery, "cursor is not valid")
+	}
+	if q.From != nil && q.To != nil && q.To.Before(*q.From) {
+		return Detailed(ErrInvalidAuditQuery, "to must not be before from")
+	}
+	return nil
+}
+
+// Matches reports whether event passes the filters of the query other than
+// its time range.
+func (q AuditQuery) Matches(event AuditEvent) bool {
+	return (q.Actor == "" || event.Actor == q.Actor) &&
+		(q.Action == "" || event.Action == q.Action) &&
+		(q.Target == "" || event.Target == q.Target) &&
+		(q.Outcome == "" || event.Outcome == q.Outcome)
+}
+
+// AuditPage is a page of events, newest first. NextCursor continues it and is
+// empty on the last page.
+type AuditPage struct {
+	Events     []AuditEvent `json:"events"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,70 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	link := domain.Link{Code: "promo", OriginalURL: "http://example.com", PasswordHash: "$2a$10$secret"}
+	updated := link
+	updated.OriginalURL = "http://example.org"
+	updated.MaxClicks = 5
+
+	tests := []struct {
+		name   string
+		before any
+		after  any
+		want   map[string]domain.Change
+	}{
+		{
+			name:   "WhenFieldsChange_ThenReturnsOnlyThose",
+			before: link,
+			after:  updated,
+			want: map[string]domain.Change{
+				"url":        {Before: json.RawMessage(`"http://example.com"`), After: json.RawMessage(`"http://example.org"`)},
+				"max_clicks": {After: json.RawMessage(`5`)},
+			},
+		},
+		{
+			name:  "WhenSomethingIsCreated_ThenReturnsEveryFieldWithSecretsRedacted",
+			after: link,
+			want: map[string]domain.Change{
+				"code":          {After: json.RawMessage(`"promo"`)},
+				"url":           {After: json.RawMessage(`"http://example.com"`)},
+				"password_hash": {After: json.RawMessage(`"[redacted]"`)},
+			},
+		},
+		{
+			name:   "WhenNothingChanges_ThenReturnsNoChanges",
+			before: link,
+			after:  &link,
+			want:   map[string]domain.Change{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := domain.Diff(tt.before, tt.after)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, changes)
+		})
+	}
+}
+
+func TestAuditQueryValidate(t *testing.T) {
+	from := time.Date(2030, 6, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+
+	query := domain.AuditQuery{}
+	assert.NoError(t, query.Validate())
+	assert.Equal(t, domain.DefaultAuditLimit, query.Limit)
+
+	for _, query := range []domain.AuditQuery{{Limit: -1}, {Cursor: "abc"}, {From: &from, To: &to}} {
+		assert.ErrorIs(t, query.Validate(), domain.ErrInvalidAuditQuery)
+	}
+}
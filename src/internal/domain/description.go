@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxTitleLength and MaxNoteLength are how many characters the title and
+	// the note of a link can have.
+	MaxTitleLength = 200
+	MaxNoteLength  = 2000
+)
+
+var ErrInvalidDescription = errors.New("invalid title or note")
+
+// NormalizeTitle collapses the whitespace of a title given by a client and
+// checks its length.
+func NormalizeTitle(title string) (string, error) {
+	title = strings.Join(strings.Fields(title), " ")
+	if utf8.RuneCountInString(title) > MaxTitleLength {
+		return "", Detailed(ErrInvalidDescription, "title must be at most %d characters", MaxTitleLength)
+	}
+	return title, nil
+}
+
+// ValidateNote checks the length of a note. Notes are free text and keep
+// their whitespace.
+func ValidateNote(note string) error {
+	if utf8.RuneCountInString(note) > MaxNoteLength {
+		return Detailed(ErrInvalidDescription, "note must be at most %d characters", MaxNoteLength)
+	}
+	return nil
+}
+
+// CleanTitle turns a title read from a web page into one a link can have:
+// invalid UTF-8 is dropped, whitespace collapsed and titles too long cut.
+func CleanTitle(title string) string {
+	title = strings.Join(strings.Fields(strings.ToValidUTF8(title, "")), " ")
+	if utf8.RuneCountInString(title) <= MaxTitleLength {
+		return title
+	}
+	return strings.TrimSpace(string([]rune(title)[:MaxTitleLength]))
+}
@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	title, err := domain.NormalizeTitle("  Summer\n sale   2030 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "Summer sale 2030", title)
+
+	_, err = domain.NormalizeTitle(strings.Repeat("é", domain.MaxTitleLength+1))
+	assert.ErrorIs(t, err, domain.ErrInvalidDescription)
+}
+
+func TestValidateNote(t *testing.T) {
+	assert.NoError(t, domain.ValidateNote("Printed on the\nspring flyer"))
+	assert.ErrorIs(t, domain.ValidateNote(strings.Repeat("a", domain.MaxNoteLength+1)), domain.ErrInvalidDescription)
+}
+
+func TestCleanTitle(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{name: "WhenTitleHasRunsOfWhitespace_ThenCollapsesThem", title: "\n\tAcme  |  Home\n", want: "Acme | Home"},
+		{name: "WhenTitleIsNotUTF8_ThenDropsTheInvalidBytes", title: "Caf\xe9 Acme", want: "Caf Acme"},
+		{name: "WhenTitleIsTooLong_ThenCutsIt", title: strings.Repeat("ñ", domain.MaxTitleLength+10), want: strings.Repeat("ñ", domain.MaxTitleLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, domain.CleanTitle(tt.title))
+		})
+	}
+}
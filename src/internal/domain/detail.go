@@ -0,0 +1,25 @@
+package domain
+
+import "fmt"
+
+// DetailedError is a domain error together with an explanation written for
+// clients, such as which field of a request is wrong. Only that explanation
+// is shown in problem details: the context wrapped around it while the error
+// travels up is not.
+type DetailedError struct {
+	Err    error
+	Detail string
+}
+
+// Detailed wraps err with a client-facing explanation built from format.
+func Detailed(err error, format string, args ...any) error {
+	return &DetailedError{Err: err, Detail: fmt.Sprintf(format, args...)}
+}
+
+func (e *DetailedError) Error() string {
+	return e.Err.Error() + ": " + e.Detail
+}
+
+func (e *DetailedError) Unwrap() error {
+	return e.Err
+}
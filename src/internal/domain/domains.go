@@ -0,0 +1,170 @@
+package domain
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrUnknownDomain  = errors.New("domain is not configured")
+	ErrInvalidDomains = errors.New("invalid domains")
+)
+
+var hostnamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?(\.[a-z0-9]([a-z0-9-]*[a-z0-9])?)*$`)
+
+// namespaceSeparator joins the namespace of a domain and a code into a
+// storage key. Codes never contain it, see aliasPattern.
+const namespaceSeparator = "/"
+
+// ShortDomain is a host short links are served from, such as go.acme.com.
+type ShortDomain struct {
+	// Name is the host visitors reach the domain on.
+	Name string `json:"name"`
+	// BaseURL prefixes the codes of the domain in short URLs. Empty means
+	// https://<Name>/.
+	BaseURL string `json:"base_url"`
+	// FallbackURL is where visitors to the root of the domain are sent,
+	// those of its links that cannot redirect and have no fallback URL of
+	// their own, and those of codes it does not have when it has no
+	// NotFoundPage.
+	FallbackURL string `json:"fallback_url"`
+	// NotFoundPage is an HTML file served for codes the domain does not
+	// have.
+	NotFoundPage string `json:"not_found_page"`
+	// Namespace prefixes the storage keys of the links of the domain, so that
+	// the same code can exist on several domains. The default domain has
+	// none, which keeps the links created before there were domains.
+	Namespace string `json:"-"`
+}
+
+// ShortURL is the full short URL of code on the domain.
+func (d ShortDomain) ShortURL(code string) string {
+	return d.BaseURL + code
+}
+
+// Domains are the short domains of the server. The first one is the default:
+// links are created on it unless the request names another, and requests for
+// a host that is not configured are resolved on it.
+type Domains struct {
+	domains []ShortDomain
+	byName  map[string]int
+}
+
+// NewDomains checks the configuration of every domain and fills in their
+// defaults.
+func NewDomains(domains []ShortDomain) (*Domains, error) {
+	if len(domains) == 0 {
+		return nil, Detailed(ErrInvalidDomains, "at least one domain is required")
+	}
+
+	d := &Domains{byName: make(map[string]int, len(domains))}
+	for i, shortDomain := range domains {
+		shortDomain.Name = strings.ToLower(shortDomain.Name)
+		if !hostnamePattern.MatchString(shortDomain.Name) {
+			return nil, Detailed(ErrInvalidDomains, "%q is not a host name", shortDomain.Name)
+		}
+		if _, ok := d.byName[shortDomain.Name]; ok {
+			return nil, Detailed(ErrInvalidDomains, "%s is repeated", shortDomain.Name)
+		}
+
+		if shortDomain.BaseURL == "" {
+			shortDomain.BaseURL = "https://" + shortDomain.Name + "/"
+		}
+		if !IsAbsoluteURL(shortDomain.BaseURL) {
+			return nil, Detailed(ErrInvalidDomains, "base_url of %s must be an absolute URL", shortDomain.Name)
+		}
+		if !strings.HasSuffix(shortDomain.BaseURL, "/") {
+			shortDomain.BaseURL += "/"
+		}
+		if shortDomain.FallbackURL != "" && !IsAbsoluteURL(shortDomain.FallbackURL) {
+			return nil, Detailed(ErrInvalidDomains, "fallback_url of %s must be an absolute URL", shortDomain.Name)
+		}
+
+		if i > 0 {
+			shortDomain.Namespace = shortDomain.Name
+		}
+		d.byName[shortDomain.Name] = i
+		d.domains = append(d.domains, shortDomain)
+	}
+	return d, nil
+}
+
+// SingleDomain serves every link from baseURL, as the server did before it
+// had several domains.
+func SingleDomain(baseURL string) *Domains {
+	name := ""
+	if base, err := url.Parse(baseURL); err == nil {
+		name = strings.ToLower(base.Hostname())
+	}
+	return &Domains{
+		domains: []ShortDomain{{Name: name, BaseURL: baseURL}},
+		byName:  map[string]int{name: 0},
+	}
+}
+
+// All returns every domain, the default first.
+func (d *Domains) All() []ShortDomain {
+	return d.domains
+}
+
+// Default returns the domain links are created on when no other is named.
+func (d *Domains) Default() ShortDomain {
+	return d.domains[0]
+}
+
+// Get returns the domain called name, or the default domain when name is
+// empty.
+func (d *Domains) Get(name string) (ShortDomain, error) {
+	if name == "" {
+		return d.Default(), nil
+	}
+	i, ok := d.byName[strings.ToLower(name)]
+	if !ok {
+		return ShortDomain{}, Detailed(ErrUnknownDomain, "%s", name)
+	}
+	return d.domains[i], nil
+}
+
+// Resolve returns the domain a request for host is for. Hosts that are not
+// configured resolve to the default domain.
+func (d *Domains) Resolve(host string) ShortDomain {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	i, ok := d.byName[strings.TrimSuffix(strings.ToLower(host), ".")]
+	if !ok {
+		return d.Default()
+	}
+	return d.domains[i]
+}
+
+// LinkKey is the storage key of code on the domain with namespace.
+func LinkKey(namespace string, code string) string {
+	if namespace == "" {
+		return code
+	}
+	return namespace + namespaceSeparator + code
+}
+
+// SplitLinkKey is the inverse of LinkKey.
+func SplitLinkKey(key string) (namespace string, code string) {
+	namespace, code, found := strings.Cut(key, namespaceSeparator)
+	if !found {
+		return "", key
+	}
+	return namespace, code
+}
+
+// Address is where visitors reach the link: its code on its domain, as a
+// storage key outside any workspace.
+func (l Link) Address() string {
+	return LinkKey(l.Domain, l.Code)
+}
+
+// Key is the storage key of the link, in the namespace of its workspace.
+func (l Link) Key() string {
+	return WorkspaceKey(l.Workspace, l.Address())
+}
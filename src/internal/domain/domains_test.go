@@ -0,0 +1,109 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDomains(t *testing.T) {
+	tests := []struct {
+		name     string
+		domains  []domain.ShortDomain
+		expected []domain.ShortDomain
+		err      string
+	}{
+		{
+			name:    "WhenDomainsAreValid_ThenFillsInTheirDefaults",
+			domains: []domain.ShortDomain{{Name: "Go.Acme.com"}, {Name: "acme.link", BaseURL: "http://acme.link:8080"}},
+			expected: []domain.ShortDomain{
+				{Name: "go.acme.com", BaseURL: "https://go.acme.com/"},
+				{Name: "acme.link", BaseURL: "http://acme.link:8080/", Namespace: "acme.link"},
+			},
+		},
+		{
+			name:    "WhenThereAreNone_ThenReturnsAnError",
+			domains: nil,
+			err:     "invalid domains: at least one domain is required",
+		},
+		{
+			name:    "WhenANameIsNotAHost_ThenReturnsAnError",
+			domains: []domain.ShortDomain{{Name: "acme.link/promo"}},
+			err:     `invalid domains: "acme.link/promo" is not a host name`,
+		},
+		{
+			name:    "WhenANameIsRepeated_ThenReturnsAnError",
+			domains: []domain.ShortDomain{{Name: "acme.link"}, {Name: "ACME.link"}},
+			err:     "invalid domains: acme.link is repeated",
+		},
+		{
+			name:    "WhenTheFallbackIsNotAbsolute_ThenReturnsAnError",
+			domains: []domain.ShortDomain{{Name: "acme.link", FallbackURL: "/home"}},
+			err:     "invalid domains: fallback_url of acme.link must be an absolute URL",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			domains, err := domain.NewDomains(tt.domains)
+			if tt.err != "" {
+				assert.ErrorIs(t, err, domain.ErrInvalidDomains)
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, domains.All())
+		})
+	}
+}
+
+func TestDomainsResolve(t *testing.T) {
+	domains, err := domain.NewDomains([]domain.ShortDomain{{Name: "go.acme.com"}, {Name: "acme.link"}})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		host     string
+		expected string
+	}{
+		{name: "WhenHostIsConfigured_ThenReturnsItsDomain", host: "acme.link", expected: "acme.link"},
+		{name: "WhenHostHasAPort_ThenIgnoresIt", host: "ACME.link:8080", expected: "acme.link"},
+		{name: "WhenHostIsUnknown_ThenReturnsTheDefaultDomain", host: "localhost:8080", expected: "go.acme.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, domains.Resolve(tt.host).Name)
+		})
+	}
+}
+
+func TestDomainsGet(t *testing.T) {
+	domains, err := domain.NewDomains([]domain.ShortDomain{{Name: "go.acme.com"}, {Name: "acme.link"}})
+	assert.NoError(t, err)
+
+	shortDomain, err := domains.Get("")
+	assert.NoError(t, err)
+	assert.Equal(t, "go.acme.com", shortDomain.Name)
+
+	shortDomain, err = domains.Get("acme.link")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://acme.link/promo", shortDomain.ShortURL("promo"))
+
+	_, err = domains.Get("acme.dev")
+	assert.ErrorIs(t, err, domain.ErrUnknownDomain)
+}
+
+func TestLinkKey(t *testing.T) {
+	assert.Equal(t, "promo", domain.Link{Code: "promo"}.Key())
+	assert.Equal(t, "acme.link/promo", domain.Link{Code: "promo", Domain: "acme.link"}.Key())
+
+	namespace, code := domain.SplitLinkKey("acme.link/promo")
+	assert.Equal(t, "acme.link", namespace)
+	assert.Equal(t, "promo", code)
+
+	namespace, code = domain.SplitLinkKey("promo")
+	assert.Equal(t, "", namespace)
+	assert.Equal(t, "promo", code)
+}
@@ -0,0 +1,88 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	// MaxTags is how many tags a link can have.
+	MaxTags = 20
+	// DefaultTopLinks is how many of its most clicked links the stats of a
+	// campaign show, and MaxTopLinks the most they can be asked to.
+	DefaultTopLinks = 10
+	MaxTopLinks     = 100
+)
+
+var ErrInvalidGrouping = errors.New("invalid tags or campaign")
+
+// groupPattern keeps tags and campaigns safe to use in storage keys and URL
+// paths.
+var groupPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// TagIndexKey holds the storage keys of the links of workspace tagged tag,
+// and CampaignIndexKey those in campaign, scored like the SortCreated index.
+// Links keep their entries when they lose the tag or leave the campaign, see
+// LinkQuery.Matches, until a listing finds them out.
+func TagIndexKey(workspace string, tag string) string {
+	return indexKey("link_tag:"+tag, workspace)
+}
+
+func CampaignIndexKey(workspace string, campaign string) string {
+	return indexKey("link_campaign:"+campaign, workspace)
+}
+
+// NormalizeTags lowercases tags, drops repeated ones and checks the rest.
+func NormalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if !groupPattern.MatchString(tag) {
+			return nil, Detailed(ErrInvalidGrouping, "tag %q must be 1-64 lowercase letters, digits, '-' or '_'", tag)
+		}
+		if !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	if len(normalized) > MaxTags {
+		return nil, Detailed(ErrInvalidGrouping, "a link can have at most %d tags", MaxTags)
+	}
+	return normalized, nil
+}
+
+// NormalizeCampaign lowercases campaign and checks it. Empty means no
+// campaign.
+func NormalizeCampaign(campaign string) (string, error) {
+	campaign = strings.ToLower(campaign)
+	if campaign != "" && !groupPattern.MatchString(campaign) {
+		return "", Detailed(ErrInvalidGrouping, "campaign %q must be 1-64 lowercase letters, digits, '-' or '_'", campaign)
+	}
+	return campaign, nil
+}
+
+// CampaignStats aggregates the clicks of the links of a campaign.
+type CampaignStats struct {
+	Campaign string
+	Links    int
+	Clicks   int64
+	// TopLinks are the most clicked links of the campaign, most clicked
+	// first.
+	TopLinks []ListedLink
+}
+
+// SummarizeCampaign adds up the clicks of links, the links of campaign, and
+// picks the top most clicked.
+func SummarizeCampaign(campaign string, links []ListedLink, top int) CampaignStats {
+	stats := CampaignStats{Campaign: campaign, Links: len(links)}
+	for _, link := range links {
+		stats.Clicks += link.Clicks
+	}
+
+	sorted := slices.Clone(links)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Clicks > sorted[j].Clicks })
+	stats.TopLinks = sorted[:min(top, len(sorted))]
+	return stats
+}
@@ -0,0 +1,76 @@
+package domain_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tooMany := make([]string, domain.MaxTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("tag%d", i)
+	}
+
+	tests := []struct {
+		name    string
+		tags    []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "WhenTagsAreMixedCase_ThenLowercasesThem", tags: []string{"Summer", "EMAIL"}, want: []string{"summer", "email"}},
+		{name: "WhenTagsRepeat_ThenKeepsTheFirst", tags: []string{"summer", "Summer", "email"}, want: []string{"summer", "email"}},
+		{name: "WhenThereAreNoTags_ThenReturnsNil", tags: []string{}, want: nil},
+		{name: "WhenTagHasASeparator_ThenFails", tags: []string{"a:b"}, wantErr: true},
+		{name: "WhenTagIsEmpty_ThenFails", tags: []string{""}, wantErr: true},
+		{name: "WhenThereAreTooManyTags_ThenFails", tags: tooMany, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.NormalizeTags(tt.tags)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, domain.ErrInvalidGrouping)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNormalizeCampaign(t *testing.T) {
+	campaign, err := domain.NormalizeCampaign("Q3-Launch")
+	assert.NoError(t, err)
+	assert.Equal(t, "q3-launch", campaign)
+
+	campaign, err = domain.NormalizeCampaign("")
+	assert.NoError(t, err)
+	assert.Empty(t, campaign)
+
+	_, err = domain.NormalizeCampaign("q3 launch")
+	assert.ErrorIs(t, err, domain.ErrInvalidGrouping)
+}
+
+func TestSummarizeCampaign(t *testing.T) {
+	links := []domain.ListedLink{
+		{Link: domain.Link{Code: "a"}, Clicks: 3},
+		{Link: domain.Link{Code: "b"}, Clicks: 10},
+		{Link: domain.Link{Code: "c"}, Clicks: 3},
+	}
+
+	stats := domain.SummarizeCampaign("launch", links, 2)
+	assert.Equal(t, domain.CampaignStats{
+		Campaign: "launch",
+		Links:    3,
+		Clicks:   16,
+		TopLinks: []domain.ListedLink{links[1], links[0]},
+	}, stats)
+
+	stats = domain.SummarizeCampaign("empty", nil, domain.DefaultTopLinks)
+	assert.Equal(t, "empty", stats.Campaign)
+	assert.Zero(t, stats.Clicks)
+	assert.Empty(t, stats.TopLinks)
+}
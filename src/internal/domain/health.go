@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"net/http"
+	"time"
+)
+
+// HealthScheduleKey holds the storage key of every link, scored by the Unix
+// time in milliseconds when its destination is next due a health check.
+const HealthScheduleKey = "health_schedule"
+
+// HealthKey holds the LinkHealth of the link stored under key.
+func HealthKey(key string) string {
+	return "health:" + key
+}
+
+// BrokenIndexKey holds the storage keys of the broken links of workspace,
+// scored by the Unix time in milliseconds since when they are broken.
+func BrokenIndexKey(workspace string) string {
+	return indexKey("link_broken", workspace)
+}
+
+// HealthCheck is the outcome of one request to the destination of a link.
+type HealthCheck struct {
+	// Status is the HTTP status of the answer, zero when the destination
+	// could not be reached, and Error why.
+	Status  int
+	Error   string
+	Latency time.Duration
+	Time    time.Time
+}
+
+// Failed reports whether the destination looks dead: unreachable, gone or
+// failing. Other client errors, such as 401 or 429, mean the page is there.
+func (c HealthCheck) Failed() bool {
+	return c.Status == 0 || c.Status == http.StatusNotFound || c.Status == http.StatusGone ||
+		c.Status >= http.StatusInternalServerError
+}
+
+// LinkHealth is what the health checks of the destination of a link found.
+type LinkHealth struct {
+	Status    int       `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+	// Failures counts the checks failed in a row.
+	Failures int `json:"failures"`
+	// BrokenSince is when the link was found broken, see Next. Nil for
+	// links that are not.
+	BrokenSince *time.Time `json:"broken_since,omitempty"`
+}
+
+func (h LinkHealth) Broken() bool {
+	return h.BrokenSince != nil
+}
+
+// Next returns the health after check. A link is broken once threshold
+// checks failed in a row, and no longer as soon as one succeeds.
+func (h LinkHealth) Next(check HealthCheck, threshold int) LinkHealth {
+	next := LinkHealth{
+		Status:      check.Status,
+		Error:       check.Error,
+		LatencyMS:   check.Latency.Milliseconds(),
+		CheckedAt:   check.Time.UTC().Truncate(time.Millisecond),
+		BrokenSince: h.BrokenSince,
+	}
+	if !check.Failed() {
+		next.BrokenSince = nil
+		return next
+	}
+	next.Failures = h.Failures + 1
+	if next.Failures >= threshold && next.BrokenSince == nil {
+		next.BrokenSince = &next.CheckedAt
+	}
+	return next
+}
+
+// BrokenLink is a broken link and its health.
+type BrokenLink struct {
+	Link   Link
+	Health LinkHealth
+}
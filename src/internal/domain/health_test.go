@@ -0,0 +1,41 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthCheckFailed(t *testing.T) {
+	for _, status := range []int{0, 404, 410, 500, 503} {
+		assert.True(t, domain.HealthCheck{Status: status}.Failed(), status)
+	}
+	for _, status := range []int{200, 204, 301, 401, 403, 429} {
+		assert.False(t, domain.HealthCheck{Status: status}.Failed(), status)
+	}
+}
+
+func TestLinkHealthNext(t *testing.T) {
+	start := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	check := func(status int, hours int) domain.HealthCheck {
+		return domain.HealthCheck{Status: status, Latency: 120 * time.Millisecond, Time: start.Add(time.Duration(hours) * time.Hour)}
+	}
+
+	health := domain.LinkHealth{}.Next(check(404, 0), 3)
+	health = health.Next(check(503, 1), 3)
+	assert.Equal(t, 2, health.Failures)
+	assert.False(t, health.Broken())
+
+	health = health.Next(check(0, 2), 3)
+	brokenSince := start.Add(2 * time.Hour)
+	assert.Equal(t, domain.LinkHealth{Status: 0, LatencyMS: 120, CheckedAt: brokenSince, Failures: 3, BrokenSince: &brokenSince}, health)
+
+	health = health.Next(check(500, 3), 3)
+	assert.Equal(t, 4, health.Failures)
+	assert.Equal(t, &brokenSince, health.BrokenSince)
+
+	health = health.Next(check(200, 4), 3)
+	assert.Equal(t, domain.LinkHealth{Status: 200, LatencyMS: 120, CheckedAt: start.Add(4 * time.Hour)}, health)
+}
@@ -0,0 +1,20 @@
+package domain
+
+import "errors"
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with a different payload")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+// IdempotentResponse is the first response given to an Idempotency-Key,
+// replayed verbatim to any retry carrying the same key and payload.
+type IdempotentResponse struct {
+	// Fingerprint identifies the payload the key was first used with.
+	Fingerprint string `json:"fingerprint"`
+	// Pending is true while the first request is still being processed.
+	Pending     bool   `json:"pending,omitempty"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+var (
+	ErrInvalidAlias       = errors.New("alias must be 3-32 characters long and contain only letters, digits, '-' or '_'")
+	ErrAliasTaken         = errors.New("alias is already in use")
+	ErrReservedAlias      = errors.New("alias is reserved for a system route")
+	ErrLinkNotFound       = errors.New("link not found")
+	ErrLinkExhausted      = errors.New("link has reached its maximum number of clicks")
+	ErrLinkDisabled       = errors.New("link is disabled")
+	ErrStorageUnavailable = errors.New("storage is unavailable")
+	ErrCodeGeneration     = errors.New("short code could not be generated")
+	ErrLinkShared         = errors.New("the link is shared by everyone shortening its URL and cannot be changed")
+)
+
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,32}$`)
+
+// Link is a short code and the original URL it redirects to.
+type Link struct {
+	Code        string `json:"code"`
+	OriginalURL string `json:"url"`
+	// Domain is the namespace of the short domain the link lives on, see
+	// ShortDomain. Empty on the default domain.
+	Domain string `json:"-"`
+	// Workspace is the ID of the workspace that owns the link. Empty for
+	// links created without an API key.
+	Workspace string `json:"workspace,omitempty"`
+	// CreatedBy is the name of the API key that created the link and Team
+	// the team it was created for. Editors can only change the links of
+	// either, see Actor.Can.
+	CreatedBy string `json:"created_by,omitempty"`
+	Team      string `json:"team,omitempty"`
+	// Tags and Campaign group links for listing and analytics, see
+	// NormalizeTags.
+	Tags     []string `json:"tags,omitempty"`
+	Campaign string   `json:"campaign,omitempty"`
+	// Title and Note describe the link to people. Links created without a
+	// title get the one of their destination page once it is fetched.
+	Title string `json:"title,omitempty"`
+	Note  string `json:"note,omitempty"`
+	// CreatedAt is when the link was created. Links created before it was
+	// recorded have none.
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	// Version counts the changes to the destination, targeting and window of
+	// the link, see LinkVersion. UpdatedAt and UpdatedBy are the time and API
+	// key of the last one.
+	Version   int64      `json:"version,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	// Disabled stops the link redirecting until it is enabled again.
+	Disabled bool `json:"disabled,omitempty"`
+	// Shared marks a link whose code is derived from its URL alone, which
+	// every request shortening that URL without settings of its own gets
+	// and saves again. It cannot be changed, as the next of those requests
+	// would undo the change.
+	Shared bool `json:"shared,omitempty"`
+	// Alias marks a code chosen by the client, which must never overwrite an
+	// existing link.
+	Alias bool `json:"-"`
+	// TTL is how long the link lives. Zero means the storage default.
+	TTL time.Duration `json:"-"`
+	// PasswordHash is the bcrypt hash of the passphrase that unlocks the
+	// link. Empty for public links.
+	PasswordHash string `json:"password_hash,omitempty"`
+	// MaxClicks is how many redirects the link allows before answering 410
+	// Gone. Zero means unlimited.
+	MaxClicks int64 `json:"max_clicks,omitempty"`
+	// ActiveFrom and ActiveUntil bound the window in which the link
+	// redirects. Either may be nil for an open-ended window.
+	ActiveFrom  *time.Time `json:"active_from,omitempty"`
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+	// InactiveMode is how the link answers outside its window, one of the
+	// Inactive constants. Empty means the server default.
+	InactiveMode string `json:"inactive_mode,omitempty"`
+	// FallbackURL is where visitors go when the link cannot send them to
+	// OriginalURL: it is disabled, out of clicks, broken, past its window,
+	// or outside it with InactiveMode InactiveFallback. Empty means the
+	// fallback URL of its domain.
+	FallbackURL string `json:"fallback_url,omitempty"`
+	// Targets are checked in order on every redirect. Visitors matching
+	// none of them go to OriginalURL.
+	Targets []TargetRule `json:"targets,omitempty"`
+	// Variants split the visitors that match no target between several
+	// weighted URLs, replacing OriginalURL for them.
+	Variants []Variant `json:"variants,omitempty"`
+	// ForwardQuery merges the query string of the request into the
+	// destination, QueryConflict deciding which value a parameter set by
+	// both keeps. Empty means QueryConflictLink.
+	ForwardQuery  bool   `json:"forward_query,omitempty"`
+	QueryConflict string `json:"query_conflict,omitempty"`
+	// Wildcard appends the path that follows the code to the destination,
+	// so that /code/docs/intro redirects to the docs/intro page under it.
+	Wildcard bool `json:"wildcard,omitempty"`
+	// Preview forces the preview page on or off for this link. Nil leaves
+	// it to the PreviewPolicy of the server.
+	Preview *bool `json:"preview,omitempty"`
+	// CreatorTrust is the trust level of whoever created the link, one of
+	// the Trust constants. Empty means trusted.
+	CreatorTrust string `json:"creator_trust,omitempty"`
+}
+
+// ValidateAlias checks that a client-chosen code is safe to use in a URL path
+// and does not shadow a reserved route.
+func ValidateAlias(alias string, reserved *ReservedWords) error {
+	if !aliasPattern.MatchString(alias) {
+		return ErrInvalidAlias
+	}
+	if reserved.Contains(alias) {
+		return ErrReservedAlias
+	}
+	return nil
+}
+
+// IsAbsoluteURL reports whether raw is an http or https URL with a host, one
+// that visitors can be redirected to.
+func IsAbsoluteURL(raw string) bool {
+	parsed, err := url.ParseRequestURI(raw)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
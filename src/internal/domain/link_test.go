@@ -0,0 +1,50 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAlias(t *testing.T) {
+	tests := []struct {
+		name        string
+		alias       string
+		expectError bool
+	}{
+		{
+			name:        "WhenAliasIsTooShort_ThenReturnsError",
+			alias:       "ab",
+			expectError: true,
+		},
+		{
+			name:        "WhenAliasHasInvalidCharacters_ThenReturnsError",
+			alias:       "summer/sale",
+			expectError: true,
+		},
+		{
+			name:        "WhenAliasIsValid_ThenReturnsNil",
+			alias:       "summer-sale_2024",
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := domain.ValidateAlias(tt.alias, nil)
+			if tt.expectError {
+				assert.ErrorIs(t, err, domain.ErrInvalidAlias)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateAliasWhenAliasIsReserved_ThenReturnsErrReservedAlias(t *testing.T) {
+	reserved := domain.NewReservedWords("healthz")
+
+	assert.ErrorIs(t, domain.ValidateAlias("HealthZ", reserved), domain.ErrReservedAlias)
+	assert.NoError(t, domain.ValidateAlias("health", reserved))
+}
@@ -0,0 +1,198 @@
+package domain
+
+import (
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+var ErrInvalidLinkQuery = errors.New("invalid link query")
+
+// Orders of a link listing, newest or most clicked first.
+const (
+	SortCreated = "created"
+	SortClicks  = "clicks"
+)
+
+// Statuses of a link in a listing. Expired links are past their window or
+// out of clicks; they are kept until their TTL, see Link.TTL.
+const (
+	StatusActive   = "active"
+	StatusPending  = "pending"
+	StatusExpired  = "expired"
+	StatusDisabled = "disabled"
+)
+
+const (
+	DefaultLinkLimit = 50
+	MaxLinkLimit     = 500
+)
+
+var statuses = []string{StatusActive, StatusPending, StatusExpired, StatusDisabled}
+
+// LinkIndexKey holds the storage keys of the links of workspace, scored by
+// the Unix time in milliseconds when they were created for SortCreated, or
+// by their clicks for SortClicks.
+func LinkIndexKey(workspace string, sort string) string {
+	return indexKey("link_index:"+sort, workspace)
+}
+
+// indexKey is the key of the index name of workspace. Names never contain
+// ':' after their prefix, so the workspace cannot be mistaken for a part of
+// them.
+func indexKey(name string, workspace string) string {
+	if workspace == "" {
+		return name
+	}
+	return name + ":" + workspace
+}
+
+// ListedLink is a link as a listing shows it, with its clicks.
+type ListedLink struct {
+	Link   Link
+	Clicks int64
+	// Exhausted reports whether a link with MaxClicks has none left.
+	Exhausted bool
+}
+
+// Status is the status of the link at now, one of the Status constants.
+func (l ListedLink) Status(now time.Time) string {
+	switch {
+	case l.Link.Disabled:
+		return StatusDisabled
+	case l.Exhausted:
+		return StatusExpired
+	}
+	switch l.Link.State(now) {
+	case LinkPending:
+		return StatusPending
+	case LinkEnded:
+		return StatusExpired
+	}
+	return StatusActive
+}
+
+// LinkQuery selects the links of a workspace. Empty filters match every link.
+type LinkQuery struct {
+	Workspace string
+	// Owner is the name of the API key that created the links.
+	Owner string
+	// Tag and Campaign limit the links to those tagged Tag and in Campaign.
+	Tag      string
+	Campaign string
+	// Domain is the namespace of the domain of the links, see ShortDomain.
+	// Nil matches every domain.
+	Domain *string
+	// CreatedFrom and CreatedTo bound the creation time of the links, both
+	// included.
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	Status      string
+	// Host is the host name of the destination of the links.
+	Host string
+	// Search is a case-insensitive substring of the destination, title or
+	// note of the links.
+	Search string
+	Sort   string
+	// Cursor continues a previous query after the links it returned.
+	Cursor string
+	Limit  int
+}
+
+// Validate checks the query and fills in its default sort and limit.
+func (q *LinkQuery) Validate() error {
+	if q.Sort == "" {
+		q.Sort = SortCreated
+	}
+	if q.Sort != SortCreated && q.Sort != SortClicks {
+		return Detailed(ErrInvalidLinkQuery, "sort must be %s or %s", SortCreated, SortClicks)
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultLinkLimit
+	}
+	if q.Limit < 1 || q.Limit > MaxLinkLimit {
+		return Detailed(ErrInvalidLinkQuery, "limit must be between 1 and %d", MaxLinkLimit)
+	}
+	if q.Status != "" && !slices.Contains(statuses, q.Status) {
+		return Detailed(ErrInvalidLinkQuery, "status must be one of %s", strings.Join(statuses, ", "))
+	}
+	if q.CreatedFrom != nil && q.CreatedTo != nil && q.CreatedTo.Before(*q.CreatedFrom) {
+		return Detailed(ErrInvalidLinkQuery, "created_to must not be before created_from")
+	}
+	q.Tag = strings.ToLower(q.Tag)
+	if q.Tag != "" && !groupPattern.MatchString(q.Tag) {
+		return Detailed(ErrInvalidLinkQuery, "tag is not valid")
+	}
+	q.Campaign = strings.ToLower(q.Campaign)
+	if q.Campaign != "" && !groupPattern.MatchString(q.Campaign) {
+		return Detailed(ErrInvalidLinkQuery, "campaign is not valid")
+	}
+	q.Host = strings.ToLower(q.Host)
+	q.Search = strings.ToLower(q.Search)
+	return nil
+}
+
+// Matches reports whether listed passes the filters of the query at now.
+// Links created before they recorded their creation time never match a
+// creation range.
+func (q LinkQuery) Matches(listed ListedLink, now time.Time) bool {
+	link := listed.Link
+	if q.Owner != "" && link.CreatedBy != q.Owner {
+		return false
+	}
+	if q.Tag != "" && !slices.Contains(link.Tags, q.Tag) {
+		return false
+	}
+	if q.Campaign != "" && link.Campaign != q.Campaign {
+		return false
+	}
+	if q.Domain != nil && link.Domain != *q.Domain {
+		return false
+	}
+	if q.CreatedFrom != nil && (link.CreatedAt == nil || link.CreatedAt.Before(*q.CreatedFrom)) {
+		return false
+	}
+	if q.CreatedTo != nil && (link.CreatedAt == nil || link.CreatedAt.After(*q.CreatedTo)) {
+		return false
+	}
+	if q.Status != "" && listed.Status(now) != q.Status {
+		return false
+	}
+	if q.Host != "" {
+		destination, err := url.Parse(link.OriginalURL)
+		if err != nil || strings.ToLower(destination.Hostname()) != q.Host {
+			return false
+		}
+	}
+	if q.Search == "" {
+		return true
+	}
+	for _, text := range []string{link.OriginalURL, link.Title, link.Note} {
+		if strings.Contains(strings.ToLower(text), q.Search) {
+			return true
+		}
+	}
+	return false
+}
+
+// Index is the storage key of the index the links of the query are read
+// from: the index of the sort, or the index of the tag or campaign of the
+// query when listing the newest links, which holds fewer links to read.
+func (q LinkQuery) Index() string {
+	switch {
+	case q.Sort == SortCreated && q.Tag != "":
+		return TagIndexKey(q.Workspace, q.Tag)
+	case q.Sort == SortCreated && q.Campaign != "":
+		return CampaignIndexKey(q.Workspace, q.Campaign)
+	}
+	return LinkIndexKey(q.Workspace, q.Sort)
+}
+
+// LinkPage is a page of links. NextCursor continues it and is empty on the
+// last page.
+type LinkPage struct {
+	Links      []ListedLink
+	NextCursor string
+}
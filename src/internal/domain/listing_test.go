@@ -0,0 +1,63 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListedLinkStatus(t *testing.T) {
+	now := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	later, earlier := now.Add(time.Hour), now.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		listed domain.ListedLink
+		want   string
+	}{
+		{name: "WhenLinkHasNoWindow_ThenIsActive", listed: domain.ListedLink{}, want: domain.StatusActive},
+		{name: "WhenWindowIsNotOpenYet_ThenIsPending", listed: domain.ListedLink{Link: domain.Link{ActiveFrom: &later}}, want: domain.StatusPending},
+		{name: "WhenWindowHasClosed_ThenIsExpired", listed: domain.ListedLink{Link: domain.Link{ActiveUntil: &earlier}}, want: domain.StatusExpired},
+		{name: "WhenClicksAreUsedUp_ThenIsExpired", listed: domain.ListedLink{Link: domain.Link{MaxClicks: 1}, Exhausted: true}, want: domain.StatusExpired},
+		{name: "WhenDisabled_ThenIsDisabledWhateverItsWindow", listed: domain.ListedLink{Link: domain.Link{Disabled: true, ActiveUntil: &earlier}}, want: domain.StatusDisabled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.listed.Status(now))
+		})
+	}
+}
+
+func TestLinkQueryValidate(t *testing.T) {
+	query := domain.LinkQuery{Host: "Shop.Example.com", Search: "SALE", Tag: "Summer"}
+	assert.NoError(t, query.Validate())
+	assert.Equal(t, domain.LinkQuery{Host: "shop.example.com", Search: "sale", Tag: "summer", Sort: domain.SortCreated, Limit: domain.DefaultLinkLimit}, query)
+
+	from := time.Date(2030, 6, 2, 0, 0, 0, 0, time.UTC)
+	to := from.Add(-time.Hour)
+	for _, query := range []domain.LinkQuery{{Sort: "title"}, {Limit: domain.MaxLinkLimit + 1}, {Status: "broken"}, {CreatedFrom: &from, CreatedTo: &to}, {Tag: "a:b"}, {Campaign: "q3 launch"}} {
+		assert.ErrorIs(t, query.Validate(), domain.ErrInvalidLinkQuery)
+	}
+}
+
+func TestLinkQueryIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		query domain.LinkQuery
+		want  string
+	}{
+		{name: "WhenNoGroupIsSet_ThenReadsTheIndexOfTheSort", query: domain.LinkQuery{Workspace: "marketing", Sort: domain.SortClicks}, want: "link_index:clicks:marketing"},
+		{name: "WhenTagIsSet_ThenReadsTheIndexOfTheTag", query: domain.LinkQuery{Workspace: "marketing", Sort: domain.SortCreated, Tag: "summer", Campaign: "launch"}, want: "link_tag:summer:marketing"},
+		{name: "WhenCampaignIsSet_ThenReadsTheIndexOfTheCampaign", query: domain.LinkQuery{Sort: domain.SortCreated, Campaign: "launch"}, want: "link_campaign:launch"},
+		{name: "WhenSortedByClicks_ThenReadsTheClicksIndexWhateverTheGroup", query: domain.LinkQuery{Sort: domain.SortClicks, Tag: "summer"}, want: "link_index:clicks"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.query.Index())
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Conflict policies for a query parameter set both by the request and by the
+// destination of a link that forwards the query.
+const (
+	// QueryConflictLink keeps the value of the destination.
+	QueryConflictLink = "link"
+	// QueryConflictRequest replaces it with the value of the request.
+	QueryConflictRequest = "request"
+	// QueryConflictBoth keeps both, the destination values first.
+	QueryConflictBoth = "both"
+)
+
+var (
+	ErrInvalidPassthrough = errors.New("invalid passthrough options")
+	ErrUnsafePath         = errors.New("path must not contain '.' or '..' segments")
+)
+
+// ValidatePassthrough checks the query and path forwarding options of a link.
+func (l Link) ValidatePassthrough() error {
+	switch l.QueryConflict {
+	case "":
+		return nil
+	case QueryConflictLink, QueryConflictRequest, QueryConflictBoth:
+	default:
+		return Detailed(ErrInvalidPassthrough, "query_conflict must be %s, %s or %s",
+			QueryConflictLink, QueryConflictRequest, QueryConflictBoth)
+	}
+	if !l.ForwardQuery {
+		return Detailed(ErrInvalidPassthrough, "query_conflict needs forward_query")
+	}
+	return nil
+}
+
+// Forward applies the passthrough options of the link to destination. In
+// wildcard mode the path that followed the code is appended to the path of
+// the destination, and with ForwardQuery the query of the request is merged
+// into its query.
+func (l Link) Forward(destination string, path string, query url.Values) (string, error) {
+	path = strings.Trim(path, "/")
+	if (!l.Wildcard || path == "") && (!l.ForwardQuery || len(query) == 0) {
+		return destination, nil
+	}
+
+	target, err := url.Parse(destination)
+	if err != nil {
+		return "", fmt.Errorf("parsing the destination | Code %s --> %w", l.Code, err)
+	}
+
+	if l.Wildcard && path != "" {
+		// JoinPath resolves dot segments, which would let a visitor climb
+		// out of the path the link points to.
+		for _, segment := range strings.Split(path, "/") {
+			if segment == "." || segment == ".." {
+				return "", ErrUnsafePath
+			}
+		}
+		target = target.JoinPath(path)
+	}
+
+	if l.ForwardQuery && len(query) > 0 {
+		target.RawQuery = mergeQuery(target.Query(), query, l.QueryConflict).Encode()
+	}
+	return target.String(), nil
+}
+
+func mergeQuery(destination url.Values, request url.Values, conflict string) url.Values {
+	for key, values := range request {
+		_, set := destination[key]
+		switch {
+		case !set, conflict == QueryConflictRequest:
+			destination[key] = values
+		case conflict == QueryConflictBoth:
+			destination[key] = append(destination[key], values...)
+		}
+	}
+	return destination
+}
@@ -0,0 +1,128 @@
+package domain_test
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForward(t *testing.T) {
+	tests := []struct {
+		name        string
+		link        domain.Link
+		destination string
+		path        string
+		query       string
+		expected    string
+		expectedErr error
+	}{
+		{
+			name:        "WhenLinkForwardsNothing_ThenReturnsTheDestination",
+			link:        domain.Link{},
+			destination: "https://example.com/landing?ref=short",
+			path:        "/docs",
+			query:       "utm_source=mail",
+			expected:    "https://example.com/landing?ref=short",
+		},
+		{
+			name:        "WhenQueryIsForwarded_ThenAddsTheRequestParameters",
+			link:        domain.Link{ForwardQuery: true},
+			destination: "https://example.com/landing?ref=short",
+			query:       "utm_source=mail",
+			expected:    "https://example.com/landing?ref=short&utm_source=mail",
+		},
+		{
+			name:        "WhenParametersConflictAndTheLinkWins_ThenKeepsTheDestinationValue",
+			link:        domain.Link{ForwardQuery: true, QueryConflict: domain.QueryConflictLink},
+			destination: "https://example.com/landing?ref=short",
+			query:       "ref=mail",
+			expected:    "https://example.com/landing?ref=short",
+		},
+		{
+			name:        "WhenParametersConflictAndTheRequestWins_ThenUsesTheRequestValue",
+			link:        domain.Link{ForwardQuery: true, QueryConflict: domain.QueryConflictRequest},
+			destination: "https://example.com/landing?ref=short",
+			query:       "ref=mail",
+			expected:    "https://example.com/landing?ref=mail",
+		},
+		{
+			name:        "WhenParametersConflictAndBothAreKept_ThenAppendsTheRequestValue",
+			link:        domain.Link{ForwardQuery: true, QueryConflict: domain.QueryConflictBoth},
+			destination: "https://example.com/landing?ref=short",
+			query:       "ref=mail",
+			expected:    "https://example.com/landing?ref=short&ref=mail",
+		},
+		{
+			name:        "WhenParametersNeedEscaping_ThenEncodesThem",
+			link:        domain.Link{ForwardQuery: true},
+			destination: "https://example.com/landing",
+			query:       "q=a%26b%3Dc&next=%2Fhome",
+			expected:    "https://example.com/landing?next=%2Fhome&q=a%26b%3Dc",
+		},
+		{
+			name:        "WhenLinkIsAWildcard_ThenAppendsThePath",
+			link:        domain.Link{Wildcard: true},
+			destination: "https://example.com/docs?lang=en#top",
+			path:        "/getting-started/install",
+			expected:    "https://example.com/docs/getting-started/install?lang=en#top",
+		},
+		{
+			name:        "WhenPathHasDotSegments_ThenReturnsError",
+			link:        domain.Link{Wildcard: true},
+			destination: "https://example.com/docs",
+			path:        "/../admin",
+			expectedErr: domain.ErrUnsafePath,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+
+			forwarded, err := tt.link.Forward(tt.destination, tt.path, query)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, forwarded)
+		})
+	}
+}
+
+func TestValidatePassthrough(t *testing.T) {
+	tests := []struct {
+		name        string
+		link        domain.Link
+		expectError bool
+	}{
+		{
+			name: "WhenOptionsAreValid_ThenReturnsNil",
+			link: domain.Link{ForwardQuery: true, QueryConflict: domain.QueryConflictBoth, Wildcard: true},
+		},
+		{
+			name:        "WhenConflictPolicyIsUnknown_ThenReturnsError",
+			link:        domain.Link{ForwardQuery: true, QueryConflict: "merge"},
+			expectError: true,
+		},
+		{
+			name:        "WhenConflictPolicyIsSetWithoutForwarding_ThenReturnsError",
+			link:        domain.Link{QueryConflict: domain.QueryConflictRequest},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.link.ValidatePassthrough()
+			if tt.expectError {
+				assert.ErrorIs(t, err, domain.ErrInvalidPassthrough)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
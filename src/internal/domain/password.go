@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordMinLength = 4
+	// PasswordMaxLength is the longest input bcrypt takes into account.
+	PasswordMaxLength = 72
+)
+
+var (
+	ErrInvalidPassword = fmt.Errorf("password must be between %d and %d bytes long", PasswordMinLength, PasswordMaxLength)
+	ErrTooManyAttempts = errors.New("too many failed password attempts")
+)
+
+// HashPassword returns the bcrypt hash stored on a protected link.
+func HashPassword(password string) (string, error) {
+	if len(password) < PasswordMinLength || len(password) > PasswordMaxLength {
+		return "", ErrInvalidPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("hashing the password --> %w", err)
+	}
+	return string(hash), nil
+}
+
+// Protected reports whether the link asks for a password before redirecting.
+func (l Link) Protected() bool {
+	return l.PasswordHash != ""
+}
+
+// CheckPassword reports whether password unlocks the link.
+func (l Link) CheckPassword(password string) bool {
+	if !l.Protected() {
+		return true
+	}
+	return bcrypt.CompareHashAndPassword([]byte(l.PasswordHash), []byte(password)) == nil
+}
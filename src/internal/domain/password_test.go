@@ -0,0 +1,82 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashPassword(t *testing.T) {
+	tests := []struct {
+		name        string
+		password    string
+		expectError bool
+	}{
+		{
+			name:        "WhenPasswordIsTooShort_ThenReturnsError",
+			password:    "abc",
+			expectError: true,
+		},
+		{
+			name:        "WhenPasswordIsTooLong_ThenReturnsError",
+			password:    strings.Repeat("a", domain.PasswordMaxLength+1),
+			expectError: true,
+		},
+		{
+			name:        "WhenPasswordIsValid_ThenReturnsAHash",
+			password:    "open sesame",
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := domain.HashPassword(tt.password)
+			if tt.expectError {
+				assert.ErrorIs(t, err, domain.ErrInvalidPassword)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEqual(t, tt.password, hash)
+		})
+	}
+}
+
+func TestCheckPassword(t *testing.T) {
+	hash, err := domain.HashPassword("open sesame")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		link     domain.Link
+		password string
+		expected bool
+	}{
+		{
+			name:     "WhenLinkIsPublic_ThenAnyPasswordUnlocksIt",
+			link:     domain.Link{},
+			password: "",
+			expected: true,
+		},
+		{
+			name:     "WhenPasswordMatches_ThenReturnsTrue",
+			link:     domain.Link{PasswordHash: hash},
+			password: "open sesame",
+			expected: true,
+		},
+		{
+			name:     "WhenPasswordDiffers_ThenReturnsFalse",
+			link:     domain.Link{PasswordHash: hash},
+			password: "open sesame!",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.link.CheckPassword(tt.password))
+		})
+	}
+}
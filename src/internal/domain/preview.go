@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+)
+
+// Which links show a preview page before redirecting, when the link does not
+// decide itself.
+const (
+	PreviewNone      = "none"
+	PreviewUntrusted = "untrusted"
+	PreviewAll       = "all"
+)
+
+// Trust levels of link creators. Links created by untrusted creators show a
+// preview page under PreviewUntrusted.
+const (
+	TrustTrusted   = "trusted"
+	TrustUntrusted = "untrusted"
+)
+
+var ErrInvalidTrust = errors.New("creator trust must be trusted or untrusted")
+
+// ValidateTrust checks a creator trust level. Empty means trusted.
+func ValidateTrust(trust string) error {
+	switch trust {
+	case "", TrustTrusted, TrustUntrusted:
+		return nil
+	}
+	return ErrInvalidTrust
+}
+
+// PreviewPolicy decides which redirects go through a page showing the
+// destination first. Destinations on SafeDomains, or any of their
+// subdomains, never do.
+type PreviewPolicy struct {
+	Mode        string
+	SafeDomains []string
+}
+
+// Show reports whether a visitor to link is shown the preview page before
+// being sent to destination. The link setting wins over the creator trust,
+// which wins over the mode, but links of untrusted creators can only turn the
+// page on, never off.
+func (p PreviewPolicy) Show(link Link, destination string) bool {
+	if p.safe(destination) {
+		return false
+	}
+	if link.Preview != nil && (*link.Preview || link.CreatorTrust != TrustUntrusted) {
+		return *link.Preview
+	}
+	switch p.Mode {
+	case PreviewAll:
+		return true
+	case PreviewUntrusted:
+		return link.CreatorTrust == TrustUntrusted
+	}
+	return false
+}
+
+func (p PreviewPolicy) safe(destination string) bool {
+	target, err := url.Parse(destination)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(target.Hostname())
+	for _, domain := range p.SafeDomains {
+		domain = strings.ToLower(domain)
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewPolicyShow(t *testing.T) {
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name        string
+		policy      domain.PreviewPolicy
+		link        domain.Link
+		destination string
+		expected    bool
+	}{
+		{
+			name:        "WhenModeIsNone_ThenSkipsThePage",
+			policy:      domain.PreviewPolicy{Mode: domain.PreviewNone},
+			link:        domain.Link{CreatorTrust: domain.TrustUntrusted},
+			destination: "https://example.com",
+			expected:    false,
+		},
+		{
+			name:        "WhenModeIsUntrustedAndTheCreatorIsUntrusted_ThenShowsThePage",
+			policy:      domain.PreviewPolicy{Mode: domain.PreviewUntrusted},
+			link:        domain.Link{CreatorTrust: domain.TrustUntrusted},
+			destination: "https://example.com",
+			expected:    true,
+		},
+		{
+			name:        "WhenModeIsUntrustedAndTheCreatorIsTrusted_ThenSkipsThePage",
+			policy:      domain.PreviewPolicy{Mode: domain.PreviewUntrusted},
+			link:        domain.Link{},
+			destination: "https://example.com",
+			expected:    false,
+		},
+		{
+			name:        "WhenModeIsAll_ThenShowsThePage",
+			policy:      domain.PreviewPolicy{Mode: domain.PreviewAll},
+			link:        domain.Link{},
+			destination: "https://example.com",
+			expected:    true,
+		},
+		{
+			name:        "WhenTheLinkEnablesThePage_ThenShowsItWhateverTheMode",
+			policy:      domain.PreviewPolicy{Mode: domain.PreviewNone},
+			link:        domain.Link{Preview: &enabled},
+			destination: "https://example.com",
+			expected:    true,
+		},
+		{
+			name:        "WhenAnUntrustedCreatorDisablesThePage_ThenShowsItAllTheSame",
+			policy:      domain.PreviewPolicy{Mode: domain.PreviewUntrusted},
+			link:        domain.Link{Preview: &disabled, CreatorTrust: domain.TrustUntrusted},
+			destination: "https://example.com",
+			expected:    true,
+		},
+		{
+			name:        "WhenTheLinkDisablesThePage_ThenSkipsItWhateverTheMode",
+			policy:      domain.PreviewPolicy{Mode: domain.PreviewAll},
+			link:        domain.Link{Preview: &disabled},
+			destination: "https://example.com",
+			expected:    false,
+		},
+		{
+			name:        "WhenTheDestinationIsOnASafeDomain_ThenSkipsThePage",
+			policy:      domain.PreviewPolicy{Mode: domain.PreviewAll, SafeDomains: []string{"example.com"}},
+			link:        domain.Link{Preview: &enabled},
+			destination: "https://docs.Example.com/start",
+			expected:    false,
+		},
+		{
+			name:        "WhenTheDestinationOnlyEndsLikeASafeDomain_ThenShowsThePage",
+			policy:      domain.PreviewPolicy{Mode: domain.PreviewAll, SafeDomains: []string{"example.com"}},
+			link:        domain.Link{},
+			destination: "https://badexample.com",
+			expected:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.Show(tt.link, tt.destination))
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+const (
+	QRFormatPNG = "png"
+	QRFormatSVG = "svg"
+
+	QRMinSize   = 64
+	QRMaxSize   = 2048
+	QRMaxMargin = 16
+)
+
+var ErrInvalidQROptions = errors.New("invalid QR code options")
+
+// QROptions describes how a QR code is rendered. Size is the side of the
+// image in pixels and Margin the quiet zone in modules. Level is one of the
+// QR error-correction levels L, M, Q or H. Colors are hex RGB values.
+type QROptions struct {
+	Format     string
+	Size       int
+	Level      string
+	Margin     int
+	Foreground string
+	Background string
+}
+
+// DefaultQROptions returns the options used when the client sets none.
+func DefaultQROptions() QROptions {
+	return QROptions{
+		Format:     QRFormatPNG,
+		Size:       256,
+		Level:      "M",
+		Margin:     4,
+		Foreground: "#000000",
+		Background: "#ffffff",
+	}
+}
+
+// Validate reports the first option that cannot be rendered.
+func (o QROptions) Validate() error {
+	if o.Format != QRFormatPNG && o.Format != QRFormatSVG {
+		return Detailed(ErrInvalidQROptions, "format must be png or svg")
+	}
+	if o.Size < QRMinSize || o.Size > QRMaxSize {
+		return Detailed(ErrInvalidQROptions, "size must be between %d and %d", QRMinSize, QRMaxSize)
+	}
+	if !strings.Contains("LMQH", o.Level) || len(o.Level) != 1 {
+		return Detailed(ErrInvalidQROptions, "level must be one of L, M, Q or H")
+	}
+	if o.Margin < 0 || o.Margin > QRMaxMargin {
+		return Detailed(ErrInvalidQROptions, "margin must be between 0 and %d", QRMaxMargin)
+	}
+	if _, err := ParseHexColor(o.Foreground); err != nil {
+		return Detailed(ErrInvalidQROptions, "foreground %s", err)
+	}
+	if _, err := ParseHexColor(o.Background); err != nil {
+		return Detailed(ErrInvalidQROptions, "background %s", err)
+	}
+	return nil
+}
+
+// CacheKey identifies a rendered image of content with these options.
+func (o QROptions) CacheKey(content string) string {
+	return fmt.Sprintf("%s|%d|%s|%d|%s|%s|%s", o.Format, o.Size, o.Level, o.Margin,
+		strings.ToLower(o.Foreground), strings.ToLower(o.Background), content)
+}
+
+// ParseHexColor reads an "#rrggbb" or "rrggbb" color.
+func ParseHexColor(hex string) (color.RGBA, error) {
+	hex = strings.TrimPrefix(hex, "#")
+	var c color.RGBA
+	if len(hex) != 6 {
+		return c, errors.New("must be a 6 digit hex color")
+	}
+	if _, err := fmt.Sscanf(hex, "%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, errors.New("must be a 6 digit hex color")
+	}
+	c.A = 0xff
+	return c, nil
+}
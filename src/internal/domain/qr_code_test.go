@@ -0,0 +1,66 @@
+package domain_test
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQROptionsValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		options     func(o *domain.QROptions)
+		expectError bool
+	}{
+		{
+			name:        "WhenOptionsAreTheDefaults_ThenReturnsNil",
+			options:     func(o *domain.QROptions) {},
+			expectError: false,
+		},
+		{
+			name:        "WhenSizeIsOutOfRange_ThenReturnsError",
+			options:     func(o *domain.QROptions) { o.Size = 10 },
+			expectError: true,
+		},
+		{
+			name:        "WhenLevelIsUnknown_ThenReturnsError",
+			options:     func(o *domain.QROptions) { o.Level = "LM" },
+			expectError: true,
+		},
+		{
+			name:        "WhenMarginIsNegative_ThenReturnsError",
+			options:     func(o *domain.QROptions) { o.Margin = -1 },
+			expectError: true,
+		},
+		{
+			name:        "WhenColorIsMalformed_ThenReturnsError",
+			options:     func(o *domain.QROptions) { o.Background = "#fff" },
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := domain.DefaultQROptions()
+			tt.options(&options)
+
+			err := options.Validate()
+			if tt.expectError {
+				assert.ErrorIs(t, err, domain.ErrInvalidQROptions)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseHexColor(t *testing.T) {
+	parsed, err := domain.ParseHexColor("#1a2B3c")
+	assert.NoError(t, err)
+	assert.Equal(t, color.RGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}, parsed)
+
+	_, err = domain.ParseHexColor("zzzzzz")
+	assert.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultReservedWords are kept out of the code namespace even before a
+// route uses them, so that adding those routes never shadows a link.
+var DefaultReservedWords = []string{
+	"api",
+	"createLink",
+	"docs",
+	"favicon.ico",
+	"healthz",
+	"links",
+	"metrics",
+	"openapi.json",
+	"robots.txt",
+}
+
+// ReservedWords is the set of path segments that short codes must never take,
+// because a system route already uses or will use them. Matching ignores case
+// so that "API" cannot be registered next to "/api".
+type ReservedWords struct {
+	mu    sync.RWMutex
+	words map[string]string
+}
+
+func NewReservedWords(words ...string) *ReservedWords {
+	reserved := &ReservedWords{words: make(map[string]string)}
+	reserved.Add(words...)
+	return reserved
+}
+
+func (r *ReservedWords) Add(words ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, word := range words {
+		if word != "" {
+			r.words[strings.ToLower(word)] = word
+		}
+	}
+}
+
+// Contains is safe to call on a nil registry, which reserves nothing.
+func (r *ReservedWords) Contains(code string) bool {
+	if r == nil {
+		return false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.words[strings.ToLower(code)]
+	return ok
+}
+
+// Words returns the reserved words, sorted, as they were added.
+func (r *ReservedWords) Words() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	words := make([]string, 0, len(r.words))
+	for _, word := range r.words {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+	return words
+}
@@ -0,0 +1,25 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReservedWords(t *testing.T) {
+	reserved := domain.NewReservedWords("api", "docs")
+	reserved.Add("openapi.json", "")
+
+	assert.True(t, reserved.Contains("API"))
+	assert.True(t, reserved.Contains("openapi.json"))
+	assert.False(t, reserved.Contains("apis"))
+	assert.False(t, reserved.Contains(""))
+	assert.Equal(t, []string{"api", "docs", "openapi.json"}, reserved.Words())
+}
+
+func TestReservedWordsWhenRegistryIsNil_ThenReservesNothing(t *testing.T) {
+	var reserved *domain.ReservedWords
+
+	assert.False(t, reserved.Contains("api"))
+}
@@ -0,0 +1,61 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// States of a link with regard to its activation window.
+const (
+	LinkPending = "pending"
+	LinkActive  = "active"
+	LinkEnded   = "ended"
+)
+
+// Responses of a link outside its activation window.
+const (
+	InactiveNotFound   = "not_found"
+	InactiveComingSoon = "coming_soon"
+	InactiveFallback   = "fallback"
+)
+
+var (
+	ErrLinkInactive    = errors.New("link is not active")
+	ErrInvalidSchedule = errors.New("invalid activation window")
+)
+
+// State reports whether the window of the link has not opened yet, is open or
+// has closed at now. Links without a window are always active.
+func (l Link) State(now time.Time) string {
+	if l.ActiveFrom != nil && now.Before(*l.ActiveFrom) {
+		return LinkPending
+	}
+	if l.ActiveUntil != nil && !now.Before(*l.ActiveUntil) {
+		return LinkEnded
+	}
+	return LinkActive
+}
+
+// ValidateSchedule checks the activation window and the inactive response of
+// the link.
+func (l Link) ValidateSchedule() error {
+	if l.ActiveFrom != nil && l.ActiveUntil != nil && !l.ActiveUntil.After(*l.ActiveFrom) {
+		return Detailed(ErrInvalidSchedule, "active_until must be after active_from")
+	}
+	switch l.InactiveMode {
+	case "", InactiveNotFound, InactiveComingSoon:
+	case InactiveFallback:
+		if l.FallbackURL == "" {
+			return Detailed(ErrInvalidSchedule, "fallback_url is required when inactive_mode is fallback")
+		}
+	default:
+		return Detailed(ErrInvalidSchedule, "inactive_mode must be one of %s, %s or %s",
+			InactiveNotFound, InactiveComingSoon, InactiveFallback)
+	}
+	if l.FallbackURL != "" {
+		if !IsAbsoluteURL(l.FallbackURL) {
+			return Detailed(ErrInvalidSchedule, "fallback_url is not valid")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestState(t *testing.T) {
+	from := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 30, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		link     domain.Link
+		now      time.Time
+		expected string
+	}{
+		{
+			name:     "WhenLinkHasNoWindow_ThenIsActive",
+			link:     domain.Link{},
+			now:      from,
+			expected: domain.LinkActive,
+		},
+		{
+			name:     "WhenWindowHasNotOpened_ThenIsPending",
+			link:     domain.Link{ActiveFrom: &from, ActiveUntil: &until},
+			now:      from.Add(-time.Second),
+			expected: domain.LinkPending,
+		},
+		{
+			name:     "WhenWindowOpensNow_ThenIsActive",
+			link:     domain.Link{ActiveFrom: &from, ActiveUntil: &until},
+			now:      from,
+			expected: domain.LinkActive,
+		},
+		{
+			name:     "WhenWindowClosesNow_ThenHasEnded",
+			link:     domain.Link{ActiveFrom: &from, ActiveUntil: &until},
+			now:      until,
+			expected: domain.LinkEnded,
+		},
+		{
+			name:     "WhenWindowHasNoEnd_ThenStaysActive",
+			link:     domain.Link{ActiveFrom: &from},
+			now:      until.AddDate(10, 0, 0),
+			expected: domain.LinkActive,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.link.State(tt.now))
+		})
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	from := time.Date(2024, 6, 1, 9, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 6, 30, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		link        domain.Link
+		expectError bool
+	}{
+		{
+			name:        "WhenWindowIsReversed_ThenReturnsError",
+			link:        domain.Link{ActiveFrom: &until, ActiveUntil: &from},
+			expectError: true,
+		},
+		{
+			name:        "WhenModeIsUnknown_ThenReturnsError",
+			link:        domain.Link{InactiveMode: "teapot"},
+			expectError: true,
+		},
+		{
+			name:        "WhenFallbackHasNoURL_ThenReturnsError",
+			link:        domain.Link{InactiveMode: domain.InactiveFallback},
+			expectError: true,
+		},
+		{
+			name:        "WhenFallbackURLIsInvalid_ThenReturnsError",
+			link:        domain.Link{InactiveMode: domain.InactiveFallback, FallbackURL: "not a url"},
+			expectError: true,
+		},
+		{
+			name:        "WhenFallbackURLIsRelative_ThenReturnsError",
+			link:        domain.Link{FallbackURL: "/over"},
+			expectError: true,
+		},
+		{
+			name:        "WhenFallbackURLIsNotHTTP_ThenReturnsError",
+			link:        domain.Link{FallbackURL: "javascript:alert(1)"},
+			expectError: true,
+		},
+		{
+			name: "WhenScheduleIsValid_ThenReturnsNil",
+			link: domain.Link{ActiveFrom: &from, ActiveUntil: &until,
+				InactiveMode: domain.InactiveFallback, FallbackURL: "http://example.com/soon"},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.link.ValidateSchedule()
+			if tt.expectError {
+				assert.ErrorIs(t, err, domain.ErrInvalidSchedule)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// LinkStats is the state of a link as reported by the stats endpoint.
+type LinkStats struct {
+	Code      string   `json:"code"`
+	URL       string   `json:"url"`
+	Protected bool     `json:"protected"`
+	Disabled  bool     `json:"disabled,omitempty"`
+	MaxClicks int64    `json:"max_clicks,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Campaign  string   `json:"campaign,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Note      string   `json:"note,omitempty"`
+	// RemainingClicks is only set for links with MaxClicks.
+	RemainingClicks *int64     `json:"remaining_clicks,omitempty"`
+	Window          LinkWindow `json:"window"`
+	// Variants are the current weighted destinations of a split link.
+	Variants []Variant  `json:"variants,omitempty"`
+	Clicks   ClickStats `json:"clicks"`
+	// Health is what the last checks of the destination found, nil until
+	// it is first checked.
+	Health *LinkHealth `json:"health,omitempty"`
+}
+
+// LinkWindow reports when the activation window of a link opens and closes.
+type LinkWindow struct {
+	State        string     `json:"state"`
+	OpensAt      *time.Time `json:"opens_at,omitempty"`
+	ClosesAt     *time.Time `json:"closes_at,omitempty"`
+	InactiveMode string     `json:"inactive_mode"`
+}
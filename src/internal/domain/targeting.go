@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Device classes a targeting rule can match.
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+	DeviceBot     = "bot"
+)
+
+const (
+	// DefaultTarget labels clicks that matched no targeting rule.
+	DefaultTarget  = "default"
+	MaxTargetRules = 20
+)
+
+var ErrInvalidTargets = errors.New("invalid targeting rules")
+
+var countryPattern = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+// Visitor is what is known about whoever follows a link. Country is an ISO
+// 3166-1 alpha-2 code, empty when unknown.
+type Visitor struct {
+	OS      string
+	Device  string
+	Browser string
+	Country string
+	// Key identifies the visitor across requests, and Variant is the
+	// variant they were sent to before, if any. Both keep a visitor on the
+	// same variant of a split link.
+	Key     string
+	Variant string
+}
+
+// TargetRule sends visitors matching every field it sets to URL. Fields are
+// compared case-insensitively with the parsed User-Agent and the country of
+// the client IP, and an empty field matches anything.
+type TargetRule struct {
+	// Name labels the rule in analytics. Unnamed rules are labelled by
+	// position, "rule-1" being the first.
+	Name    string `json:"name,omitempty"`
+	OS      string `json:"os,omitempty"`
+	Device  string `json:"device,omitempty"`
+	Browser string `json:"browser,omitempty"`
+	Country string `json:"country,omitempty"`
+	URL     string `json:"url"`
+}
+
+func (r TargetRule) Matches(v Visitor) bool {
+	return matchesField(r.OS, v.OS) && matchesField(r.Device, v.Device) &&
+		matchesField(r.Browser, v.Browser) && matchesField(r.Country, v.Country)
+}
+
+func matchesField(want string, got string) bool {
+	return want == "" || strings.EqualFold(want, got)
+}
+
+// Label names the rule at index i in analytics.
+func (r TargetRule) Label(i int) string {
+	if r.Name != "" {
+		return r.Name
+	}
+	return "rule-" + strconv.Itoa(i+1)
+}
+
+// ValidateTargets checks an ordered list of targeting rules.
+func ValidateTargets(rules []TargetRule) error {
+	if len(rules) > MaxTargetRules {
+		return Detailed(ErrInvalidTargets, "a link can have at most %d rules", MaxTargetRules)
+	}
+	labels := make(map[string]bool)
+	for i, rule := range rules {
+		if rule.OS == "" && rule.Device == "" && rule.Browser == "" && rule.Country == "" {
+			return Detailed(ErrInvalidTargets, "rule %d must match on os, device, browser or country", i+1)
+		}
+		if rule.Country != "" && !countryPattern.MatchString(rule.Country) {
+			return Detailed(ErrInvalidTargets, "the country of rule %d must be a two letter ISO 3166-1 code", i+1)
+		}
+		switch strings.ToLower(rule.Device) {
+		case "", DeviceMobile, DeviceTablet, DeviceDesktop, DeviceBot:
+		default:
+			return Detailed(ErrInvalidTargets, "the device of rule %d must be one of %s, %s, %s or %s",
+				i+1, DeviceMobile, DeviceTablet, DeviceDesktop, DeviceBot)
+		}
+		if !IsAbsoluteURL(rule.URL) {
+			return Detailed(ErrInvalidTargets, "the url of rule %d is not valid", i+1)
+		}
+		label := rule.Label(i)
+		if label == DefaultTarget || labels[label] {
+			return Detailed(ErrInvalidTargets, "rule name %q is already used", label)
+		}
+		labels[label] = true
+	}
+	return nil
+}
+
+// Destination returns where the visitor is sent: the URL of the first rule
+// that matches, or else the variant picked for them, or else the original
+// URL. The second value labels the rule for analytics and the third names
+// the variant, empty when none was picked.
+func (l Link) Destination(v Visitor) (string, string, string) {
+	for i, rule := range l.Targets {
+		if rule.Matches(v) {
+			return rule.URL, rule.Label(i), ""
+		}
+	}
+	if variant, ok := l.PickVariant(v); ok {
+		return variant.URL, DefaultTarget, variant.Name
+	}
+	return l.OriginalURL, DefaultTarget, ""
+}
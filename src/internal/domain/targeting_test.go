@@ -0,0 +1,131 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDestination(t *testing.T) {
+	link := domain.Link{
+		OriginalURL: "https://example.com",
+		Targets: []domain.TargetRule{
+			{Name: "app-store", OS: "iOS", URL: "https://apps.apple.com/app/id1"},
+			{OS: "android", Device: "mobile", URL: "https://play.google.com/store/apps/details?id=app"},
+			{Name: "spain", Country: "es", URL: "https://example.es"},
+		},
+	}
+
+	tests := []struct {
+		name           string
+		visitor        domain.Visitor
+		expectedURL    string
+		expectedTarget string
+	}{
+		{
+			name:           "WhenVisitorMatchesANamedRule_ThenReturnsItsURLAndName",
+			visitor:        domain.Visitor{OS: "iOS", Device: "mobile", Browser: "Safari"},
+			expectedURL:    "https://apps.apple.com/app/id1",
+			expectedTarget: "app-store",
+		},
+		{
+			name:           "WhenVisitorMatchesAnUnnamedRule_ThenLabelsItByPosition",
+			visitor:        domain.Visitor{OS: "Android", Device: "mobile", Browser: "Chrome"},
+			expectedURL:    "https://play.google.com/store/apps/details?id=app",
+			expectedTarget: "rule-2",
+		},
+		{
+			name:           "WhenOnlySomeFieldsMatch_ThenSkipsTheRule",
+			visitor:        domain.Visitor{OS: "Android", Device: "tablet", Browser: "Chrome"},
+			expectedURL:    "https://example.com",
+			expectedTarget: domain.DefaultTarget,
+		},
+		{
+			name:           "WhenVisitorMatchesACountryRule_ThenReturnsItsURL",
+			visitor:        domain.Visitor{OS: "Windows", Device: "desktop", Browser: "Edge", Country: "ES"},
+			expectedURL:    "https://example.es",
+			expectedTarget: "spain",
+		},
+		{
+			name:           "WhenNoRuleMatches_ThenReturnsTheOriginalURL",
+			visitor:        domain.Visitor{OS: "Windows", Device: "desktop", Browser: "Edge"},
+			expectedURL:    "https://example.com",
+			expectedTarget: domain.DefaultTarget,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url, target, _ := link.Destination(tt.visitor)
+			assert.Equal(t, tt.expectedURL, url)
+			assert.Equal(t, tt.expectedTarget, target)
+		})
+	}
+}
+
+func TestValidateTargets(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       []domain.TargetRule
+		expectError bool
+	}{
+		{
+			name:        "WhenRuleMatchesNothing_ThenReturnsError",
+			rules:       []domain.TargetRule{{URL: "https://example.com"}},
+			expectError: true,
+		},
+		{
+			name:        "WhenDeviceIsUnknown_ThenReturnsError",
+			rules:       []domain.TargetRule{{Device: "fridge", URL: "https://example.com"}},
+			expectError: true,
+		},
+		{
+			name:        "WhenCountryIsNotAnISOCode_ThenReturnsError",
+			rules:       []domain.TargetRule{{Country: "Spain", URL: "https://example.es"}},
+			expectError: true,
+		},
+		{
+			name:        "WhenURLIsInvalid_ThenReturnsError",
+			rules:       []domain.TargetRule{{OS: "iOS", URL: "not a url"}},
+			expectError: true,
+		},
+		{
+			name:        "WhenURLIsRelative_ThenReturnsError",
+			rules:       []domain.TargetRule{{OS: "iOS", URL: "/ios"}},
+			expectError: true,
+		},
+		{
+			name:        "WhenURLIsNotHTTP_ThenReturnsError",
+			rules:       []domain.TargetRule{{OS: "iOS", URL: "ftp://example.com/app"}},
+			expectError: true,
+		},
+		{
+			name: "WhenNamesRepeat_ThenReturnsError",
+			rules: []domain.TargetRule{
+				{Name: "apps", OS: "iOS", URL: "https://example.com/ios"},
+				{Name: "apps", OS: "Android", URL: "https://example.com/android"},
+			},
+			expectError: true,
+		},
+		{
+			name: "WhenRulesAreValid_ThenReturnsNil",
+			rules: []domain.TargetRule{
+				{OS: "iOS", URL: "https://example.com/ios"},
+				{Device: "Desktop", Browser: "firefox", URL: "https://example.com/firefox"},
+			},
+			expectError: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := domain.ValidateTargets(tt.rules)
+			if tt.expectError {
+				assert.ErrorIs(t, err, domain.ErrInvalidTargets)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
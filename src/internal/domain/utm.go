@@ -0,0 +1,148 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const MaxUTMValueLength = 256
+
+var ErrInvalidUTM = errors.New("invalid utm parameters")
+
+// UTMFields are the names of the UTM parameters, without their "utm_" prefix,
+// in the order they are checked.
+var UTMFields = []string{"source", "medium", "campaign", "term", "content"}
+
+// UTM holds the campaign parameters added to a destination URL.
+type UTM struct {
+	Source   string `json:"source,omitempty"`
+	Medium   string `json:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty"`
+	Term     string `json:"term,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+// Field returns the value of the UTM parameter named field, one of UTMFields.
+func (u UTM) Field(field string) string {
+	switch field {
+	case "source":
+		return u.Source
+	case "medium":
+		return u.Medium
+	case "campaign":
+		return u.Campaign
+	case "term":
+		return u.Term
+	case "content":
+		return u.Content
+	}
+	return ""
+}
+
+// Validate checks that at least one parameter is set and that every value is
+// usable as is.
+func (u UTM) Validate() error {
+	empty := true
+	for _, field := range UTMFields {
+		value := u.Field(field)
+		if value == "" {
+			continue
+		}
+		empty = false
+		if strings.TrimSpace(value) != value {
+			return Detailed(ErrInvalidUTM, "%s must not start or end with spaces", field)
+		}
+		if len(value) > MaxUTMValueLength {
+			return Detailed(ErrInvalidUTM, "%s must be at most %d bytes", field, MaxUTMValueLength)
+		}
+	}
+	if empty {
+		return Detailed(ErrInvalidUTM, "at least one parameter must be set")
+	}
+	return nil
+}
+
+// Apply sets the parameters on rawURL as utm_* query parameters, replacing
+// any the URL already had where they were and adding the others at the end.
+// The rest of the URL, its other parameters included, is left as it was, so
+// that signed or order-sensitive URLs keep working.
+func (u UTM) Apply(rawURL string) (string, error) {
+	target, err := url.Parse(rawURL)
+	if err != nil {
+		return "", Detailed(ErrInvalidUTM, "the url is not valid")
+	}
+
+	values := make(map[string]string)
+	for _, field := range UTMFields {
+		if value := u.Field(field); value != "" {
+			values["utm_"+field] = value
+		}
+	}
+
+	var pairs []string
+	if target.RawQuery != "" {
+		for _, pair := range strings.Split(target.RawQuery, "&") {
+			rawKey, _, _ := strings.Cut(pair, "=")
+			key, err := url.QueryUnescape(rawKey)
+			value, ok := values[key]
+			switch {
+			case err != nil || !ok:
+				pairs = append(pairs, pair)
+			case value != "":
+				pairs = append(pairs, url.QueryEscape(key)+"="+url.QueryEscape(value))
+				// Repeats of the parameter are dropped.
+				values[key] = ""
+			}
+		}
+	}
+	for _, field := range UTMFields {
+		if value := values["utm_"+field]; value != "" {
+			pairs = append(pairs, url.QueryEscape("utm_"+field)+"="+url.QueryEscape(value))
+		}
+	}
+	target.RawQuery = strings.Join(pairs, "&")
+	return target.String(), nil
+}
+
+// UTMTemplate is the UTM convention of a team: the parameters every link must
+// set and, per parameter, the only values it may take.
+type UTMTemplate struct {
+	Required []string            `json:"required"`
+	Allowed  map[string][]string `json:"allowed"`
+}
+
+// ValidateTemplate checks that the template only names known parameters.
+func (t UTMTemplate) ValidateTemplate() error {
+	for _, field := range t.Required {
+		if !slices.Contains(UTMFields, field) {
+			return fmt.Errorf("unknown required utm parameter %q", field)
+		}
+	}
+	for field := range t.Allowed {
+		if !slices.Contains(UTMFields, field) {
+			return fmt.Errorf("unknown allowed utm parameter %q", field)
+		}
+	}
+	return nil
+}
+
+// Check reports the first parameter of u that breaks the template.
+func (t UTMTemplate) Check(u UTM) error {
+	for _, field := range t.Required {
+		if u.Field(field) == "" {
+			return Detailed(ErrInvalidUTM, "%s is required", field)
+		}
+	}
+	for _, field := range UTMFields {
+		allowed, ok := t.Allowed[field]
+		value := u.Field(field)
+		if !ok || value == "" || slices.Contains(allowed, value) {
+			continue
+		}
+		return Detailed(ErrInvalidUTM, "%s must be one of %s", field, strings.Join(allowed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUTMApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		utm      domain.UTM
+		url      string
+		expected string
+	}{
+		{
+			name:     "WhenURLHasNoQuery_ThenAddsTheParameters",
+			utm:      domain.UTM{Source: "newsletter", Medium: "email", Campaign: "spring sale"},
+			url:      "https://example.com/landing",
+			expected: "https://example.com/landing?utm_source=newsletter&utm_medium=email&utm_campaign=spring+sale",
+		},
+		{
+			name:     "WhenURLAlreadyHasUTMParameters_ThenReplacesThem",
+			utm:      domain.UTM{Source: "newsletter"},
+			url:      "https://example.com/landing?utm_source=Newsletter&id=7#pricing",
+			expected: "https://example.com/landing?utm_source=newsletter&id=7#pricing",
+		},
+		{
+			name:     "WhenValuesNeedEscaping_ThenEncodesThem",
+			utm:      domain.UTM{Source: "a&b", Content: "x=y"},
+			url:      "https://example.com",
+			expected: "https://example.com?utm_source=a%26b&utm_content=x%3Dy",
+		},
+		{
+			name:     "WhenURLHasOtherParameters_ThenLeavesThemAsTheyWere",
+			utm:      domain.UTM{Source: "newsletter", Medium: "email"},
+			url:      "https://cdn.example.com/file?z=1&a=%2f&utm_medium=old&utm_medium=older&Signature=ab%2Bc",
+			expected: "https://cdn.example.com/file?z=1&a=%2f&utm_medium=email&Signature=ab%2Bc&utm_source=newsletter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			applied, err := tt.utm.Apply(tt.url)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, applied)
+		})
+	}
+}
+
+func TestUTMValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		utm         domain.UTM
+		expectError bool
+	}{
+		{
+			name: "WhenParametersAreValid_ThenReturnsNil",
+			utm:  domain.UTM{Source: "newsletter", Medium: "email"},
+		},
+		{
+			name:        "WhenNoParameterIsSet_ThenReturnsError",
+			utm:         domain.UTM{},
+			expectError: true,
+		},
+		{
+			name:        "WhenAValueHasSurroundingSpaces_ThenReturnsError",
+			utm:         domain.UTM{Source: " newsletter"},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.utm.Validate()
+			if tt.expectError {
+				assert.ErrorIs(t, err, domain.ErrInvalidUTM)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUTMTemplateCheck(t *testing.T) {
+	template := domain.UTMTemplate{
+		Required: []string{"source", "medium", "campaign"},
+		Allowed:  map[string][]string{"medium": {"email", "social", "cpc"}},
+	}
+
+	tests := []struct {
+		name        string
+		utm         domain.UTM
+		expectedErr string
+	}{
+		{
+			name: "WhenUTMFollowsTheTemplate_ThenReturnsNil",
+			utm:  domain.UTM{Source: "newsletter", Medium: "email", Campaign: "spring"},
+		},
+		{
+			name:        "WhenARequiredParameterIsMissing_ThenReturnsError",
+			utm:         domain.UTM{Source: "newsletter", Medium: "email"},
+			expectedErr: "invalid utm parameters: campaign is required",
+		},
+		{
+			name:        "WhenAValueIsNotAllowed_ThenReturnsError",
+			utm:         domain.UTM{Source: "newsletter", Medium: "Email", Campaign: "spring"},
+			expectedErr: "invalid utm parameters: medium must be one of email, social, cpc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := template.Check(tt.utm)
+			if tt.expectedErr != "" {
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
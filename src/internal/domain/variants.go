@@ -0,0 +1,84 @@
+package domain
+
+import (
+	"errors"
+	"hash/fnv"
+	"regexp"
+)
+
+const (
+	MinVariants      = 2
+	MaxVariants      = 10
+	MaxVariantWeight = 1000
+)
+
+var ErrInvalidVariants = errors.New("invalid variants")
+
+// variantNamePattern keeps names safe to store in a cookie.
+var variantNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// Variant is one of the destinations a link splits its traffic between. Each
+// visitor is sent to a variant with a probability of its weight over the sum
+// of the weights. A zero weight pauses the variant.
+type Variant struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Weight int    `json:"weight"`
+}
+
+// ValidateVariants checks the weighted destinations of a link.
+func ValidateVariants(variants []Variant) error {
+	if len(variants) < MinVariants || len(variants) > MaxVariants {
+		return Detailed(ErrInvalidVariants, "a link must have between %d and %d variants", MinVariants, MaxVariants)
+	}
+	names := make(map[string]bool)
+	total := 0
+	for i, variant := range variants {
+		if !variantNamePattern.MatchString(variant.Name) {
+			return Detailed(ErrInvalidVariants, "the name of variant %d must be 1-32 letters, digits, '-' or '_'", i+1)
+		}
+		if names[variant.Name] {
+			return Detailed(ErrInvalidVariants, "variant name %q is already used", variant.Name)
+		}
+		names[variant.Name] = true
+		if !IsAbsoluteURL(variant.URL) {
+			return Detailed(ErrInvalidVariants, "the url of variant %q is not valid", variant.Name)
+		}
+		if variant.Weight < 0 || variant.Weight > MaxVariantWeight {
+			return Detailed(ErrInvalidVariants, "the weight of variant %q must be between 0 and %d", variant.Name, MaxVariantWeight)
+		}
+		total += variant.Weight
+	}
+	if total == 0 {
+		return Detailed(ErrInvalidVariants, "at least one variant must have a weight")
+	}
+	return nil
+}
+
+// PickVariant returns the variant the visitor is sent to. A visitor keeps the
+// variant they were given before, as long as it still has a weight. Anyone
+// else is assigned one from a hash of their Key, so the same visitor always
+// lands on the same variant while the weights stay the same.
+func (l Link) PickVariant(v Visitor) (Variant, bool) {
+	total := 0
+	for _, variant := range l.Variants {
+		if variant.Name == v.Variant && variant.Weight > 0 {
+			return variant, true
+		}
+		total += variant.Weight
+	}
+	if total == 0 {
+		return Variant{}, false
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(l.Code + "|" + v.Key))
+	bucket := int(hash.Sum64() % uint64(total))
+	for _, variant := range l.Variants {
+		if bucket < variant.Weight {
+			return variant, true
+		}
+		bucket -= variant.Weight
+	}
+	return Variant{}, false
+}
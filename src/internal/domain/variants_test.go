@@ -0,0 +1,161 @@
+package domain_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickVariant(t *testing.T) {
+	link := domain.Link{
+		Code:        "split",
+		OriginalURL: "https://example.com",
+		Variants: []domain.Variant{
+			{Name: "a", URL: "https://example.com/a", Weight: 70},
+			{Name: "b", URL: "https://example.com/b", Weight: 30},
+			{Name: "paused", URL: "https://example.com/paused", Weight: 0},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		visitor  domain.Visitor
+		expected string
+	}{
+		{
+			name:     "WhenVisitorWasGivenAVariant_ThenKeepsIt",
+			visitor:  domain.Visitor{Key: "203.0.113.7|curl", Variant: "b"},
+			expected: "b",
+		},
+		{
+			name:     "WhenVisitorWasGivenAPausedVariant_ThenPicksAnother",
+			visitor:  domain.Visitor{Key: "203.0.113.7|curl", Variant: "paused"},
+			expected: variantFor(link, "203.0.113.7|curl"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			variant, ok := link.PickVariant(tt.visitor)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, variant.Name)
+		})
+	}
+}
+
+func variantFor(link domain.Link, key string) string {
+	variant, _ := link.PickVariant(domain.Visitor{Key: key})
+	return variant.Name
+}
+
+func TestPickVariantWhenVisitorsAreNew_ThenSplitsThemByWeight(t *testing.T) {
+	link := domain.Link{
+		Code: "split",
+		Variants: []domain.Variant{
+			{Name: "a", URL: "https://example.com/a", Weight: 70},
+			{Name: "b", URL: "https://example.com/b", Weight: 30},
+			{Name: "paused", URL: "https://example.com/paused", Weight: 0},
+		},
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		key := "198.51.100." + strconv.Itoa(i%256) + "|agent-" + strconv.Itoa(i)
+		counts[variantFor(link, key)]++
+		assert.Equal(t, variantFor(link, key), variantFor(link, key))
+	}
+
+	assert.InDelta(t, 7000, counts["a"], 300)
+	assert.InDelta(t, 3000, counts["b"], 300)
+	assert.Zero(t, counts["paused"])
+}
+
+func TestValidateVariants(t *testing.T) {
+	tests := []struct {
+		name        string
+		variants    []domain.Variant
+		expectError bool
+	}{
+		{
+			name: "WhenVariantsAreValid_ThenReturnsNil",
+			variants: []domain.Variant{
+				{Name: "a", URL: "https://example.com/a", Weight: 70},
+				{Name: "b", URL: "https://example.com/b", Weight: 30},
+			},
+		},
+		{
+			name:        "WhenThereIsOnlyOneVariant_ThenReturnsError",
+			variants:    []domain.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}},
+			expectError: true,
+		},
+		{
+			name: "WhenNamesRepeat_ThenReturnsError",
+			variants: []domain.Variant{
+				{Name: "a", URL: "https://example.com/a", Weight: 1},
+				{Name: "a", URL: "https://example.com/b", Weight: 1},
+			},
+			expectError: true,
+		},
+		{
+			name: "WhenNameIsNotCookieSafe_ThenReturnsError",
+			variants: []domain.Variant{
+				{Name: "a;b", URL: "https://example.com/a", Weight: 1},
+				{Name: "c", URL: "https://example.com/b", Weight: 1},
+			},
+			expectError: true,
+		},
+		{
+			name: "WhenWeightIsNegative_ThenReturnsError",
+			variants: []domain.Variant{
+				{Name: "a", URL: "https://example.com/a", Weight: -1},
+				{Name: "b", URL: "https://example.com/b", Weight: 1},
+			},
+			expectError: true,
+		},
+		{
+			name: "WhenEveryWeightIsZero_ThenReturnsError",
+			variants: []domain.Variant{
+				{Name: "a", URL: "https://example.com/a"},
+				{Name: "b", URL: "https://example.com/b"},
+			},
+			expectError: true,
+		},
+		{
+			name: "WhenURLIsInvalid_ThenReturnsError",
+			variants: []domain.Variant{
+				{Name: "a", URL: "not a url", Weight: 1},
+				{Name: "b", URL: "https://example.com/b", Weight: 1},
+			},
+			expectError: true,
+		},
+		{
+			name: "WhenURLIsRelative_ThenReturnsError",
+			variants: []domain.Variant{
+				{Name: "a", URL: "/a", Weight: 1},
+				{Name: "b", URL: "https://example.com/b", Weight: 1},
+			},
+			expectError: true,
+		},
+		{
+			name: "WhenURLIsNotHTTP_ThenReturnsError",
+			variants: []domain.Variant{
+				{Name: "a", URL: "javascript:alert(1)", Weight: 1},
+				{Name: "b", URL: "https://example.com/b", Weight: 1},
+			},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := domain.ValidateVariants(tt.variants)
+			if tt.expectError {
+				assert.ErrorIs(t, err, domain.ErrInvalidVariants)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
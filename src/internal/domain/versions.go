@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// MaxLinkVersions is how many past versions of a link are kept. Older ones
+// are dropped as new ones are saved.
+const MaxLinkVersions = 20
+
+var (
+	ErrVersionNotFound = errors.New("version not found")
+	ErrLinkChanged     = errors.New("the link was changed by another request")
+)
+
+// LinkVersion is where a link sent its visitors at one point in its history:
+// its destination, targeting and activation window.
+type LinkVersion struct {
+	Version int64 `json:"version"`
+	// Time is when the version was saved and Actor the API key that saved
+	// it. Both are unknown for the version a link was created with.
+	Time         *time.Time   `json:"time,omitempty"`
+	Actor        string       `json:"actor,omitempty"`
+	URL          string       `json:"url"`
+	Targets      []TargetRule `json:"targets,omitempty"`
+	Variants     []Variant    `json:"variants,omitempty"`
+	ActiveFrom   *time.Time   `json:"active_from,omitempty"`
+	ActiveUntil  *time.Time   `json:"active_until,omitempty"`
+	InactiveMode string       `json:"inactive_mode,omitempty"`
+	FallbackURL  string       `json:"fallback_url,omitempty"`
+}
+
+// CurrentVersion is the number of the version the link is at. Links are
+// created at version 1.
+func (l Link) CurrentVersion() int64 {
+	return max(l.Version, 1)
+}
+
+// Snapshot returns the current version of the link.
+func (l Link) Snapshot() LinkVersion {
+	actor := l.UpdatedBy
+	if l.CurrentVersion() == 1 {
+		actor = l.CreatedBy
+	}
+	return LinkVersion{
+		Version:      l.CurrentVersion(),
+		Time:         l.UpdatedAt,
+		Actor:        actor,
+		URL:          l.OriginalURL,
+		Targets:      l.Targets,
+		Variants:     l.Variants,
+		ActiveFrom:   l.ActiveFrom,
+		ActiveUntil:  l.ActiveUntil,
+		InactiveMode: l.InactiveMode,
+		FallbackURL:  l.FallbackURL,
+	}
+}
+
+// Restore sets the destination, targeting and activation window of the link
+// back to those of version. The rest of the link is left as it is.
+func (l *Link) Restore(version LinkVersion) {
+	l.OriginalURL = version.URL
+	l.Targets = version.Targets
+	l.Variants = version.Variants
+	l.ActiveFrom = version.ActiveFrom
+	l.ActiveUntil = version.ActiveUntil
+	l.InactiveMode = version.InactiveMode
+	l.FallbackURL = version.FallbackURL
+}
+
+// Revise numbers the link as the version that follows previous, saved by
+// actor at now.
+func (l *Link) Revise(previous Link, actor string, now time.Time) {
+	l.Version = previous.CurrentVersion() + 1
+	l.UpdatedAt = &now
+	l.UpdatedBy = actor
+}
@@ -0,0 +1,30 @@
+package domain_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLinkVersions(t *testing.T) {
+	created := domain.Link{Code: "promo", OriginalURL: "http://example.com", CreatedBy: "ci", MaxClicks: 5,
+		Targets: []domain.TargetRule{{OS: "iOS", URL: "http://apps.example.com"}}}
+	assert.Equal(t, domain.LinkVersion{Version: 1, Actor: "ci", URL: "http://example.com", Targets: created.Targets}, created.Snapshot())
+
+	now := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	updated := created
+	updated.OriginalURL = "http://example.org"
+	updated.Targets = nil
+	updated.Revise(created, "ops", now)
+	assert.Equal(t, domain.LinkVersion{Version: 2, Time: &now, Actor: "ops", URL: "http://example.org"}, updated.Snapshot())
+
+	rolledBack := updated
+	rolledBack.Restore(created.Snapshot())
+	rolledBack.Revise(updated, "ops", now)
+	assert.Equal(t, "http://example.com", rolledBack.OriginalURL)
+	assert.Equal(t, created.Targets, rolledBack.Targets)
+	assert.Equal(t, int64(5), rolledBack.MaxClicks)
+	assert.Equal(t, int64(3), rolledBack.CurrentVersion())
+}
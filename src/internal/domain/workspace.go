@@ -0,0 +1,183 @@
+package domain
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnauthorized      = errors.New("a valid API key is required")
+	ErrWorkspaceNotFound = errors.New("workspace not found")
+	ErrQuotaExceeded     = errors.New("workspace quota exceeded")
+	ErrInvalidWorkspaces = errors.New("invalid workspaces")
+)
+
+var (
+	workspaceIDPattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+	apiKeyHashPattern  = regexp.MustCompile(`^[0-9a-f]{64}$`)
+)
+
+// workspacePrefix starts the storage keys of every workspace. Codes and
+// domain names never contain ':', so no key outside a workspace starts with
+// it.
+const workspacePrefix = "ws:"
+
+// Workspace is a team sharing the server. Its links are kept apart from
+// those of every other workspace and only its API keys can manage them.
+type Workspace struct {
+	ID   string `json:"id"`
+	Name string `json:"name,omitempty"`
+	// APIKeys are the keys of the workspace, with the hex SHA-256 hashes of
+	// the keys so that the configuration never holds the keys themselves.
+	APIKeys []APIKey `json:"api_keys,omitempty"`
+	// MaxLinks caps the live links of the workspace and MaxCreationsPerHour
+	// how many it creates per hour. Zero means unlimited.
+	MaxLinks            int64 `json:"max_links,omitempty"`
+	MaxCreationsPerHour int64 `json:"max_creations_per_hour,omitempty"`
+	// Trust is the trust level of whoever creates links in the workspace,
+	// one of the Trust constants. Empty means trusted.
+	Trust string `json:"trust,omitempty"`
+}
+
+// WorkspaceUsage reports how much of its quotas a workspace uses.
+type WorkspaceUsage struct {
+	Workspace           string `json:"workspace"`
+	Links               int64  `json:"links"`
+	MaxLinks            int64  `json:"max_links,omitempty"`
+	CreationsThisHour   int64  `json:"creations_this_hour"`
+	MaxCreationsPerHour int64  `json:"max_creations_per_hour,omitempty"`
+}
+
+// QuotaReservation is room held in the quotas of a workspace for links about
+// to be saved.
+type QuotaReservation struct {
+	Workspace string
+	ID        string
+	Links     int
+	At        time.Time
+}
+
+// Workspaces are the workspaces of the server, found by ID or by API key.
+type Workspaces struct {
+	byID  map[string]Workspace
+	byKey map[string]Actor
+}
+
+// NewWorkspaces checks the configuration of every workspace.
+func NewWorkspaces(workspaces []Workspace) (*Workspaces, error) {
+	w := &Workspaces{byID: make(map[string]Workspace, len(workspaces)), byKey: make(map[string]Actor)}
+	for _, workspace := range workspaces {
+		if !workspaceIDPattern.MatchString(workspace.ID) {
+			return nil, fmt.Errorf("%w: id %q must be 1-32 lowercase letters, digits, '-' or '_'", ErrInvalidWorkspaces, workspace.ID)
+		}
+		if _, ok := w.byID[workspace.ID]; ok {
+			return nil, fmt.Errorf("%w: %s is repeated", ErrInvalidWorkspaces, workspace.ID)
+		}
+		if workspace.MaxLinks < 0 || workspace.MaxCreationsPerHour < 0 {
+			return nil, fmt.Errorf("%w: quotas of %s must be positive", ErrInvalidWorkspaces, workspace.ID)
+		}
+		if err := ValidateTrust(workspace.Trust); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidWorkspaces, workspace.ID, err)
+		}
+
+		names := make(map[string]bool, len(workspace.APIKeys))
+		keys := make([]APIKey, 0, len(workspace.APIKeys))
+		for _, key := range workspace.APIKeys {
+			if err := ValidateAPIKey(key); err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrInvalidWorkspaces, workspace.ID, err)
+			}
+			if names[key.Name] {
+				return nil, fmt.Errorf("%w: api key %s of %s is repeated", ErrInvalidWorkspaces, key.Name, workspace.ID)
+			}
+			names[key.Name] = true
+			key.Hash = strings.ToLower(key.Hash)
+			if !apiKeyHashPattern.MatchString(key.Hash) {
+				return nil, fmt.Errorf("%w: api keys of %s must be hex SHA-256 hashes", ErrInvalidWorkspaces, workspace.ID)
+			}
+			if _, ok := w.byKey[key.Hash]; ok {
+				return nil, fmt.Errorf("%w: an api key of %s belongs to another workspace", ErrInvalidWorkspaces, workspace.ID)
+			}
+			if slices.ContainsFunc(keys, func(k APIKey) bool { return k.Hash == key.Hash }) {
+				return nil, fmt.Errorf("%w: api key %s of %s is another key of the workspace", ErrInvalidWorkspaces, key.Name, workspace.ID)
+			}
+			keys = append(keys, key)
+		}
+		workspace.APIKeys = keys
+		for _, key := range workspace.APIKeys {
+			w.byKey[key.Hash] = Actor{Workspace: workspace, Key: key}
+		}
+		w.byID[workspace.ID] = workspace
+	}
+	return w, nil
+}
+
+// Authenticate returns the workspace and key apiKey is, among the keys of the
+// configuration.
+func (w *Workspaces) Authenticate(apiKey string) (Actor, error) {
+	if apiKey == "" {
+		return Actor{}, ErrUnauthorized
+	}
+	actor, ok := w.byKey[HashAPIKey(apiKey)]
+	if !ok {
+		return Actor{}, ErrUnauthorized
+	}
+	return actor, nil
+}
+
+// Get returns the workspace with id.
+func (w *Workspaces) Get(id string) (Workspace, bool) {
+	workspace, ok := w.byID[id]
+	return workspace, ok
+}
+
+// HashAPIKey is how API keys are written in the workspaces configuration.
+func HashAPIKey(apiKey string) string {
+	hash := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// GenerateAPIKey returns a new random API key.
+func GenerateAPIKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("reading a random key --> %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(key), nil
+}
+
+// WorkspaceKey prefixes key with the namespace of workspace. Keys outside
+// any workspace are left as they are, which keeps the links created before
+// there were workspaces.
+func WorkspaceKey(workspace string, key string) string {
+	if workspace == "" {
+		return key
+	}
+	return workspacePrefix + workspace + ":" + key
+}
+
+// SplitWorkspaceKey is the inverse of WorkspaceKey.
+func SplitWorkspaceKey(key string) (workspace string, rest string) {
+	scoped, found := strings.CutPrefix(key, workspacePrefix)
+	if !found {
+		return "", key
+	}
+	workspace, rest, found = strings.Cut(scoped, ":")
+	if !found {
+		return "", key
+	}
+	return workspace, rest
+}
+
+// WorkspaceLinksKey holds the storage keys of the live links of workspace,
+// scored by the Unix time in milliseconds when they expire.
+func WorkspaceLinksKey(workspace string) string {
+	return "workspace_links:" + workspace
+}
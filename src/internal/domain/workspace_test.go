@@ -0,0 +1,116 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWorkspaces(t *testing.T) {
+	key := domain.APIKey{Name: "ci", Role: domain.RoleEditor, Hash: domain.HashAPIKey("marketing-key")}
+	upper := key
+	upper.Hash = strings.ToUpper(key.Hash)
+
+	tests := []struct {
+		name       string
+		workspaces []domain.Workspace
+		err        string
+	}{
+		{
+			name:       "WhenWorkspacesAreValid_ThenAcceptsThem",
+			workspaces: []domain.Workspace{{ID: "marketing", APIKeys: []domain.APIKey{upper}, MaxLinks: 10}, {ID: "sales"}},
+		},
+		{
+			name:       "WhenAnIDIsInvalid_ThenReturnsAnError",
+			workspaces: []domain.Workspace{{ID: "Marketing Team"}},
+			err:        `invalid workspaces: id "Marketing Team" must be 1-32 lowercase letters, digits, '-' or '_'`,
+		},
+		{
+			name:       "WhenAnIDIsRepeated_ThenReturnsAnError",
+			workspaces: []domain.Workspace{{ID: "marketing"}, {ID: "marketing"}},
+			err:        "invalid workspaces: marketing is repeated",
+		},
+		{
+			name:       "WhenAKeyIsNotAHash_ThenReturnsAnError",
+			workspaces: []domain.Workspace{{ID: "marketing", APIKeys: []domain.APIKey{{Name: "ci", Role: domain.RoleEditor, Hash: "marketing-key"}}}},
+			err:        "invalid workspaces: api keys of marketing must be hex SHA-256 hashes",
+		},
+		{
+			name:       "WhenAKeyIsShared_ThenReturnsAnError",
+			workspaces: []domain.Workspace{{ID: "marketing", APIKeys: []domain.APIKey{key}}, {ID: "sales", APIKeys: []domain.APIKey{key}}},
+			err:        "invalid workspaces: an api key of sales belongs to another workspace",
+		},
+		{
+			name:       "WhenAKeyHasNoRole_ThenReturnsAnError",
+			workspaces: []domain.Workspace{{ID: "marketing", APIKeys: []domain.APIKey{{Name: "ci", Hash: key.Hash}}}},
+			err:        "invalid workspaces: marketing: invalid API key: role of ci must be viewer, editor or admin",
+		},
+		{
+			name: "WhenAKeyNameIsRepeated_ThenReturnsAnError",
+			workspaces: []domain.Workspace{{ID: "marketing", APIKeys: []domain.APIKey{key,
+				{Name: "ci", Role: domain.RoleViewer, Hash: domain.HashAPIKey("other-key")}}}},
+			err: "invalid workspaces: api key ci of marketing is repeated",
+		},
+		{
+			name:       "WhenAQuotaIsNegative_ThenReturnsAnError",
+			workspaces: []domain.Workspace{{ID: "marketing", MaxCreationsPerHour: -1}},
+			err:        "invalid workspaces: quotas of marketing must be positive",
+		},
+		{
+			name:       "WhenATrustIsUnknown_ThenReturnsAnError",
+			workspaces: []domain.Workspace{{ID: "marketing", Trust: "partly"}},
+			err:        "invalid workspaces: marketing: creator trust must be trusted or untrusted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := domain.NewWorkspaces(tt.workspaces)
+			if tt.err != "" {
+				assert.ErrorIs(t, err, domain.ErrInvalidWorkspaces)
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestWorkspacesAuthenticate(t *testing.T) {
+	key := domain.APIKey{Name: "ci", Role: domain.RoleEditor, Team: "growth", Hash: domain.HashAPIKey("marketing-key")}
+	workspaces, err := domain.NewWorkspaces([]domain.Workspace{{ID: "marketing", APIKeys: []domain.APIKey{key}}})
+	assert.NoError(t, err)
+
+	actor, err := workspaces.Authenticate("marketing-key")
+	assert.NoError(t, err)
+	assert.Equal(t, "marketing", actor.Workspace.ID)
+	assert.Equal(t, key, actor.Key)
+
+	workspace, ok := workspaces.Get("marketing")
+	assert.True(t, ok)
+	assert.Equal(t, actor.Workspace, workspace)
+	_, ok = workspaces.Get("sales")
+	assert.False(t, ok)
+
+	_, err = workspaces.Authenticate("sales-key")
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	_, err = workspaces.Authenticate("")
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+}
+
+func TestWorkspaceKey(t *testing.T) {
+	link := domain.Link{Code: "promo", Domain: "acme.link", Workspace: "marketing"}
+	assert.Equal(t, "acme.link/promo", link.Address())
+	assert.Equal(t, "ws:marketing:acme.link/promo", link.Key())
+	assert.Equal(t, "promo", domain.Link{Code: "promo"}.Key())
+
+	workspace, rest := domain.SplitWorkspaceKey(link.Key())
+	assert.Equal(t, "marketing", workspace)
+	assert.Equal(t, "acme.link/promo", rest)
+
+	workspace, rest = domain.SplitWorkspaceKey("promo")
+	assert.Equal(t, "", workspace)
+	assert.Equal(t, "promo", rest)
+}
@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed openapi.json
+var specJSON []byte
+
+//go:embed docs.html
+var docsHTML []byte
+
+func init() {
+	// NDJSON bodies are described as plain strings in the specification; the
+	// handler decodes the lines itself.
+	openapi3filter.RegisterBodyDecoder("application/x-ndjson", stringBodyDecoder)
+}
+
+// Load parses and validates the embedded OpenAPI document.
+func Load() (*openapi3.T, error) {
+	spec, err := openapi3.NewLoader().LoadFromData(specJSON)
+	if err != nil {
+		return nil, fmt.Errorf("error loading the OpenAPI document --> %w", err)
+	}
+	if err := spec.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("error validating the OpenAPI document --> %w", err)
+	}
+	return spec, nil
+}
+
+// NewOpenAPIHandler serves the OpenAPI document at /openapi.json and a page
+// rendering it at /docs. The page is embedded whole and loads nothing from
+// other origins.
+func NewOpenAPIHandler(router *gin.RouterGroup) {
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", specJSON)
+	})
+	router.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", docsHTML)
+	})
+}
+
+// ValidateRequests returns a middleware that rejects with an invalid-request
+// problem any request whose parameters or body do not match spec. Requests to
+// paths the document does not describe are passed through untouched.
+func ValidateRequests(spec *openapi3.T) (gin.HandlerFunc, error) {
+	router, err := gorillamux.NewRouter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("error building the OpenAPI router --> %w", err)
+	}
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if errors.Is(err, routers.ErrPathNotFound) || errors.Is(err, routers.ErrMethodNotAllowed) {
+			c.Next()
+			return
+		}
+		if err != nil {
+			log.Error(fmt.Errorf("finding the OpenAPI route --> %w", err))
+			c.Next()
+			return
+		}
+
+		err = openapi3filter.ValidateRequest(c, &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			// API keys are checked by the handlers, which know the
+			// workspaces.
+			Options: &openapi3filter.Options{AuthenticationFunc: openapi3filter.NoopAuthenticationFunc},
+		})
+		if err != nil {
+			problem.Abort(c, problem.New(problem.InvalidRequest, validationMessage(err)))
+			return
+		}
+		c.Next()
+	}, nil
+}
+
+// validationMessage keeps the part of a validation error that helps the
+// client, leaving out the schema dump kin-openapi appends to body errors.
+func validationMessage(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return err.Error()
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(requestErr.Err, &schemaErr) {
+		if requestErr.Parameter != nil {
+			return fmt.Sprintf("parameter %q in %s has an error: %s",
+				requestErr.Parameter.Name, requestErr.Parameter.In, schemaErr.Reason)
+		}
+		return fmt.Sprintf("request body has an error: %s at /%s",
+			schemaErr.Reason, strings.Join(schemaErr.JSONPointer(), "/"))
+	}
+	return requestErr.Error()
+}
+
+func stringBodyDecoder(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (interface{}, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,246 @@
+package openapi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/handlers/openapi"
+	urlshortener "github.com/dariomba/url-shortener/src/internal/handlers/url_shortener"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ginParam = regexp.MustCompile(`[:*](\w+)`)
+
+func invalidRequest(detail string, instance string) string {
+	p := problem.New(problem.InvalidRequest, detail)
+	p.Instance = instance
+	body, _ := json.Marshal(p)
+	return string(body)
+}
+
+// TestSpecMatchesHandlerRoutes fails when a route is added to or removed from
+// URLShortenerHandler without updating openapi.json, or the other way round.
+func TestSpecMatchesHandlerRoutes(t *testing.T) {
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	urlshortener.NewURLShortenerHandler(router.Group("/"), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, urlshortener.Config{}, nil)
+
+	var handlerRoutes []string
+	for _, route := range router.Routes() {
+		handlerRoutes = append(handlerRoutes, route.Method+" "+ginParam.ReplaceAllString(route.Path, "{$1}"))
+	}
+
+	var specRoutes []string
+	for path, item := range spec.Paths.Map() {
+		for method := range item.Operations() {
+			specRoutes = append(specRoutes, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	sort.Strings(handlerRoutes)
+	sort.Strings(specRoutes)
+	assert.Equal(t, handlerRoutes, specRoutes)
+}
+
+func TestValidateRequests(t *testing.T) {
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		requestBody string
+		want        want
+	}{
+		{
+			name:        "WhenBodyMissesARequiredField_ThenReturnsBadRequest",
+			method:      "POST",
+			path:        "/api/v1/createLink",
+			contentType: "application/json",
+			requestBody: `{"qr":true}`,
+			want: want{statusCode: http.StatusBadRequest,
+				body: invalidRequest(`request body has an error: property "url" is missing at /url`, "/api/v1/createLink")},
+		},
+		{
+			name:        "WhenBodyHasTheWrongType_ThenReturnsBadRequest",
+			method:      "POST",
+			path:        "/api/v1/links/bulk",
+			contentType: "application/json",
+			requestBody: `[{"url":"http://example.com","ttl":"soon"}]`,
+			want: want{statusCode: http.StatusBadRequest,
+				body: invalidRequest(`request body has an error: value must be an integer at /0/ttl`, "/api/v1/links/bulk")},
+		},
+		{
+			name:   "WhenQueryParameterIsOutOfRange_ThenReturnsBadRequest",
+			method: "GET",
+			path:   "/api/v1/links/abc/qr?size=5000",
+			want: want{statusCode: http.StatusBadRequest,
+				body: invalidRequest(`parameter "size" in query has an error: number must be at most 2048`, "/api/v1/links/abc/qr")},
+		},
+		{
+			name:        "WhenNDJSONIsSent_ThenPassesItThrough",
+			method:      "POST",
+			path:        "/api/v1/links/bulk",
+			contentType: "application/x-ndjson",
+			requestBody: "{\"url\":\"http://example.com\"}\n",
+			want:        want{statusCode: http.StatusOK, body: `{"validated":true}`},
+		},
+		{
+			name:        "WhenCSVIsSent_ThenPassesItThrough",
+			method:      "POST",
+			path:        "/api/v1/links/bulk",
+			contentType: "text/csv",
+			requestBody: "url,alias\nhttp://example.com,promo\n",
+			want:        want{statusCode: http.StatusOK, body: `{"validated":true}`},
+		},
+		{
+			name:        "WhenFileIsUploaded_ThenPassesItThrough",
+			method:      "POST",
+			path:        "/api/v1/links/bulk",
+			contentType: "multipart/form-data; boundary=b",
+			requestBody: "--b\r\nContent-Disposition: form-data; name=\"file\"; filename=\"links.csv\"\r\n" +
+				"Content-Type: text/csv\r\n\r\nhttp://example.com\r\n--b--\r\n",
+			want: want{statusCode: http.StatusOK, body: `{"validated":true}`},
+		},
+		{
+			name:        "WhenPasswordFormIsSubmitted_ThenPassesItThrough",
+			method:      "POST",
+			path:        "/abc123",
+			contentType: "application/x-www-form-urlencoded",
+			requestBody: "password=open+sesame",
+			want:        want{statusCode: http.StatusOK, body: `{"validated":true}`},
+		},
+		{
+			name:        "WhenPreviewFormIsSubmitted_ThenPassesItThrough",
+			method:      "POST",
+			path:        "/abc123",
+			contentType: "application/x-www-form-urlencoded",
+			want:        want{statusCode: http.StatusOK, body: `{"validated":true}`},
+		},
+		{
+			name:        "WhenActivationWindowIsCleared_ThenCallsTheHandler",
+			method:      "PATCH",
+			path:        "/api/v1/links/abc",
+			contentType: "application/json",
+			requestBody: `{"active_from":null,"active_until":null}`,
+			want:        want{statusCode: http.StatusOK, body: `{"validated":true}`},
+		},
+		{
+			name:   "WhenRequestIsValid_ThenCallsTheHandler",
+			method: "GET",
+			path:   "/api/v1/links/abc/qr?format=svg&fg=%23000000",
+			want:   want{statusCode: http.StatusOK, body: `{"validated":true}`},
+		},
+		{
+			name:   "WhenPathIsNotInTheSpec_ThenCallsTheHandler",
+			method: "GET",
+			path:   "/docs/extra",
+			want:   want{statusCode: http.StatusOK, body: `{"validated":true}`},
+		},
+	}
+
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+	validator, err := openapi.ValidateRequests(spec)
+	require.NoError(t, err)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(validator)
+			router.NoRoute(func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"validated": true})
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.requestBody))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.JSONEq(t, tt.want.body, w.Body.String())
+		})
+	}
+}
+
+func TestNewOpenAPIHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	openapi.NewOpenAPIHandler(router.Group("/"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"openapi": "3.0.3"`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/docs", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `fetch("/openapi.json")`)
+	// The page is self-contained: it loads no script from another origin.
+	assert.NotContains(t, w.Body.String(), `<script src=`)
+}
+
+// TestValidateRequestsAfterAuthentication checks that a request without a
+// valid API key is refused before its body is validated.
+func TestValidateRequestsAfterAuthentication(t *testing.T) {
+	workspaces, err := domain.NewWorkspaces([]domain.Workspace{{ID: "marketing", APIKeys: []domain.APIKey{
+		{Name: "ops", Role: domain.RoleAdmin, Hash: domain.HashAPIKey("admin-key")},
+	}}})
+	require.NoError(t, err)
+
+	spec, err := openapi.Load()
+	require.NoError(t, err)
+	validator, err := openapi.ValidateRequests(spec)
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	urlshortener.NewURLShortenerHandler(router.Group("/"), nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, urlshortener.Config{Workspaces: workspaces}, nil, validator)
+
+	tests := []struct {
+		name       string
+		apiKey     string
+		statusCode int
+	}{
+		{name: "WhenAPIKeyIsMissing_ThenReturnsUnauthorized", statusCode: http.StatusUnauthorized},
+		{name: "WhenAPIKeyIsValid_ThenValidatesTheBody", apiKey: "admin-key", statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/v1/createLink", bytes.NewBufferString(`{"url":42}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.apiKey != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.apiKey)
+			}
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.statusCode, w.Code)
+		})
+	}
+}
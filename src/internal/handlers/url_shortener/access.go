@@ -0,0 +1,51 @@
+package urlshortener
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/dariomba/url-shortener/src/internal/requestid"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// authorize reports whether the API key of the request may perform action on
+// link, nil for actions on no link in particular, see domain.Actor.Can.
+// Otherwise it records the denial in the audit log under target and answers
+// 403. Every request is allowed when the server has no workspaces.
+func (u *URLShortenerHandler) authorize(c *gin.Context, action string, target string, link *domain.Link) bool {
+	if u.workspaces == nil || actorOf(c).Can(action, link) {
+		return true
+	}
+
+	u.audit(c, domain.AuditEvent{Action: action, Target: target, Outcome: domain.AuditDenied})
+	problem.AbortWithError(c, domain.ErrForbidden)
+	return false
+}
+
+// auditChange records in the audit log that action succeeded on target,
+// turning before into after. Either is nil when the action created or removed
+// it.
+func (u *URLShortenerHandler) auditChange(c *gin.Context, action string, target string, before any, after any) {
+	changes, err := domain.Diff(before, after)
+	if err != nil {
+		log.Error(fmt.Errorf("comparing the audited values --> %w", err))
+	}
+	u.audit(c, domain.AuditEvent{Action: action, Target: target, Outcome: domain.AuditSucceeded, Changes: changes})
+}
+
+// audit records event in the audit log, with the workspace, API key and ID of
+// the request. An event that cannot be recorded is logged but does not fail
+// the request.
+func (u *URLShortenerHandler) audit(c *gin.Context, event domain.AuditEvent) {
+	actor := actorOf(c)
+	event.Time = time.Now().UTC()
+	event.Workspace = actor.Workspace.ID
+	event.Actor = actor.Key.Name
+	event.RequestID = requestid.FromContext(c)
+	if err := u.auditService.Record(c, event); err != nil {
+		log.Error(fmt.Errorf("recording the audit event --> %w", err))
+	}
+}
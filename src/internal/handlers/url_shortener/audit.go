@@ -0,0 +1,131 @@
+package urlshortener
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	auditFormatJSON  = "json"
+	auditFormatJSONL = "jsonl"
+)
+
+// GetAuditLog lists the audit log of the workspace of the request, newest
+// first, one page at a time. With format=jsonl it exports every matching
+// event instead, one JSON object per line.
+func (u *URLShortenerHandler) GetAuditLog(c *gin.Context) {
+	if !u.authorize(c, domain.ActionReadAudit, "", nil) {
+		return
+	}
+
+	query, err := u.auditQueryFromRequest(c)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	format := c.DefaultQuery("format", auditFormatJSON)
+	if format != auditFormatJSON && format != auditFormatJSONL {
+		problem.Abort(c, problem.New(problem.InvalidRequest, "format must be json or jsonl"))
+		return
+	}
+	if format == auditFormatJSONL {
+		query.Limit = domain.MaxAuditLimit
+	}
+
+	page, err := u.auditService.Query(c, query)
+	if err != nil {
+		log.Error(fmt.Errorf("reading the audit log --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	if format == auditFormatJSON {
+		c.JSON(http.StatusOK, page)
+		return
+	}
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Content-Disposition", `attachment; filename="audit.jsonl"`)
+	c.Status(http.StatusOK)
+	encoder := json.NewEncoder(c.Writer)
+	for {
+		for _, event := range page.Events {
+			if err := encoder.Encode(event); err != nil {
+				log.Error(fmt.Errorf("exporting the audit log --> %w", err))
+				return
+			}
+		}
+		if page.NextCursor == "" {
+			return
+		}
+		// The status is already sent, so a failure can only cut the export
+		// short.
+		query.Cursor = page.NextCursor
+		if page, err = u.auditService.Query(c, query); err != nil {
+			log.Error(fmt.Errorf("exporting the audit log --> %w", err))
+			return
+		}
+	}
+}
+
+// auditQueryFromRequest reads the filters of GetAuditLog: actor, action,
+// outcome, code (with domain) or key for the target, from and to as RFC 3339
+// times, and the cursor and limit of the page.
+func (u *URLShortenerHandler) auditQueryFromRequest(c *gin.Context) (domain.AuditQuery, error) {
+	query := domain.AuditQuery{
+		Workspace: workspaceOf(c).ID,
+		Actor:     c.Query("actor"),
+		Action:    c.Query("action"),
+		Outcome:   c.Query("outcome"),
+		Cursor:    c.Query("cursor"),
+	}
+
+	code, key := c.Query("code"), c.Query("key")
+	switch {
+	case code != "" && key != "":
+		return domain.AuditQuery{}, domain.Detailed(domain.ErrInvalidAuditQuery, "code and key cannot be combined")
+	case code != "":
+		shortDomain, err := u.queryDomain(c)
+		if err != nil {
+			return domain.AuditQuery{}, err
+		}
+		query.Target = managedKey(c, shortDomain, code)
+	case key != "":
+		query.Target = key
+	}
+
+	var err error
+	if query.From, err = timeQuery(c, "from", domain.ErrInvalidAuditQuery); err != nil {
+		return domain.AuditQuery{}, err
+	}
+	if query.To, err = timeQuery(c, "to", domain.ErrInvalidAuditQuery); err != nil {
+		return domain.AuditQuery{}, err
+	}
+	if limit, ok := c.GetQuery("limit"); ok {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return domain.AuditQuery{}, domain.Detailed(domain.ErrInvalidAuditQuery, "limit must be an integer")
+		}
+	}
+	return query, query.Validate()
+}
+
+// timeQuery reads the query parameter name as an RFC 3339 time, nil when it
+// is missing. A malformed time is reported as invalid.
+func timeQuery(c *gin.Context, name string, invalid error) (*time.Time, error) {
+	value, ok := c.GetQuery(name)
+	if !ok {
+		return nil, nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, domain.Detailed(invalid, "%s must be an RFC 3339 time", name)
+	}
+	return &parsed, nil
+}
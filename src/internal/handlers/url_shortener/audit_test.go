@@ -0,0 +1,103 @@
+package urlshortener_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAuditLog(t *testing.T) {
+	setWorkspaces(t)
+	at := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	created := domain.AuditEvent{ID: "1906189200000-0", Time: at, Workspace: "marketing", Actor: "ci",
+		Action: domain.ActionCreate, Target: "ws:marketing:promo", Outcome: domain.AuditSucceeded}
+	deleted := domain.AuditEvent{ID: "1906189260000-0", Time: at.Add(time.Minute), Workspace: "marketing", Actor: "ops",
+		Action: domain.ActionDelete, Target: "ws:marketing:promo", Outcome: domain.AuditSucceeded}
+
+	type want struct {
+		statusCode  int
+		contentType string
+		body        string
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		apiKey string
+		want   want
+		mocks  func(m mocksShortenerHandler)
+	}{
+		{
+			name:   "WhenAdminFiltersTheLog_ThenReturnsAPage",
+			path:   "/api/v1/audit?code=promo&actor=ci&from=2030-06-01T00:00:00Z&limit=1",
+			apiKey: "admin-key",
+			want: want{statusCode: http.StatusOK, contentType: "application/json; charset=utf-8",
+				body: `{"events": [{"id": "1906189200000-0", "time": "2030-06-01T09:00:00Z", "workspace": "marketing", "actor": "ci",
+					"action": "create", "target": "ws:marketing:promo", "outcome": "succeeded"}], "next_cursor": "1906189200000-0"}`},
+			mocks: func(m mocksShortenerHandler) {
+				from := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+				m.auditService.EXPECT().Query(gomock.Any(), domain.AuditQuery{Workspace: "marketing", Actor: "ci",
+					Target: "ws:marketing:promo", From: &from, Limit: 1}).
+					Return(&domain.AuditPage{Events: []domain.AuditEvent{created}, NextCursor: created.ID}, nil)
+			},
+		},
+		{
+			name:   "WhenTheLogIsExported_ThenWritesEveryPageAsJSONLines",
+			path:   "/api/v1/audit?format=jsonl",
+			apiKey: "admin-key",
+			want: want{statusCode: http.StatusOK, contentType: "application/x-ndjson",
+				body: `{"id":"1906189260000-0","time":"2030-06-01T09:01:00Z","workspace":"marketing","actor":"ops","action":"delete","target":"ws:marketing:promo","outcome":"succeeded"}` + "\n" +
+					`{"id":"1906189200000-0","time":"2030-06-01T09:00:00Z","workspace":"marketing","actor":"ci","action":"create","target":"ws:marketing:promo","outcome":"succeeded"}` + "\n"},
+			mocks: func(m mocksShortenerHandler) {
+				m.auditService.EXPECT().Query(gomock.Any(), domain.AuditQuery{Workspace: "marketing", Limit: domain.MaxAuditLimit}).
+					Return(&domain.AuditPage{Events: []domain.AuditEvent{deleted}, NextCursor: deleted.ID}, nil)
+				m.auditService.EXPECT().Query(gomock.Any(), domain.AuditQuery{Workspace: "marketing", Limit: domain.MaxAuditLimit, Cursor: deleted.ID}).
+					Return(&domain.AuditPage{Events: []domain.AuditEvent{created}}, nil)
+			},
+		},
+		{
+			name:   "WhenLimitIsTooHigh_ThenReturnsBadRequest",
+			path:   "/api/v1/audit?limit=5000",
+			apiKey: "admin-key",
+			want: want{statusCode: http.StatusBadRequest, contentType: problem.ContentType, body: problemJSON(problem.InvalidRequest,
+				"invalid audit query: limit must be between 1 and 1000", "/api/v1/audit")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:   "WhenEditorReadsTheLog_ThenReturnsForbidden",
+			path:   "/api/v1/audit",
+			apiKey: "editor-key",
+			want: want{statusCode: http.StatusForbidden, contentType: problem.ContentType, body: problemJSON(problem.Forbidden,
+				domain.ErrForbidden.Error(), "/api/v1/audit")},
+			mocks: func(m mocksShortenerHandler) {
+				m.auditService.EXPECT().Record(gomock.Any(), auditEvent{Workspace: "marketing", Actor: "ci",
+					Action: domain.ActionReadAudit, Outcome: domain.AuditDenied}).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newMocks(ctrl)
+			tt.mocks(m)
+
+			w := serveAPI(t, m, "GET", tt.path, "", tt.apiKey)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+			if tt.want.contentType == "application/x-ndjson" {
+				assert.Equal(t, tt.want.body, w.Body.String())
+				return
+			}
+			assert.JSONEq(t, tt.want.body, w.Body.String())
+		})
+	}
+}
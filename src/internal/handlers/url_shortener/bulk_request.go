@@ -0,0 +1,223 @@
+package urlshortener
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	bulkFormatJSON   = "json"
+	bulkFormatNDJSON = "ndjson"
+	bulkFormatCSV    = "csv"
+)
+
+// BulkLinkItem is one URL of a bulk creation request. TTL is expressed in
+// seconds; zero keeps the default link lifetime. An empty Domain means the
+// default domain.
+type BulkLinkItem struct {
+	URL    string `json:"url"`
+	Alias  string `json:"alias,omitempty"`
+	TTL    int64  `json:"ttl,omitempty"`
+	Domain string `json:"domain,omitempty"`
+}
+
+// BulkLinkResult reports the outcome of a single BulkLinkItem, in the same
+// order as the request.
+type BulkLinkResult struct {
+	Index int    `json:"index"`
+	URL   string `json:"url"`
+	Code  string `json:"code,omitempty"`
+	Short string `json:"short_url,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Type is the problem type URI of Error, see docs/problems.md.
+	Type string `json:"type,omitempty"`
+}
+
+func (r *BulkLinkResult) setError(err error) {
+	p := problem.FromError(err)
+	r.Type = p.Type
+	r.Error = p.Detail
+	if r.Error == "" {
+		r.Error = p.Title
+	}
+}
+
+var errTooManyItems = errors.New("too many items in the batch")
+
+// parseBulkItems reads the request body as a JSON array, NDJSON or CSV,
+// either sent directly or as the "file" part of a multipart upload. It stops
+// reading once more than maxItems items have been seen.
+func parseBulkItems(c *gin.Context, maxItems int) ([]BulkLinkItem, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("reading the uploaded file --> %w", err)
+		}
+		file, err := fileHeader.Open()
+		if err != nil {
+			return nil, fmt.Errorf("opening the uploaded file --> %w", err)
+		}
+		defer file.Close()
+
+		format := formatFromFilename(fileHeader.Filename)
+		if format == "" {
+			partType, _, _ := mime.ParseMediaType(fileHeader.Header.Get("Content-Type"))
+			format = formatFromMediaType(partType)
+		}
+		return decodeBulkItems(file, format, maxItems)
+	}
+
+	return decodeBulkItems(c.Request.Body, formatFromMediaType(mediaType), maxItems)
+}
+
+func formatFromMediaType(mediaType string) string {
+	switch mediaType {
+	case "application/x-ndjson", "application/ndjson", "application/jsonl":
+		return bulkFormatNDJSON
+	case "text/csv":
+		return bulkFormatCSV
+	case "application/json":
+		return bulkFormatJSON
+	}
+	return ""
+}
+
+func formatFromFilename(filename string) string {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".ndjson", ".jsonl":
+		return bulkFormatNDJSON
+	case ".csv":
+		return bulkFormatCSV
+	case ".json":
+		return bulkFormatJSON
+	}
+	return ""
+}
+
+func decodeBulkItems(r io.Reader, format string, maxItems int) ([]BulkLinkItem, error) {
+	switch format {
+	case bulkFormatJSON:
+		return decodeJSONItems(r, maxItems)
+	case bulkFormatNDJSON:
+		return decodeNDJSONItems(r, maxItems)
+	case bulkFormatCSV:
+		return decodeCSVItems(r, maxItems)
+	}
+	return nil, errors.New("unsupported content type, use application/json, application/x-ndjson or text/csv")
+}
+
+func decodeJSONItems(r io.Reader, maxItems int) ([]BulkLinkItem, error) {
+	decoder := json.NewDecoder(r)
+	if _, err := decoder.Token(); err != nil {
+		return nil, fmt.Errorf("reading the JSON array --> %w", err)
+	}
+
+	var items []BulkLinkItem
+	for decoder.More() {
+		if len(items) == maxItems {
+			return nil, errTooManyItems
+		}
+		var item BulkLinkItem
+		if err := decoder.Decode(&item); err != nil {
+			return nil, fmt.Errorf("decoding item %d --> %w", len(items), err)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func decodeNDJSONItems(r io.Reader, maxItems int) ([]BulkLinkItem, error) {
+	scanner := bufio.NewScanner(r)
+	var items []BulkLinkItem
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if len(items) == maxItems {
+			return nil, errTooManyItems
+		}
+		var item BulkLinkItem
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			return nil, fmt.Errorf("decoding item %d --> %w", len(items), err)
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading the NDJSON body --> %w", err)
+	}
+	return items, nil
+}
+
+// decodeCSVItems accepts an optional "url,alias,ttl,domain" header. Without it
+// the columns are read in that order.
+func decodeCSVItems(r io.Reader, maxItems int) ([]BulkLinkItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	columns := map[string]int{"url": 0, "alias": 1, "ttl": 2, "domain": 3}
+	var items []BulkLinkItem
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading CSV row %d --> %w", row, err)
+		}
+		if row == 0 && isCSVHeader(record) {
+			columns = map[string]int{}
+			for i, name := range record {
+				columns[strings.ToLower(strings.TrimSpace(name))] = i
+			}
+			continue
+		}
+		if len(items) == maxItems {
+			return nil, errTooManyItems
+		}
+
+		item := BulkLinkItem{
+			URL:    csvField(record, columns, "url"),
+			Alias:  csvField(record, columns, "alias"),
+			Domain: csvField(record, columns, "domain"),
+		}
+		if ttl := csvField(record, columns, "ttl"); ttl != "" {
+			item.TTL, err = strconv.ParseInt(ttl, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("parsing the ttl of CSV row %d --> %w", row, err)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func isCSVHeader(record []string) bool {
+	for _, field := range record {
+		if strings.EqualFold(strings.TrimSpace(field), "url") {
+			return true
+		}
+	}
+	return false
+}
+
+func csvField(record []string, columns map[string]int, name string) string {
+	i, ok := columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
@@ -0,0 +1,66 @@
+package urlshortener
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type CampaignStatsResponse struct {
+	Campaign string `json:"campaign"`
+	Links    int    `json:"links"`
+	Clicks   int64  `json:"clicks"`
+	// TopLinks are the most clicked links of the campaign, most clicked
+	// first.
+	TopLinks []LinkSummary `json:"top_links"`
+}
+
+// GetCampaignStats adds up the clicks of the links of a campaign of the
+// workspace of the request and shows the top most clicked, domain.DefaultTopLinks
+// unless asked otherwise.
+func (u *URLShortenerHandler) GetCampaignStats(c *gin.Context) {
+	if !u.authorize(c, domain.ActionRead, "", nil) {
+		return
+	}
+
+	campaign, err := domain.NormalizeCampaign(c.Param("campaign"))
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	top := domain.DefaultTopLinks
+	if value := c.Query("top"); value != "" {
+		top, err = strconv.Atoi(value)
+		if err != nil || top < 1 || top > domain.MaxTopLinks {
+			problem.Abort(c, problem.New(problem.InvalidRequest,
+				fmt.Sprintf("top must be an integer between 1 and %d", domain.MaxTopLinks)))
+			return
+		}
+	}
+
+	links, err := u.storageService.CampaignLinks(c, workspaceOf(c).ID, campaign)
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the links of the campaign --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	stats := domain.SummarizeCampaign(campaign, links, top)
+	now := time.Now()
+	response := CampaignStatsResponse{
+		Campaign: stats.Campaign,
+		Links:    stats.Links,
+		Clicks:   stats.Clicks,
+		TopLinks: make([]LinkSummary, 0, len(stats.TopLinks)),
+	}
+	for _, listed := range stats.TopLinks {
+		response.TopLinks = append(response.TopLinks, u.linkSummary(listed, now))
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,101 @@
+package urlshortener_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetCampaignStats(t *testing.T) {
+	setDomains(t)
+
+	links := []domain.ListedLink{
+		{Link: domain.Link{Code: "a", OriginalURL: "https://example.com/a", Campaign: "launch"}, Clicks: 4},
+		{Link: domain.Link{Code: "b", OriginalURL: "https://example.com/b", Campaign: "launch", Tags: []string{"email"}}, Clicks: 9},
+		{Link: domain.Link{Code: "c", OriginalURL: "https://example.com/c", Campaign: "launch"}},
+	}
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		want  want
+		mocks func(m mocksShortenerHandler)
+	}{
+		{
+			name: "WhenCampaignHasLinks_ThenAddsUpTheirClicksAndShowsTheTopOnes",
+			path: "/api/v1/campaigns/Launch/stats?top=2",
+			want: want{statusCode: http.StatusOK, body: `{"campaign": "launch", "links": 3, "clicks": 13, "top_links": [
+				{"code": "b", "domain": "go.acme.com", "short_url": "https://go.acme.com/b", "url": "https://example.com/b",
+				 "tags": ["email"], "campaign": "launch", "status": "active", "clicks": 9},
+				{"code": "a", "domain": "go.acme.com", "short_url": "https://go.acme.com/a", "url": "https://example.com/a",
+				 "campaign": "launch", "status": "active", "clicks": 4}]}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().CampaignLinks(gomock.Any(), "", "launch").Return(links, nil)
+			},
+		},
+		{
+			name: "WhenCampaignHasNoLinks_ThenReturnsEmptyStats",
+			path: "/api/v1/campaigns/unknown/stats",
+			want: want{statusCode: http.StatusOK, body: `{"campaign": "unknown", "links": 0, "clicks": 0, "top_links": []}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().CampaignLinks(gomock.Any(), "", "unknown").Return([]domain.ListedLink{}, nil)
+			},
+		},
+		{
+			name: "WhenTopIsOutOfRange_ThenReturnsBadRequest",
+			path: "/api/v1/campaigns/launch/stats?top=0",
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"top must be an integer between 1 and 100", "/api/v1/campaigns/launch/stats")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name: "WhenStorageFails_ThenReturnsServiceUnavailable",
+			path: "/api/v1/campaigns/launch/stats",
+			want: want{statusCode: http.StatusServiceUnavailable},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().CampaignLinks(gomock.Any(), "", "launch").
+					Return(nil, errors.Join(domain.ErrStorageUnavailable, errors.New("connection refused")))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newMocks(ctrl)
+			tt.mocks(m)
+
+			w := serveAPI(t, m, "GET", tt.path, "", "")
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			if tt.want.body != "" {
+				assert.JSONEq(t, tt.want.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestGetCampaignStatsWhenServerHasWorkspaces_ThenReadsTheCampaignOfTheKey(t *testing.T) {
+	setWorkspaces(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newMocks(ctrl)
+	m.storageService.EXPECT().CampaignLinks(gomock.Any(), "marketing", "launch").Return([]domain.ListedLink{}, nil)
+
+	w := serveAPI(t, m, "GET", "/api/v1/campaigns/launch/stats", "", "viewer-key")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,91 @@
+package urlshortener
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// domainsFromEnv loads DOMAINS, a JSON file listing the short domains with
+// the default first, and the 404 pages they name, by domain name. Without it
+// every link is served from HOST.
+func domainsFromEnv() (*domain.Domains, map[string][]byte, error) {
+	path := os.Getenv("DOMAINS")
+	if path == "" {
+		return domain.SingleDomain(os.Getenv("HOST")), nil, nil
+	}
+	domains, notFoundPages, err := loadDomains(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading the domains --> %w", err)
+	}
+	return domains, notFoundPages, nil
+}
+
+func loadDomains(path string) (*domain.Domains, map[string][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading %s --> %w", path, err)
+	}
+	var list []domain.ShortDomain
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, nil, fmt.Errorf("decoding %s --> %w", path, err)
+	}
+	domains, err := domain.NewDomains(list)
+	if err != nil {
+		return nil, nil, fmt.Errorf("validating %s --> %w", path, err)
+	}
+
+	notFoundPages := make(map[string][]byte)
+	for _, shortDomain := range domains.All() {
+		if shortDomain.NotFoundPage == "" {
+			continue
+		}
+		page, err := os.ReadFile(shortDomain.NotFoundPage)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading the 404 page of %s --> %w", shortDomain.Name, err)
+		}
+		notFoundPages[shortDomain.Name] = page
+	}
+	return domains, notFoundPages, nil
+}
+
+// requestDomain returns the domain a redirect request was sent to, from its
+// Host header.
+func (u *URLShortenerHandler) requestDomain(c *gin.Context) domain.ShortDomain {
+	return u.domains.Resolve(c.Request.Host)
+}
+
+// queryDomain returns the domain named by the domain query parameter of a
+// management request, the default domain when it is missing.
+func (u *URLShortenerHandler) queryDomain(c *gin.Context) (domain.ShortDomain, error) {
+	return u.domains.Get(c.Query("domain"))
+}
+
+// DomainRoot sends visitors of the bare domain to its fallback URL.
+func (u *URLShortenerHandler) DomainRoot(c *gin.Context) {
+	shortDomain := u.requestDomain(c)
+	if shortDomain.FallbackURL == "" {
+		u.abortLinkError(c, shortDomain, domain.ErrLinkNotFound)
+		return
+	}
+	c.Redirect(http.StatusFound, shortDomain.FallbackURL)
+}
+
+// abortLinkError answers a redirect request that failed with err. Links the
+// domain does not have get its 404 page when it has one.
+func (u *URLShortenerHandler) abortLinkError(c *gin.Context, shortDomain domain.ShortDomain, err error) {
+	page, ok := u.notFoundPages[shortDomain.Name]
+	if !ok || !errors.Is(err, domain.ErrLinkNotFound) {
+		problem.AbortWithError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusNotFound, "text/html; charset=utf-8", page)
+	c.Abort()
+}
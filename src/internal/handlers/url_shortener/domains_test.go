@@ -0,0 +1,308 @@
+package urlshortener_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	urlshortener "github.com/dariomba/url-shortener/src/internal/handlers/url_shortener"
+	"github.com/dariomba/url-shortener/src/internal/mocks"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// setDomains configures go.acme.com, the default, and acme.link, which has a
+// 404 page.
+func setDomains(t *testing.T) {
+	dir := t.TempDir()
+	notFound := filepath.Join(dir, "acme-link-404.html")
+	err := os.WriteFile(notFound, []byte("<h1>Nothing here on acme.link</h1>"), 0o600)
+	assert.NoError(t, err)
+
+	domains := filepath.Join(dir, "domains.json")
+	err = os.WriteFile(domains, []byte(fmt.Sprintf(`[
+		{"name": "go.acme.com", "fallback_url": "https://acme.com"},
+		{"name": "acme.link", "not_found_page": %q}
+	]`, notFound)), 0o600)
+	assert.NoError(t, err)
+	t.Setenv("DOMAINS", domains)
+}
+
+func TestRedirectToURLOnDomains(t *testing.T) {
+	setDomains(t)
+
+	type want struct {
+		statusCode int
+		URL        string
+		page       string
+		body       string
+	}
+
+	tests := []struct {
+		name  string
+		host  string
+		path  string
+		want  want
+		mocks func(m mocksShortenerHandler)
+	}{
+		{
+			name: "WhenHostIsTheDefaultDomain_ThenLooksUpTheBareCode",
+			host: "go.acme.com",
+			path: "/promo",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com"}, nil)
+				m.healthService.EXPECT().Health(gomock.Any(), "promo").Return(nil, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "promo", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+		{
+			name: "WhenHostIsAnotherDomain_ThenLooksUpTheCodeInItsNamespace",
+			host: "acme.link:443",
+			path: "/promo",
+			want: want{statusCode: http.StatusFound, URL: "http://example.org"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "acme.link/promo").Return(&domain.Link{Code: "promo", Domain: "acme.link",
+					OriginalURL: "http://example.org"}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "acme.link/promo", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+		{
+			name: "WhenCodeIsNotFoundOnADomainWithA404Page_ThenServesThePage",
+			host: "acme.link",
+			path: "/missing",
+			want: want{statusCode: http.StatusNotFound, page: "<h1>Nothing here on acme.link</h1>"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "acme.link/missing").Return(nil, domain.ErrLinkNotFound)
+				m.storageService.EXPECT().ExpiredLink(gomock.Any(), "acme.link/missing").Return(nil, domain.ErrLinkNotFound)
+			},
+		},
+		{
+			name: "WhenCodeIsNotFoundOnADomainWithAFallback_ThenRedirectsToTheFallbackOfTheDomain",
+			host: "go.acme.com",
+			path: "/missing",
+			want: want{statusCode: http.StatusFound, URL: "https://acme.com"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "missing").Return(nil, domain.ErrLinkNotFound)
+				m.storageService.EXPECT().ExpiredLink(gomock.Any(), "missing").Return(nil, domain.ErrLinkNotFound)
+			},
+		},
+		{
+			name: "WhenLinkExpiredOnADomainWithAFallback_ThenRedirectsToTheFallbackOfTheLink",
+			host: "go.acme.com",
+			path: "/promo",
+			want: want{statusCode: http.StatusFound, URL: "https://acme.com/over"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(nil, domain.ErrLinkNotFound)
+				m.storageService.EXPECT().ExpiredLink(gomock.Any(), "promo").
+					Return(&domain.Link{Code: "promo", FallbackURL: "https://acme.com/over"}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "promo", domain.Click{Path: domain.PathExpired}).Return(nil)
+			},
+		},
+		{
+			name: "WhenLinkExpiredWithoutAFallback_ThenServesThe404Page",
+			host: "acme.link",
+			path: "/promo",
+			want: want{statusCode: http.StatusNotFound, page: "<h1>Nothing here on acme.link</h1>"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "acme.link/promo").Return(nil, domain.ErrLinkNotFound)
+				m.storageService.EXPECT().ExpiredLink(gomock.Any(), "acme.link/promo").Return(nil, domain.ErrLinkNotFound)
+			},
+		},
+		{
+			name: "WhenStorageFailsLookingForAnExpiredLink_ThenReturnsNotFound",
+			host: "acme.link",
+			path: "/missing",
+			want: want{statusCode: http.StatusNotFound, page: "<h1>Nothing here on acme.link</h1>"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "acme.link/missing").Return(nil, domain.ErrLinkNotFound)
+				m.storageService.EXPECT().ExpiredLink(gomock.Any(), "acme.link/missing").
+					Return(nil, domain.Detailed(domain.ErrStorageUnavailable, "connection refused"))
+			},
+		},
+		{
+			name: "WhenLinkIsDisabledOnADomainWithAFallback_ThenRedirectsToTheFallbackOfTheDomain",
+			host: "go.acme.com",
+			path: "/promo",
+			want: want{statusCode: http.StatusFound, URL: "https://acme.com"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").
+					Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com", Disabled: true}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "promo", domain.Click{Path: domain.PathDisabled}).Return(nil)
+			},
+		},
+		{
+			name: "WhenLinkIsDisabledOnADomainWithoutAFallback_ThenReturnsAProblem",
+			host: "acme.link",
+			path: "/promo",
+			want: want{statusCode: http.StatusNotFound, body: problemJSON(problem.LinkDisabled, domain.ErrLinkDisabled.Error(), "/promo")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "acme.link/promo").
+					Return(&domain.Link{Code: "promo", Domain: "acme.link", OriginalURL: "http://example.com", Disabled: true}, nil)
+			},
+		},
+		{
+			name:  "WhenRootOfADomainWithAFallbackIsVisited_ThenRedirectsToIt",
+			host:  "go.acme.com",
+			path:  "/",
+			want:  want{statusCode: http.StatusFound, URL: "https://acme.com"},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:  "WhenRootOfADomainWithoutAFallbackIsVisited_ThenServesThe404Page",
+			host:  "acme.link",
+			path:  "/",
+			want:  want{statusCode: http.StatusNotFound, page: "<h1>Nothing here on acme.link</h1>"},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+			m.geoIPService.EXPECT().Country(gomock.Any()).Return("").AnyTimes()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+			req.Host = tt.host
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			if tt.want.URL != "" {
+				assert.Equal(t, tt.want.URL, w.Header().Get("Location"))
+			}
+			if tt.want.page != "" {
+				assert.Equal(t, tt.want.page, w.Body.String())
+			}
+			if tt.want.body != "" {
+				assert.JSONEq(t, tt.want.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateLinkOnDomain(t *testing.T) {
+	setDomains(t)
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name        string
+		requestBody string
+		want        want
+		mocks       func(m mocksShortenerHandler)
+	}{
+		{
+			name:        "WhenDomainIsNotSet_ThenCreatesTheLinkOnTheDefaultDomain",
+			requestBody: `{"url": "http://example.com"}`,
+			want: want{statusCode: http.StatusOK,
+				body: `{"message": "short url created successfully!", "url": "https://go.acme.com/someLink"}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink("http://example.com").Return("someLink", nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "someLink", OriginalURL: "http://example.com", Shared: true}).Return(nil)
+			},
+		},
+		{
+			name:        "WhenDomainIsSet_ThenCreatesTheLinkInItsNamespace",
+			requestBody: `{"url": "http://example.com", "domain": "acme.link"}`,
+			want: want{statusCode: http.StatusOK,
+				body: `{"message": "short url created successfully!", "url": "https://acme.link/someLink"}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink("http://example.com").Return("someLink", nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "someLink", Domain: "acme.link",
+					OriginalURL: "http://example.com", Shared: true}).Return(nil)
+			},
+		},
+		{
+			name:        "WhenDomainIsNotConfigured_ThenReturnsBadRequest",
+			requestBody: `{"url": "http://example.com", "domain": "acme.dev"}`,
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"domain is not configured: acme.dev", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+			m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/v1/createLink", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.JSONEq(t, tt.want.body, w.Body.String())
+		})
+	}
+}
+
+func TestConfigFromEnvWhenDomainsAreInvalid_ThenReturnsAnError(t *testing.T) {
+	domains := filepath.Join(t.TempDir(), "domains.json")
+	err := os.WriteFile(domains, []byte(`[{"name": "not a host"}]`), 0o600)
+	assert.NoError(t, err)
+	t.Setenv("DOMAINS", domains)
+
+	_, err = urlshortener.ConfigFromEnv()
+	assert.ErrorIs(t, err, domain.ErrInvalidDomains)
+}
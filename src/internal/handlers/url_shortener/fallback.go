@@ -0,0 +1,111 @@
+package urlshortener
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// visitableLink returns the link a redirect request is for, when it can send
+// the visitor on. Otherwise it answers the request, with a fallback URL when
+// there is one, and reports false.
+func (u *URLShortenerHandler) visitableLink(c *gin.Context) (*domain.Link, bool) {
+	shortDomain := u.requestDomain(c)
+	link, err := u.findLink(c, shortDomain)
+	if errors.Is(err, domain.ErrLinkDisabled) && u.fallBack(c, link, domain.PathDisabled) {
+		return nil, false
+	}
+	if errors.Is(err, domain.ErrLinkNotFound) && u.answerExpired(c, shortDomain) {
+		return nil, false
+	}
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the original url --> %w", err))
+		u.abortLinkError(c, shortDomain, err)
+		return nil, false
+	}
+
+	if u.answerInactive(c, link) || u.answerBroken(c, link) {
+		return nil, false
+	}
+	return link, true
+}
+
+// fallbackURL is where visitors of link go when it cannot send them to its
+// destination: its own fallback URL, or else the one of the domain of the
+// request. Empty when neither has one.
+func (u *URLShortenerHandler) fallbackURL(c *gin.Context, link *domain.Link) string {
+	if link.FallbackURL != "" {
+		return link.FallbackURL
+	}
+	return u.requestDomain(c).FallbackURL
+}
+
+// fallBack sends the visitor to the fallback URL of link, counting the click
+// under path, and reports whether it did. Links without one are left to the
+// caller.
+func (u *URLShortenerHandler) fallBack(c *gin.Context, link *domain.Link, path string) bool {
+	target := u.fallbackURL(c, link)
+	if target == "" {
+		return false
+	}
+
+	click := domain.Click{Country: u.geoIPService.Country(c.ClientIP()), Path: path}
+	if err := u.analyticsService.RecordClick(c, link.Key(), click); err != nil {
+		log.Error(fmt.Errorf("recording the click --> %w", err))
+	}
+
+	// The link may recover, or be enabled again, at any time.
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, target)
+	c.Abort()
+	return true
+}
+
+// answerBroken sends the visitor to the fallback URL of a link whose
+// destination is broken, see domain.LinkHealth, and reports whether it did.
+// Links without a fallback URL are sent to their destination all the same.
+func (u *URLShortenerHandler) answerBroken(c *gin.Context, link *domain.Link) bool {
+	if u.fallbackURL(c, link) == "" {
+		return false
+	}
+	health, err := u.healthService.Health(c, link.Key())
+	if err != nil {
+		// Unknown health must not cost the visitor the redirect.
+		log.Error(fmt.Errorf("retrieving the health --> %w", err))
+		return false
+	}
+	if health == nil || !health.Broken() {
+		return false
+	}
+	return u.fallBack(c, link, domain.PathBroken)
+}
+
+// answerExpired sends the visitor of a code the domain of the request does
+// not have to a fallback URL, and reports whether it did. A link with a
+// fallback URL leaves a tombstone when it expires, see
+// storage.TombstoneDuration, and its visitors go to that fallback URL,
+// counted as domain.PathExpired. Other codes go to the fallback URL of the
+// domain unless it has a 404 page.
+func (u *URLShortenerHandler) answerExpired(c *gin.Context, shortDomain domain.ShortDomain) bool {
+	link, err := u.storageService.ExpiredLink(c, domain.LinkKey(shortDomain.Namespace, c.Param("link")))
+	if err == nil && answersPath(c, link) {
+		return u.fallBack(c, link, domain.PathExpired)
+	}
+	if err != nil && !errors.Is(err, domain.ErrLinkNotFound) {
+		// Storage is failing: answer not found as usual.
+		log.Error(fmt.Errorf("retrieving the expired url --> %w", err))
+		return false
+	}
+
+	if _, ok := u.notFoundPages[shortDomain.Name]; ok || shortDomain.FallbackURL == "" {
+		return false
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, shortDomain.FallbackURL)
+	c.Abort()
+	return true
+}
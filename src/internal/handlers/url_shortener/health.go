@@ -0,0 +1,71 @@
+package urlshortener
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// BrokenLinkSummary is a link as GET /links/broken lists it.
+type BrokenLinkSummary struct {
+	Code      string            `json:"code"`
+	Domain    string            `json:"domain"`
+	ShortURL  string            `json:"short_url"`
+	URL       string            `json:"url"`
+	Title     string            `json:"title,omitempty"`
+	CreatedBy string            `json:"created_by,omitempty"`
+	Health    domain.LinkHealth `json:"health"`
+}
+
+type BrokenLinksResponse struct {
+	Links []BrokenLinkSummary `json:"links"`
+}
+
+// ListBrokenLinks lists the links of the workspace of the request whose
+// destination failed its last health checks, most recently broken first.
+func (u *URLShortenerHandler) ListBrokenLinks(c *gin.Context) {
+	if !u.authorize(c, domain.ActionRead, "", nil) {
+		return
+	}
+
+	limit := domain.DefaultLinkLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > domain.MaxLinkLimit {
+			problem.Abort(c, problem.New(problem.InvalidRequest,
+				fmt.Sprintf("limit must be an integer between 1 and %d", domain.MaxLinkLimit)))
+			return
+		}
+	}
+
+	broken, err := u.healthService.BrokenLinks(c, workspaceOf(c).ID, limit)
+	if err != nil {
+		log.Error(fmt.Errorf("listing the broken links --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	response := BrokenLinksResponse{Links: make([]BrokenLinkSummary, 0, len(broken))}
+	for _, link := range broken {
+		shortDomain, err := u.domains.Get(link.Link.Domain)
+		if err != nil {
+			shortDomain = domain.ShortDomain{Name: link.Link.Domain}
+		}
+		response.Links = append(response.Links, BrokenLinkSummary{
+			Code:      link.Link.Code,
+			Domain:    shortDomain.Name,
+			ShortURL:  shortDomain.ShortURL(link.Link.Code),
+			URL:       link.Link.OriginalURL,
+			Title:     link.Link.Title,
+			CreatedBy: link.Link.CreatedBy,
+			Health:    link.Health,
+		})
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,99 @@
+package urlshortener_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListBrokenLinks(t *testing.T) {
+	setDomains(t)
+	since := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		want  want
+		mocks func(m mocksShortenerHandler)
+	}{
+		{
+			name: "WhenLinksAreBroken_ThenListsThemWithTheirHealth",
+			path: "/api/v1/links/broken?limit=10",
+			want: want{statusCode: http.StatusOK, body: `{"links": [
+				{"code": "a", "domain": "acme.link", "short_url": "https://acme.link/a", "url": "https://example.com/a",
+				 "title": "Spring sale", "created_by": "ci", "health": {"status": 0, "error": "connection refused",
+				 "latency_ms": 3, "checked_at": "2030-06-01T09:00:00Z", "failures": 3, "broken_since": "2030-06-01T09:00:00Z"}}]}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.healthService.EXPECT().BrokenLinks(gomock.Any(), "", 10).Return([]domain.BrokenLink{{
+					Link:   domain.Link{Code: "a", Domain: "acme.link", OriginalURL: "https://example.com/a", Title: "Spring sale", CreatedBy: "ci"},
+					Health: domain.LinkHealth{Error: "connection refused", LatencyMS: 3, CheckedAt: since, Failures: 3, BrokenSince: &since},
+				}}, nil)
+			},
+		},
+		{
+			name: "WhenNoLinkIsBroken_ThenReturnsAnEmptyList",
+			path: "/api/v1/links/broken",
+			want: want{statusCode: http.StatusOK, body: `{"links": []}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.healthService.EXPECT().BrokenLinks(gomock.Any(), "", domain.DefaultLinkLimit).Return([]domain.BrokenLink{}, nil)
+			},
+		},
+		{
+			name: "WhenLimitIsOutOfRange_ThenReturnsBadRequest",
+			path: "/api/v1/links/broken?limit=0",
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"limit must be an integer between 1 and 500", "/api/v1/links/broken")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name: "WhenStorageFails_ThenReturnsServiceUnavailable",
+			path: "/api/v1/links/broken",
+			want: want{statusCode: http.StatusServiceUnavailable},
+			mocks: func(m mocksShortenerHandler) {
+				m.healthService.EXPECT().BrokenLinks(gomock.Any(), "", domain.DefaultLinkLimit).
+					Return(nil, errors.Join(domain.ErrStorageUnavailable, errors.New("connection refused")))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newMocks(ctrl)
+			tt.mocks(m)
+
+			w := serveAPI(t, m, "GET", tt.path, "", "")
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			if tt.want.body != "" {
+				assert.JSONEq(t, tt.want.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListBrokenLinksWhenServerHasWorkspaces_ThenListsTheWorkspaceOfTheKey(t *testing.T) {
+	setWorkspaces(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newMocks(ctrl)
+	m.healthService.EXPECT().BrokenLinks(gomock.Any(), "marketing", domain.DefaultLinkLimit).Return([]domain.BrokenLink{}, nil)
+
+	w := serveAPI(t, m, "GET", "/api/v1/links/broken", "", "viewer-key")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -0,0 +1,131 @@
+package urlshortener
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// maxIdempotentBodyBytes bounds the bodies read into memory to be
+	// fingerprinted.
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+// responseRecorder keeps a copy of everything written to the client so the
+// response can be stored against its idempotency key.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// idempotent makes the wrapped route safe to retry when the client sends an
+// Idempotency-Key header. The first response is stored and replayed for the
+// same key and payload; a different payload under the same key gets a 422.
+// Only successes and validation errors are stored, see storable: any other
+// answer releases the key so the request can be retried.
+func (u *URLShortenerHandler) idempotent(c *gin.Context) {
+	key := c.GetHeader(idempotencyKeyHeader)
+	if key == "" {
+		c.Next()
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		problem.Abort(c, problem.New(problem.InvalidRequest,
+			fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength)))
+		return
+	}
+
+	// Keys are only unique within a workspace.
+	key = domain.WorkspaceKey(workspaceOf(c).ID, key)
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		problem.Abort(c, problem.New(problem.BodyTooLarge,
+			fmt.Sprintf("the request body must be at most %d bytes", maxIdempotentBodyBytes)))
+		return
+	}
+	if err != nil {
+		log.Error(fmt.Errorf("reading the request body --> %w", err))
+		problem.Abort(c, problem.New(problem.InvalidRequest, "the request body could not be read"))
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	fingerprint := requestFingerprint(c.Request.Method, c.FullPath(), body)
+	stored, err := u.idempotencyService.Reserve(c, key, fingerprint)
+	switch {
+	case err != nil:
+		log.Error(fmt.Errorf("reserving the idempotency key --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	case stored != nil:
+		c.Header(idempotentReplayedHeader, "true")
+		c.Data(stored.StatusCode, stored.ContentType, stored.Body)
+		c.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+	c.Next()
+
+	if !storable(recorder.Status()) {
+		if err := u.idempotencyService.Release(c, key); err != nil {
+			log.Error(fmt.Errorf("releasing the idempotency key --> %w", err))
+		}
+		return
+	}
+
+	err = u.idempotencyService.Save(c, key, domain.IdempotentResponse{
+		Fingerprint: fingerprint,
+		StatusCode:  recorder.Status(),
+		ContentType: recorder.Header().Get("Content-Type"),
+		Body:        recorder.body.Bytes(),
+	})
+	if err != nil {
+		log.Error(fmt.Errorf("saving the idempotent response --> %w", err))
+	}
+}
+
+// storable reports whether a response with status answers its request for
+// good. Conflicts, rate limits, missing permissions and server errors may
+// not be there on a retry.
+func storable(status int) bool {
+	switch status {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return true
+	}
+	return status >= http.StatusOK && status < http.StatusMultipleChoices
+}
+
+func requestFingerprint(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + " " + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
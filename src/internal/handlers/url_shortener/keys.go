@@ -0,0 +1,138 @@
+package urlshortener
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// Sources of the API keys of a workspace.
+const (
+	keySourceConfig = "config"
+	keySourceAPI    = "api"
+)
+
+type CreateKeyRequest struct {
+	Name string `json:"name" binding:"required"`
+	Role string `json:"role" binding:"required"`
+	Team string `json:"team"`
+}
+
+// KeyResponse describes an API key without its hash. Source tells whether it
+// comes from WORKSPACES or was created through the API, the only keys that
+// can be revoked through it.
+type KeyResponse struct {
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+	Team   string `json:"team,omitempty"`
+	Source string `json:"source"`
+}
+
+// CreateAPIKey creates a key in the workspace of the request. The key itself
+// is only ever returned in this response.
+func (u *URLShortenerHandler) CreateAPIKey(c *gin.Context) {
+	workspace, ok := ownWorkspace(c)
+	if !ok {
+		return
+	}
+
+	var req CreateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Error(fmt.Errorf("binding the JSON --> %w", err))
+		problem.Abort(c, problem.New(problem.InvalidRequest, "name and role parameters are required"))
+		return
+	}
+	if !u.authorize(c, domain.ActionCreateKey, req.Name, nil) {
+		return
+	}
+
+	key := domain.APIKey{Name: req.Name, Role: req.Role, Team: req.Team}
+	if err := domain.ValidateAPIKey(key); err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	if configuredKey(workspace, key.Name) {
+		problem.AbortWithError(c, domain.Detailed(domain.ErrKeyNameTaken, "%s", key.Name))
+		return
+	}
+
+	secret, err := domain.GenerateAPIKey()
+	if err != nil {
+		log.Error(fmt.Errorf("generating the key --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	key.Hash = domain.HashAPIKey(secret)
+
+	if err := u.keyService.Create(c, workspace.ID, key); err != nil {
+		log.Error(fmt.Errorf("creating the key --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	u.auditChange(c, domain.ActionCreateKey, key.Name, nil, key)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"name": key.Name,
+		"role": key.Role,
+		"team": key.Team,
+		"key":  secret,
+	})
+}
+
+// ListAPIKeys lists the keys of the workspace of the request, those of
+// WORKSPACES first.
+func (u *URLShortenerHandler) ListAPIKeys(c *gin.Context) {
+	workspace, ok := ownWorkspace(c)
+	if !ok || !u.authorize(c, domain.ActionListKeys, "", nil) {
+		return
+	}
+
+	stored, err := u.keyService.List(c, workspace.ID)
+	if err != nil {
+		log.Error(fmt.Errorf("listing the keys --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	keys := make([]KeyResponse, 0, len(workspace.APIKeys)+len(stored))
+	for _, key := range workspace.APIKeys {
+		keys = append(keys, KeyResponse{Name: key.Name, Role: key.Role, Team: key.Team, Source: keySourceConfig})
+	}
+	for _, key := range stored {
+		keys = append(keys, KeyResponse{Name: key.Name, Role: key.Role, Team: key.Team, Source: keySourceAPI})
+	}
+	c.JSON(http.StatusOK, gin.H{"keys": keys})
+}
+
+// RevokeAPIKey deletes a key created through the API. Keys of WORKSPACES can
+// only be removed from the file.
+func (u *URLShortenerHandler) RevokeAPIKey(c *gin.Context) {
+	workspace, ok := ownWorkspace(c)
+	name := c.Param("name")
+	if !ok || !u.authorize(c, domain.ActionRevokeKey, name, nil) {
+		return
+	}
+
+	if configuredKey(workspace, name) {
+		problem.Abort(c, problem.New(problem.InvalidRequest, "keys listed in WORKSPACES can only be removed from the file"))
+		return
+	}
+	if err := u.keyService.Revoke(c, workspace.ID, name); err != nil {
+		log.Error(fmt.Errorf("revoking the key --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	u.audit(c, domain.AuditEvent{Action: domain.ActionRevokeKey, Target: name, Outcome: domain.AuditSucceeded})
+	c.Status(http.StatusNoContent)
+}
+
+// configuredKey reports whether workspace has a key called name in
+// WORKSPACES.
+func configuredKey(workspace domain.Workspace, name string) bool {
+	return slices.ContainsFunc(workspace.APIKeys, func(key domain.APIKey) bool { return key.Name == name })
+}
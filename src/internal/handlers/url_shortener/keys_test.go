@@ -0,0 +1,206 @@
+package urlshortener_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	urlshortener "github.com/dariomba/url-shortener/src/internal/handlers/url_shortener"
+	"github.com/dariomba/url-shortener/src/internal/mocks"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeys(t *testing.T) {
+	setWorkspaces(t)
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		requestBody string
+		apiKey      string
+		want        want
+		mocks       func(m mocksShortenerHandler)
+	}{
+		{
+			name:        "WhenEditorCreatesAKey_ThenReturnsForbiddenAndAuditsIt",
+			method:      "POST",
+			path:        "/api/v1/workspaces/marketing/keys",
+			requestBody: `{"name": "bot", "role": "admin"}`,
+			apiKey:      "editor-key",
+			want: want{statusCode: http.StatusForbidden, body: problemJSON(problem.Forbidden,
+				domain.ErrForbidden.Error(), "/api/v1/workspaces/marketing/keys")},
+			mocks: func(m mocksShortenerHandler) {
+				m.auditService.EXPECT().Record(gomock.Any(), auditEvent{Workspace: "marketing", Actor: "ci",
+					Action: domain.ActionCreateKey, Target: "bot", Outcome: domain.AuditDenied}).Return(nil)
+			},
+		},
+		{
+			name:        "WhenKeyIsNamedAfterAKeyOfTheConfiguration_ThenReturnsConflict",
+			method:      "POST",
+			path:        "/api/v1/workspaces/marketing/keys",
+			requestBody: `{"name": "ci", "role": "viewer"}`,
+			apiKey:      "admin-key",
+			want: want{statusCode: http.StatusConflict, body: problemJSON(problem.KeyNameTaken,
+				"an API key with this name already exists: ci", "/api/v1/workspaces/marketing/keys")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenRoleIsUnknown_ThenReturnsBadRequest",
+			method:      "POST",
+			path:        "/api/v1/workspaces/marketing/keys",
+			requestBody: `{"name": "bot", "role": "owner"}`,
+			apiKey:      "admin-key",
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"invalid API key: role of bot must be viewer, editor or admin", "/api/v1/workspaces/marketing/keys")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:   "WhenAdminListsTheKeys_ThenListsThoseOfTheConfigurationFirst",
+			method: "GET",
+			path:   "/api/v1/workspaces/marketing/keys",
+			apiKey: "admin-key",
+			want: want{statusCode: http.StatusOK, body: `{"keys": [
+				{"name": "ops", "role": "admin", "source": "config"},
+				{"name": "ci", "role": "editor", "team": "growth", "source": "config"},
+				{"name": "dashboard", "role": "viewer", "source": "config"},
+				{"name": "bot", "role": "editor", "source": "api"}
+			]}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.keyService.EXPECT().List(gomock.Any(), "marketing").Return([]domain.APIKey{{Name: "bot", Role: domain.RoleEditor}}, nil)
+			},
+		},
+		{
+			name:   "WhenAKeyOfTheConfigurationIsRevoked_ThenReturnsBadRequest",
+			method: "DELETE",
+			path:   "/api/v1/workspaces/marketing/keys/ci",
+			apiKey: "admin-key",
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"keys listed in WORKSPACES can only be removed from the file", "/api/v1/workspaces/marketing/keys/ci")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:   "WhenAKeyCreatedThroughTheAPIIsRevoked_ThenRevokesIt",
+			method: "DELETE",
+			path:   "/api/v1/workspaces/marketing/keys/bot",
+			apiKey: "admin-key",
+			want:   want{statusCode: http.StatusNoContent},
+			mocks: func(m mocksShortenerHandler) {
+				m.keyService.EXPECT().Revoke(gomock.Any(), "marketing", "bot").Return(nil)
+			},
+		},
+		{
+			name:   "WhenTheKeyDoesNotExist_ThenReturnsNotFound",
+			method: "DELETE",
+			path:   "/api/v1/workspaces/marketing/keys/bot",
+			apiKey: "admin-key",
+			want: want{statusCode: http.StatusNotFound, body: problemJSON(problem.KeyNotFound,
+				"API key not found: bot", "/api/v1/workspaces/marketing/keys/bot")},
+			mocks: func(m mocksShortenerHandler) {
+				m.keyService.EXPECT().Revoke(gomock.Any(), "marketing", "bot").Return(domain.Detailed(domain.ErrKeyNotFound, "bot"))
+			},
+		},
+		{
+			name:   "WhenTheKeysOfAnotherWorkspaceAreListed_ThenReturnsNotFound",
+			method: "GET",
+			path:   "/api/v1/workspaces/sales/keys",
+			apiKey: "admin-key",
+			want: want{statusCode: http.StatusNotFound, body: problemJSON(problem.WorkspaceNotFound,
+				domain.ErrWorkspaceNotFound.Error(), "/api/v1/workspaces/sales/keys")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newMocks(ctrl)
+			tt.mocks(m)
+			m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+			w := serveAPI(t, m, tt.method, tt.path, tt.requestBody, tt.apiKey)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			if tt.want.body != "" {
+				assert.JSONEq(t, tt.want.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCreateAPIKeyWhenAdminCreatesIt_ThenReturnsTheKeyOnce(t *testing.T) {
+	setWorkspaces(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newMocks(ctrl)
+	var stored domain.APIKey
+	m.keyService.EXPECT().Create(gomock.Any(), "marketing", gomock.Any()).
+		Do(func(_ context.Context, _ string, key domain.APIKey) { stored = key }).Return(nil)
+	m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil)
+
+	w := serveAPI(t, m, "POST", "/api/v1/workspaces/marketing/keys", `{"name": "bot", "role": "editor", "team": "growth"}`, "admin-key")
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var created struct {
+		Name string `json:"name"`
+		Role string `json:"role"`
+		Team string `json:"team"`
+		Key  string `json:"key"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "bot", created.Name)
+	assert.Equal(t, domain.RoleEditor, created.Role)
+	assert.Equal(t, "growth", created.Team)
+	assert.NotEmpty(t, created.Key)
+	assert.Equal(t, domain.APIKey{Name: "bot", Role: domain.RoleEditor, Team: "growth", Hash: domain.HashAPIKey(created.Key)}, stored)
+}
+
+// newMocks returns a mock of every service of the handler.
+func newMocks(ctrl *gomock.Controller) mocksShortenerHandler {
+	return mocksShortenerHandler{
+		storageService:     mocks.NewMockStorageService(ctrl),
+		shortenerService:   mocks.NewMockShortenerService(ctrl),
+		idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+		qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+		lockoutService:     mocks.NewMockLockoutService(ctrl),
+		analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+		geoIPService:       mocks.NewMockGeoIPService(ctrl),
+		quotaService:       mocks.NewMockQuotaService(ctrl),
+		keyService:         mocks.NewMockKeyService(ctrl),
+		auditService:       mocks.NewMockAuditService(ctrl),
+		titleService:       mocks.NewMockTitleService(ctrl),
+		healthService:      mocks.NewMockHealthService(ctrl),
+	}
+}
+
+// serveAPI sends a request with apiKey as its bearer token to a handler
+// backed by m.
+func serveAPI(t *testing.T, m mocksShortenerHandler, method string, path string, body string, apiKey string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	router.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,125 @@
+package urlshortener
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// LinkSummary is a link as GET /links lists it.
+type LinkSummary struct {
+	Code      string     `json:"code"`
+	Domain    string     `json:"domain"`
+	ShortURL  string     `json:"short_url"`
+	URL       string     `json:"url"`
+	CreatedBy string     `json:"created_by,omitempty"`
+	Team      string     `json:"team,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+	Title     string     `json:"title,omitempty"`
+	Tags      []string   `json:"tags,omitempty"`
+	Campaign  string     `json:"campaign,omitempty"`
+	Status    string     `json:"status"`
+	Clicks    int64      `json:"clicks"`
+}
+
+type LinkListResponse struct {
+	Links      []LinkSummary `json:"links"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+// ListLinks lists the links of the workspace of the request, newest or most
+// clicked first, one page at a time.
+func (u *URLShortenerHandler) ListLinks(c *gin.Context) {
+	if !u.authorize(c, domain.ActionRead, "", nil) {
+		return
+	}
+
+	query, err := u.linkQueryFromRequest(c)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	page, err := u.storageService.ListLinks(c, query)
+	if err != nil {
+		log.Error(fmt.Errorf("listing the links --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	now := time.Now()
+	response := LinkListResponse{Links: make([]LinkSummary, 0, len(page.Links)), NextCursor: page.NextCursor}
+	for _, listed := range page.Links {
+		response.Links = append(response.Links, u.linkSummary(listed, now))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (u *URLShortenerHandler) linkSummary(listed domain.ListedLink, now time.Time) LinkSummary {
+	shortDomain, err := u.domains.Get(listed.Link.Domain)
+	if err != nil {
+		// The domain was removed from DOMAINS, the link is no longer
+		// reachable and its short URL is unknown.
+		shortDomain = domain.ShortDomain{Name: listed.Link.Domain}
+	}
+	return LinkSummary{
+		Code:      listed.Link.Code,
+		Domain:    shortDomain.Name,
+		ShortURL:  shortDomain.ShortURL(listed.Link.Code),
+		URL:       listed.Link.OriginalURL,
+		CreatedBy: listed.Link.CreatedBy,
+		Team:      listed.Link.Team,
+		CreatedAt: listed.Link.CreatedAt,
+		Title:     listed.Link.Title,
+		Tags:      listed.Link.Tags,
+		Campaign:  listed.Link.Campaign,
+		Status:    listed.Status(now),
+		Clicks:    listed.Clicks,
+	}
+}
+
+// linkQueryFromRequest reads the filters of ListLinks: owner, domain,
+// created_from and created_to as RFC 3339 times, status, host, q to search
+// the destinations, titles and notes, tag, campaign, sort, and the cursor and
+// limit of the page.
+func (u *URLShortenerHandler) linkQueryFromRequest(c *gin.Context) (domain.LinkQuery, error) {
+	query := domain.LinkQuery{
+		Workspace: workspaceOf(c).ID,
+		Owner:     c.Query("owner"),
+		Status:    c.Query("status"),
+		Host:      c.Query("host"),
+		Search:    c.Query("q"),
+		Tag:       c.Query("tag"),
+		Campaign:  c.Query("campaign"),
+		Sort:      c.Query("sort"),
+		Cursor:    c.Query("cursor"),
+	}
+
+	if name := c.Query("domain"); name != "" {
+		shortDomain, err := u.domains.Get(name)
+		if err != nil {
+			return domain.LinkQuery{}, err
+		}
+		query.Domain = &shortDomain.Namespace
+	}
+
+	var err error
+	if query.CreatedFrom, err = timeQuery(c, "created_from", domain.ErrInvalidLinkQuery); err != nil {
+		return domain.LinkQuery{}, err
+	}
+	if query.CreatedTo, err = timeQuery(c, "created_to", domain.ErrInvalidLinkQuery); err != nil {
+		return domain.LinkQuery{}, err
+	}
+	if limit, ok := c.GetQuery("limit"); ok {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			return domain.LinkQuery{}, domain.Detailed(domain.ErrInvalidLinkQuery, "limit must be an integer")
+		}
+	}
+	return query, query.Validate()
+}
@@ -0,0 +1,118 @@
+package urlshortener_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListLinks(t *testing.T) {
+	setDomains(t)
+
+	created := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	page := &domain.LinkPage{
+		Links: []domain.ListedLink{
+			{Link: domain.Link{Code: "promo", Domain: "acme.link", OriginalURL: "https://shop.example.com", CreatedBy: "ci", CreatedAt: &created,
+				Tags: []string{"summer"}, Campaign: "launch"}, Clicks: 12},
+			{Link: domain.Link{Code: "old", OriginalURL: "https://example.com", Disabled: true}},
+		},
+		NextCursor: "next",
+	}
+	acmeLink := "acme.link"
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		want  want
+		mocks func(m mocksShortenerHandler)
+	}{
+		{
+			name: "WhenNoFilterIsSet_ThenListsTheNewestLinks",
+			path: "/api/v1/links",
+			want: want{statusCode: http.StatusOK, body: `{"links": [
+				{"code": "promo", "domain": "acme.link", "short_url": "https://acme.link/promo", "url": "https://shop.example.com",
+				 "created_by": "ci", "created_at": "2030-06-01T09:00:00Z", "tags": ["summer"], "campaign": "launch", "status": "active", "clicks": 12},
+				{"code": "old", "domain": "go.acme.com", "short_url": "https://go.acme.com/old", "url": "https://example.com",
+				 "status": "disabled", "clicks": 0}], "next_cursor": "next"}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().ListLinks(gomock.Any(), domain.LinkQuery{Sort: domain.SortCreated, Limit: domain.DefaultLinkLimit}).Return(page, nil)
+			},
+		},
+		{
+			name: "WhenFiltersAreSet_ThenPassesThemOn",
+			path: "/api/v1/links?owner=ci&domain=acme.link&created_from=2030-06-01T00:00:00Z&status=active&host=Shop.example.com&q=Sale&sort=clicks&cursor=abc&limit=10",
+			want: want{statusCode: http.StatusOK},
+			mocks: func(m mocksShortenerHandler) {
+				from := time.Date(2030, 6, 1, 0, 0, 0, 0, time.UTC)
+				m.storageService.EXPECT().ListLinks(gomock.Any(), domain.LinkQuery{Owner: "ci", Domain: &acmeLink, CreatedFrom: &from,
+					Status: domain.StatusActive, Host: "shop.example.com", Search: "sale", Sort: domain.SortClicks, Cursor: "abc", Limit: 10}).
+					Return(&domain.LinkPage{}, nil)
+			},
+		},
+		{
+			name: "WhenTagAndCampaignAreSet_ThenPassesThemOnLowercased",
+			path: "/api/v1/links?tag=Summer&campaign=Launch",
+			want: want{statusCode: http.StatusOK},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().ListLinks(gomock.Any(), domain.LinkQuery{Tag: "summer", Campaign: "launch",
+					Sort: domain.SortCreated, Limit: domain.DefaultLinkLimit}).Return(&domain.LinkPage{}, nil)
+			},
+		},
+		{
+			name: "WhenSortIsUnknown_ThenReturnsBadRequest",
+			path: "/api/v1/links?sort=title",
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"invalid link query: sort must be created or clicks", "/api/v1/links")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name: "WhenCreationTimeIsMalformed_ThenReturnsBadRequest",
+			path: "/api/v1/links?created_to=yesterday",
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"invalid link query: created_to must be an RFC 3339 time", "/api/v1/links")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newMocks(ctrl)
+			tt.mocks(m)
+
+			w := serveAPI(t, m, "GET", tt.path, "", "")
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			if tt.want.body != "" {
+				assert.JSONEq(t, tt.want.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestListLinksWhenServerHasWorkspaces_ThenListsOnlyTheLinksOfTheKey(t *testing.T) {
+	setWorkspaces(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newMocks(ctrl)
+	m.storageService.EXPECT().ListLinks(gomock.Any(), domain.LinkQuery{Workspace: "marketing", Sort: domain.SortCreated,
+		Limit: domain.DefaultLinkLimit}).Return(&domain.LinkPage{}, nil)
+
+	w := serveAPI(t, m, "GET", "/api/v1/links", "", "viewer-key")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"links": []}`, w.Body.String())
+}
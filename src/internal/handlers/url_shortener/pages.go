@@ -0,0 +1,46 @@
+package urlshortener
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+var pages = template.Must(template.ParseFS(templatesFS, "templates/*.html"))
+
+// passwordPage asks for the password of a link. The form posts back to
+// Action, the URL it was shown for, so that passthrough paths and query
+// parameters survive the unlock.
+type passwordPage struct {
+	Action  string
+	Message string
+}
+
+// comingSoonPage is shown outside the window of a link. OpensAt is nil once
+// the window has closed.
+type comingSoonPage struct {
+	OpensAt *time.Time
+}
+
+// renderPage writes one of the HTML templates. Pages are never cached since
+// they answer for a single visitor.
+func renderPage(c *gin.Context, status int, name string, data interface{}) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(status)
+	if err := pages.ExecuteTemplate(c.Writer, name, data); err != nil {
+		log.Error(fmt.Errorf("rendering the %s page --> %w", name, err))
+	}
+	c.Abort()
+}
+
+func renderPasswordPage(c *gin.Context, status int, message string) {
+	renderPage(c, status, "password.html", passwordPage{Action: c.Request.URL.RequestURI(), Message: message})
+}
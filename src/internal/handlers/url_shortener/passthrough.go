@@ -0,0 +1,32 @@
+package urlshortener
+
+import (
+	"strings"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// findLink returns the link a redirect request to shortDomain is for. Only
+// wildcard links answer below their code: /code/more is not found for any
+// other link.
+func (u *URLShortenerHandler) findLink(c *gin.Context, shortDomain domain.ShortDomain) (*domain.Link, error) {
+	link, err := u.storageService.GetLink(c, domain.LinkKey(shortDomain.Namespace, c.Param("link")))
+	if err != nil {
+		return nil, err
+	}
+	if !answersPath(c, link) {
+		return nil, domain.ErrLinkNotFound
+	}
+	if link.Disabled {
+		// The link comes along for its fallback URL.
+		return link, domain.ErrLinkDisabled
+	}
+	return link, nil
+}
+
+// answersPath reports whether link answers the path of the request below its
+// code, if any.
+func answersPath(c *gin.Context, link *domain.Link) bool {
+	return link.Wildcard || strings.Trim(c.Param("path"), "/") == ""
+}
@@ -0,0 +1,144 @@
+package urlshortener
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/dariomba/url-shortener/src/internal/config"
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/text/language"
+)
+
+const (
+	// defaultPreviewLanguage is used when the visitor accepts none of the
+	// translations.
+	defaultPreviewLanguage = "en"
+	defaultPreviewAccent   = "#2563eb"
+)
+
+//go:embed templates/locales/*.json
+var localesFS embed.FS
+
+// previewMessages are the texts of the preview page in one language, read
+// from templates/locales/<language>.json.
+type previewMessages struct {
+	Title    string `json:"title"`
+	Leaving  string `json:"leaving"`
+	Warning  string `json:"warning"`
+	Continue string `json:"continue"`
+}
+
+var previewLanguages, previewLocales = loadPreviewLocales()
+
+var previewMatcher = language.NewMatcher(previewLanguages)
+
+// loadPreviewLocales reads every embedded translation. The default language
+// goes first, since the matcher falls back to the first tag.
+func loadPreviewLocales() ([]language.Tag, map[language.Tag]previewMessages) {
+	files, err := localesFS.ReadDir("templates/locales")
+	if err != nil {
+		panic(fmt.Errorf("reading the preview translations --> %w", err))
+	}
+
+	tags := []language.Tag{language.MustParse(defaultPreviewLanguage)}
+	locales := make(map[language.Tag]previewMessages)
+	for _, file := range files {
+		data, err := localesFS.ReadFile("templates/locales/" + file.Name())
+		if err != nil {
+			panic(fmt.Errorf("reading the preview translation %s --> %w", file.Name(), err))
+		}
+		var messages previewMessages
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Errorf("decoding the preview translation %s --> %w", file.Name(), err))
+		}
+		tag := language.MustParse(strings.TrimSuffix(file.Name(), path.Ext(file.Name())))
+		if tag != tags[0] {
+			tags = append(tags, tag)
+		}
+		locales[tag] = messages
+	}
+	return tags, locales
+}
+
+// previewTheme brands the preview page, see the PREVIEW_* variables.
+type previewTheme struct {
+	Brand   string
+	Accent  string
+	LogoURL string
+}
+
+func previewThemeFromEnv() previewTheme {
+	theme := previewTheme{
+		Brand:   os.Getenv("PREVIEW_BRAND"),
+		Accent:  defaultPreviewAccent,
+		LogoURL: os.Getenv("PREVIEW_LOGO_URL"),
+	}
+	if accent := os.Getenv("PREVIEW_ACCENT_COLOR"); accent != "" {
+		rgb, err := domain.ParseHexColor(accent)
+		if err != nil {
+			log.Warnf("PREVIEW_ACCENT_COLOR %s, using %s", err, defaultPreviewAccent)
+			return theme
+		}
+		theme.Accent = fmt.Sprintf("#%02x%02x%02x", rgb.R, rgb.G, rgb.B)
+	}
+	return theme
+}
+
+// previewPolicyFromEnv reads which links show the preview page from
+// PREVIEW_LINKS, by default only those created by untrusted clients, and the
+// domains that never do from PREVIEW_SAFE_DOMAINS.
+func previewPolicyFromEnv() domain.PreviewPolicy {
+	policy := domain.PreviewPolicy{
+		Mode:        os.Getenv("PREVIEW_LINKS"),
+		SafeDomains: config.Strings("PREVIEW_SAFE_DOMAINS"),
+	}
+	switch policy.Mode {
+	case "":
+		policy.Mode = domain.PreviewUntrusted
+	case domain.PreviewNone, domain.PreviewUntrusted, domain.PreviewAll:
+	default:
+		log.Warnf("PREVIEW_LINKS must be %s, %s or %s, using %s",
+			domain.PreviewNone, domain.PreviewUntrusted, domain.PreviewAll, domain.PreviewUntrusted)
+		policy.Mode = domain.PreviewUntrusted
+	}
+	return policy
+}
+
+// previewPage shows where a link leads before going there. The form posts
+// back to Action, which UnlockLink answers with the redirect.
+type previewPage struct {
+	Lang        string
+	Messages    previewMessages
+	Theme       previewTheme
+	Host        string
+	Destination string
+	Action      string
+}
+
+func renderPreviewPage(c *gin.Context, theme previewTheme, destination string) {
+	accepted, _, _ := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	_, index, _ := previewMatcher.Match(accepted...)
+	tag := previewLanguages[index]
+
+	page := previewPage{
+		Lang:        tag.String(),
+		Messages:    previewLocales[tag],
+		Theme:       theme,
+		Destination: destination,
+		Action:      c.Request.URL.RequestURI(),
+	}
+	if target, err := url.Parse(destination); err == nil {
+		page.Host = strings.ToLower(target.Hostname())
+	}
+
+	c.Header("Vary", "Accept-Language")
+	renderPage(c, http.StatusOK, "preview.html", page)
+}
@@ -0,0 +1,118 @@
+package urlshortener_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	urlshortener "github.com/dariomba/url-shortener/src/internal/handlers/url_shortener"
+	"github.com/dariomba/url-shortener/src/internal/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectToURLWithPreview(t *testing.T) {
+	t.Setenv("PREVIEW_SAFE_DOMAINS", "docs.example.org")
+	t.Setenv("PREVIEW_BRAND", "Acme")
+	t.Setenv("PREVIEW_ACCENT_COLOR", "FF5500")
+
+	untrusted := &domain.Link{Code: "someLink", OriginalURL: "https://Example.com/landing", CreatorTrust: domain.TrustUntrusted}
+
+	type want struct {
+		statusCode int
+		URL        string
+		page       []string
+	}
+
+	tests := []struct {
+		name           string
+		method         string
+		acceptLanguage string
+		want           want
+		mocks          func(m mocksShortenerHandler)
+	}{
+		{
+			name:   "WhenCreatorIsUntrusted_ThenShowsTheDestinationFirst",
+			method: "GET",
+			want: want{statusCode: http.StatusOK, page: []string{`<html lang="en">`, `<p class="host">example.com</p>`,
+				`--accent: #ff5500`, `<title>You are leaving this site · Acme</title>`, `<form method="post" action="/someLink">`}},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(untrusted, nil)
+			},
+		},
+		{
+			name:           "WhenVisitorPrefersAnotherLanguage_ThenTranslatesThePage",
+			method:         "GET",
+			acceptLanguage: "es-ES,es;q=0.9,en;q=0.8",
+			want:           want{statusCode: http.StatusOK, page: []string{`<html lang="es">`, `Continuar`}},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(untrusted, nil)
+			},
+		},
+		{
+			name:   "WhenDestinationIsASafeDomain_ThenRedirectsStraightAway",
+			method: "GET",
+			want:   want{statusCode: http.StatusFound, URL: "https://docs.example.org/start"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(&domain.Link{Code: "someLink",
+					OriginalURL: "https://docs.example.org/start", CreatorTrust: domain.TrustUntrusted}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+		{
+			name:   "WhenVisitorContinues_ThenRedirectsAndCountsTheClick",
+			method: "POST",
+			want:   want{statusCode: http.StatusSeeOther, URL: "https://Example.com/landing"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(untrusted, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+			m.geoIPService.EXPECT().Country(gomock.Any()).Return("").AnyTimes()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, "/someLink", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			if tt.want.URL != "" {
+				assert.Equal(t, tt.want.URL, w.Header().Get("Location"))
+			}
+			for _, fragment := range tt.want.page {
+				assert.Contains(t, w.Body.String(), fragment)
+			}
+		})
+	}
+}
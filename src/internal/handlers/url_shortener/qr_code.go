@@ -0,0 +1,45 @@
+package urlshortener
+
+import (
+	"strconv"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+var qrContentTypes = map[string]string{
+	domain.QRFormatPNG: "image/png",
+	domain.QRFormatSVG: "image/svg+xml",
+}
+
+// qrOptionsFromQuery overrides the default QR options with the format, size,
+// level, margin, fg and bg query parameters. Range checks are left to
+// QROptions.Validate.
+func qrOptionsFromQuery(c *gin.Context) (domain.QROptions, error) {
+	options := domain.DefaultQROptions()
+	if format, ok := c.GetQuery("format"); ok {
+		options.Format = format
+	}
+	if level, ok := c.GetQuery("level"); ok {
+		options.Level = level
+	}
+	if fg, ok := c.GetQuery("fg"); ok {
+		options.Foreground = fg
+	}
+	if bg, ok := c.GetQuery("bg"); ok {
+		options.Background = bg
+	}
+
+	var err error
+	if size, ok := c.GetQuery("size"); ok {
+		if options.Size, err = strconv.Atoi(size); err != nil {
+			return options, domain.Detailed(domain.ErrInvalidQROptions, "size must be an integer")
+		}
+	}
+	if margin, ok := c.GetQuery("margin"); ok {
+		if options.Margin, err = strconv.Atoi(margin); err != nil {
+			return options, domain.Detailed(domain.ErrInvalidQROptions, "margin must be an integer")
+		}
+	}
+	return options, nil
+}
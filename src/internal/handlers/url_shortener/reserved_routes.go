@@ -0,0 +1,44 @@
+package urlshortener
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/ports"
+	"github.com/gin-gonic/gin"
+)
+
+// ReserveRoutes adds the first segment of every static route to reserved.
+// Call it once every handler is registered so that no short code can be
+// created under a path a route already answers.
+func ReserveRoutes(routes gin.RoutesInfo, reserved *domain.ReservedWords) {
+	for _, route := range routes {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route.Path, "/"), "/")
+		if segment == "" || strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			continue
+		}
+		reserved.Add(segment)
+	}
+}
+
+// CheckReservedCodes returns the short URLs, on every domain, of the links
+// whose code is a reserved word in any case. Those links were created before
+// the word was reserved: the ones matching a route can no longer be reached,
+// and none could be created again, so they need to be moved to a new code.
+func CheckReservedCodes(ctx context.Context, storageService ports.StorageService, domains *domain.Domains, reserved *domain.ReservedWords) ([]string, error) {
+	var shadowed []string
+	for _, shortDomain := range domains.All() {
+		for _, word := range reserved.Words() {
+			codes, err := storageService.FindCodes(ctx, shortDomain.Namespace, word)
+			if err != nil {
+				return nil, fmt.Errorf("checking the reserved word %s on %s --> %w", word, shortDomain.Name, err)
+			}
+			for _, code := range codes {
+				shadowed = append(shadowed, shortDomain.ShortURL(code))
+			}
+		}
+	}
+	return shadowed, nil
+}
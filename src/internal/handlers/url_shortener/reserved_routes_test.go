@@ -0,0 +1,80 @@
+package urlshortener_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	urlshortener "github.com/dariomba/url-shortener/src/internal/handlers/url_shortener"
+	"github.com/dariomba/url-shortener/src/internal/mocks"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserveRoutes(t *testing.T) {
+	routes := gin.RoutesInfo{
+		{Method: "GET", Path: "/:link"},
+		{Method: "GET", Path: "/healthz"},
+		{Method: "POST", Path: "/api/v1/createLink"},
+		{Method: "GET", Path: "/*filepath"},
+	}
+	reserved := domain.NewReservedWords()
+
+	urlshortener.ReserveRoutes(routes, reserved)
+
+	assert.Equal(t, []string{"api", "healthz"}, reserved.Words())
+}
+
+func TestCheckReservedCodes(t *testing.T) {
+	domains, err := domain.NewDomains([]domain.ShortDomain{{Name: "go.acme.com"}, {Name: "acme.link"}})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		mocks    func(m *mocks.MockStorageService)
+		expected []string
+		err      error
+	}{
+		{
+			name: "WhenNoReservedWordIsStored_ThenReturnsNothing",
+			mocks: func(m *mocks.MockStorageService) {
+				m.EXPECT().FindCodes(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(4)
+			},
+		},
+		{
+			name: "WhenAReservedWordIsStoredInAnyCaseOnAnyDomain_ThenReturnsItsShortURL",
+			mocks: func(m *mocks.MockStorageService) {
+				m.EXPECT().FindCodes(gomock.Any(), "", "docs").Return([]string{"Docs"}, nil)
+				m.EXPECT().FindCodes(gomock.Any(), "", "healthz").Return(nil, nil)
+				m.EXPECT().FindCodes(gomock.Any(), "acme.link", "docs").Return([]string{"docs", "DOCS"}, nil)
+				m.EXPECT().FindCodes(gomock.Any(), "acme.link", "healthz").Return(nil, nil)
+			},
+			expected: []string{"https://go.acme.com/Docs", "https://acme.link/docs", "https://acme.link/DOCS"},
+		},
+		{
+			name: "WhenStorageFails_ThenReturnsError",
+			mocks: func(m *mocks.MockStorageService) {
+				m.EXPECT().FindCodes(gomock.Any(), "", "docs").Return(nil, domain.ErrStorageUnavailable)
+			},
+			err: domain.ErrStorageUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storageService := mocks.NewMockStorageService(ctrl)
+			tt.mocks(storageService)
+
+			shadowed, err := urlshortener.CheckReservedCodes(context.Background(), storageService, domains,
+				domain.NewReservedWords("healthz", "docs"))
+
+			assert.True(t, errors.Is(err, tt.err))
+			assert.Equal(t, tt.expected, shadowed)
+		})
+	}
+}
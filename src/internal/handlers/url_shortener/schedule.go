@@ -0,0 +1,71 @@
+package urlshortener
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultInactiveMode reads INACTIVE_LINK_MODE, the answer of links outside
+// their window when they do not set one themselves.
+func defaultInactiveMode() string {
+	mode := os.Getenv("INACTIVE_LINK_MODE")
+	switch mode {
+	case "":
+		return domain.InactiveNotFound
+	case domain.InactiveNotFound, domain.InactiveComingSoon:
+		return mode
+	}
+	// A server wide fallback would need a server wide URL, which links
+	// already set one by one.
+	log.Warnf("INACTIVE_LINK_MODE must be %s or %s, using %s",
+		domain.InactiveNotFound, domain.InactiveComingSoon, domain.InactiveNotFound)
+	return domain.InactiveNotFound
+}
+
+func (u *URLShortenerHandler) inactiveModeOf(link *domain.Link) string {
+	if link.InactiveMode != "" {
+		return link.InactiveMode
+	}
+	return u.inactiveMode
+}
+
+// answerInactive responds for a link whose activation window is not open and
+// reports whether it did. Active links are left to the caller. Links whose
+// window closed without an InactiveMode of their own fall back, see fallBack,
+// before getting the server default.
+func (u *URLShortenerHandler) answerInactive(c *gin.Context, link *domain.Link) bool {
+	state := link.State(time.Now())
+	if state == domain.LinkActive {
+		return false
+	}
+	path := domain.PathExpired
+	if state == domain.LinkPending {
+		path = domain.PathPending
+	}
+	if state == domain.LinkEnded && link.InactiveMode == "" && u.fallBack(c, link, path) {
+		return true
+	}
+
+	// The answer changes once the window opens or closes.
+	c.Header("Cache-Control", "no-store")
+
+	switch u.inactiveModeOf(link) {
+	case domain.InactiveFallback:
+		u.fallBack(c, link, path)
+	case domain.InactiveComingSoon:
+		page := comingSoonPage{}
+		if state == domain.LinkPending {
+			page.OpensAt = link.ActiveFrom
+		}
+		renderPage(c, http.StatusNotFound, "coming_soon.html", page)
+	default:
+		problem.AbortWithError(c, domain.ErrLinkInactive)
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+package urlshortener
+
+import (
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/mileusna/useragent"
+)
+
+// visitorFromRequest describes the client from its User-Agent header and the
+// country of its IP. The IP honours X-Forwarded-For only from the proxies
+// trusted by the router, see TRUSTED_PROXIES. Visitors without a variant
+// cookie are told apart by their IP and User-Agent.
+func (u *URLShortenerHandler) visitorFromRequest(c *gin.Context, code string) domain.Visitor {
+	ua := useragent.Parse(c.GetHeader("User-Agent"))
+
+	visitor := domain.Visitor{
+		OS:      ua.OS,
+		Browser: ua.Name,
+		Country: u.geoIPService.Country(c.ClientIP()),
+		Key:     c.ClientIP() + "|" + c.GetHeader("User-Agent"),
+	}
+	visitor.Variant, _ = c.Cookie(variantCookiePrefix + code)
+	switch {
+	case ua.Bot:
+		visitor.Device = domain.DeviceBot
+	case ua.Tablet:
+		visitor.Device = domain.DeviceTablet
+	case ua.Mobile:
+		visitor.Device = domain.DeviceMobile
+	case ua.Desktop:
+		visitor.Device = domain.DeviceDesktop
+	}
+	return visitor
+}
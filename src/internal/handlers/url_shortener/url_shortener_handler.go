@@ -1,79 +1,878 @@
 package urlshortener
 
 import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"reflect"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/dariomba/url-shortener/src/internal/config"
+	"github.com/dariomba/url-shortener/src/internal/domain"
 	"github.com/dariomba/url-shortener/src/internal/ports"
+	"github.com/dariomba/url-shortener/src/internal/problem"
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// APIPrefix holds every management route, so that the root namespace is
+	// left to short codes.
+	APIPrefix           = "/api/v1"
+	defaultBulkMaxItems = 1000
+)
+
 type URLShortenerHandler struct {
-	storageService   ports.StorageService
-	shortenerService ports.ShortenerService
+	storageService     ports.StorageService
+	shortenerService   ports.ShortenerService
+	idempotencyService ports.IdempotencyService
+	qrCodeService      ports.QRCodeService
+	lockoutService     ports.LockoutService
+	analyticsService   ports.AnalyticsService
+	geoIPService       ports.GeoIPService
+	quotaService       ports.QuotaService
+	keyService         ports.KeyService
+	auditService       ports.AuditService
+	titleService       ports.TitleService
+	healthService      ports.HealthService
+	reserved           *domain.ReservedWords
+	bulkMaxItems       int
+	// inactiveMode is how links without their own InactiveMode answer
+	// outside their activation window.
+	inactiveMode string
+	// utmTemplates holds the UTM conventions of each team, by team name.
+	utmTemplates  map[string]domain.UTMTemplate
+	previewPolicy domain.PreviewPolicy
+	previewTheme  previewTheme
+	domains       *domain.Domains
+	// notFoundPages holds the 404 page of each domain that has one, by
+	// domain name.
+	notFoundPages map[string][]byte
+	// workspaces is nil when the API is open to everyone.
+	workspaces *domain.Workspaces
 }
 
 type CreateLinkRequest struct {
 	URL string `json:"url" binding:"required"`
+	// QR asks for a PNG QR code of the short URL, returned as a data URI.
+	QR bool `json:"qr"`
+	// Password protects the link: visitors must enter it before being
+	// redirected. Only its hash is stored.
+	Password string `json:"password"`
+	// MaxClicks limits how many times the link redirects. Zero means
+	// unlimited.
+	MaxClicks int64 `json:"max_clicks"`
+	// ActiveFrom and ActiveUntil limit when the link redirects. Outside
+	// that window it answers as set by InactiveMode.
+	ActiveFrom   *time.Time `json:"active_from"`
+	ActiveUntil  *time.Time `json:"active_until"`
+	InactiveMode string     `json:"inactive_mode"`
+	FallbackURL  string     `json:"fallback_url"`
+	// Targets send visitors to other URLs depending on their User-Agent.
+	// The first matching rule wins.
+	Targets []domain.TargetRule `json:"targets"`
+	// Variants split the visitors no target matches between weighted URLs.
+	Variants []domain.Variant `json:"variants"`
+	// ForwardQuery and Wildcard pass the query string and the path after
+	// the code on to the destination.
+	ForwardQuery  bool   `json:"forward_query"`
+	QueryConflict string `json:"query_conflict"`
+	Wildcard      bool   `json:"wildcard"`
+	// UTM tags the URL with campaign parameters before its code is
+	// generated. They are checked against the template of the team of the
+	// link, if it has one: Team, or the team of the API key when empty. On
+	// a server with workspaces the link also belongs to that team.
+	UTM  *domain.UTM `json:"utm"`
+	Team string      `json:"team"`
+	// Preview forces the preview page on or off for the link instead of
+	// leaving it to the server policy.
+	Preview *bool `json:"preview"`
+	// Domain is the short domain the link is created on. Empty means the
+	// default domain.
+	Domain string `json:"domain"`
+	// Tags and Campaign group the link for listings and campaign stats.
+	Tags     []string `json:"tags"`
+	Campaign string   `json:"campaign"`
+	// Title describes the link, the title of its destination page when
+	// empty. Note is free text for the people managing the link.
+	Title string `json:"title"`
+	Note  string `json:"note"`
+}
+
+// Config is what the handler reads from the environment once, at start-up,
+// so that the server refuses to start when any of it is invalid.
+type Config struct {
+	// Domains are the short domains, the default first, and NotFoundPages
+	// the 404 pages they name, by domain name.
+	Domains       *domain.Domains
+	NotFoundPages map[string][]byte
+	// Workspaces are the workspaces and their API keys, nil when the API is
+	// open.
+	Workspaces *domain.Workspaces
 }
 
+// ConfigFromEnv loads DOMAINS and WORKSPACES.
+func ConfigFromEnv() (Config, error) {
+	domains, notFoundPages, err := domainsFromEnv()
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid DOMAINS --> %w", err)
+	}
+	workspaces, err := workspacesFromEnv()
+	if err != nil {
+		return Config{}, fmt.Errorf("invalid WORKSPACES --> %w", err)
+	}
+	return Config{Domains: domains, NotFoundPages: notFoundPages, Workspaces: workspaces}, nil
+}
+
+// NewURLShortenerHandler registers the API and the short links on router,
+// with the configuration cfg. validate, such as openapi.ValidateRequests,
+// runs on every route, after the API key is checked on the API ones, so that
+// requests without a valid key are refused before their content is looked
+// at.
 func NewURLShortenerHandler(
 	router *gin.RouterGroup,
 	storageService ports.StorageService,
 	shortenerService ports.ShortenerService,
+	idempotencyService ports.IdempotencyService,
+	qrCodeService ports.QRCodeService,
+	lockoutService ports.LockoutService,
+	analyticsService ports.AnalyticsService,
+	geoIPService ports.GeoIPService,
+	quotaService ports.QuotaService,
+	keyService ports.KeyService,
+	auditService ports.AuditService,
+	titleService ports.TitleService,
+	healthService ports.HealthService,
+	cfg Config,
+	reserved *domain.ReservedWords,
+	validate ...gin.HandlerFunc,
 ) {
 	urlShortenerHandler := URLShortenerHandler{
-		storageService:   storageService,
-		shortenerService: shortenerService,
+		storageService:     storageService,
+		shortenerService:   shortenerService,
+		idempotencyService: idempotencyService,
+		qrCodeService:      qrCodeService,
+		lockoutService:     lockoutService,
+		analyticsService:   analyticsService,
+		geoIPService:       geoIPService,
+		quotaService:       quotaService,
+		keyService:         keyService,
+		auditService:       auditService,
+		titleService:       titleService,
+		healthService:      healthService,
+		reserved:           reserved,
+		bulkMaxItems:       config.Int("BULK_MAX_ITEMS", defaultBulkMaxItems),
+		inactiveMode:       defaultInactiveMode(),
+		utmTemplates:       utmTemplatesFromEnv(),
+		previewPolicy:      previewPolicyFromEnv(),
+		previewTheme:       previewThemeFromEnv(),
+		domains:            cfg.Domains,
+		notFoundPages:      cfg.NotFoundPages,
+		workspaces:         cfg.Workspaces,
 	}
 
-	router.POST("/createLink", urlShortenerHandler.CreateLink)
-	router.GET("/:link", urlShortenerHandler.RedirectToURL)
+	api := router.Group(APIPrefix, append([]gin.HandlerFunc{urlShortenerHandler.authenticate}, validate...)...)
+	api.POST("/createLink", urlShortenerHandler.idempotent, urlShortenerHandler.CreateLink)
+	api.GET("/links", urlShortenerHandler.ListLinks)
+	api.GET("/links/broken", urlShortenerHandler.ListBrokenLinks)
+	api.POST("/links/bulk", urlShortenerHandler.CreateLinksBulk)
+	api.GET("/links/:code/qr", urlShortenerHandler.GetQRCode)
+	api.GET("/links/:code/stats", urlShortenerHandler.GetLinkStats)
+	api.PUT("/links/:code/variants", urlShortenerHandler.UpdateLinkVariants)
+	api.POST("/links/:code/disable", urlShortenerHandler.DisableLink)
+	api.POST("/links/:code/enable", urlShortenerHandler.EnableLink)
+	api.PATCH("/links/:code", urlShortenerHandler.UpdateLink)
+	api.DELETE("/links/:code", urlShortenerHandler.DeleteLink)
+	api.GET("/links/:code/versions", urlShortenerHandler.ListLinkVersions)
+	api.POST("/links/:code/rollback", urlShortenerHandler.RollbackLink)
+	api.GET("/workspaces/:id/usage", urlShortenerHandler.GetWorkspaceUsage)
+	api.GET("/workspaces/:id/keys", urlShortenerHandler.ListAPIKeys)
+	api.POST("/workspaces/:id/keys", urlShortenerHandler.CreateAPIKey)
+	api.DELETE("/workspaces/:id/keys/:name", urlShortenerHandler.RevokeAPIKey)
+	api.GET("/campaigns/:campaign/stats", urlShortenerHandler.GetCampaignStats)
+	api.GET("/audit", urlShortenerHandler.GetAuditLog)
+
+	links := router.Group("/", validate...)
+	links.GET("/", urlShortenerHandler.DomainRoot)
+	links.GET("/:link", urlShortenerHandler.RedirectToURL)
+	links.POST("/:link", urlShortenerHandler.UnlockLink)
+	links.GET("/:link/*path", urlShortenerHandler.RedirectToURL)
+	links.POST("/:link/*path", urlShortenerHandler.UnlockLink)
 }
 
 func (u *URLShortenerHandler) CreateLink(c *gin.Context) {
 	var createLinkReq CreateLinkRequest
 	if err := c.ShouldBindJSON(&createLinkReq); err != nil {
 		log.Error(fmt.Errorf("binding the JSON --> %w", err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "url parameter is required"})
+		problem.Abort(c, problem.New(problem.InvalidRequest, "url parameter is required"))
+		return
+	}
+	if !domain.IsAbsoluteURL(createLinkReq.URL) {
+		problem.Abort(c, problem.New(problem.InvalidRequest, "url is not valid"))
+		return
+	}
+
+	// The team of the link is the one asked for or else the team of the API
+	// key, and its UTM template applies.
+	actor := actorOf(c)
+	team := createLinkReq.Team
+	if team == "" {
+		team = actor.Key.Team
+	}
+	if err := u.applyUTM(&createLinkReq, team); err != nil {
+		log.Error(fmt.Errorf("tagging the url --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	link, err := linkFromRequest(createLinkReq)
+	if err != nil {
+		log.Error(fmt.Errorf("reading the link settings --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	shortDomain, err := u.domains.Get(createLinkReq.Domain)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	if actor.Workspace.ID != "" {
+		link.Workspace = actor.Workspace.ID
+		link.CreatedBy = actor.Key.Name
+		link.CreatorTrust = actor.Workspace.Trust
+		link.Team = team
+	}
+	if !u.authorize(c, domain.ActionCreate, "", &link) {
+		return
+	}
+
+	seed, err := codeSeed(link)
+	if err != nil {
+		log.Error(fmt.Errorf("seeding the short link --> %w", err))
+		problem.AbortWithError(c, err)
 		return
 	}
 
-	shortLink, err := u.shortenerService.GenerateShortLink(createLinkReq.URL)
+	shortLink, err := u.shortenerService.GenerateShortLink(seed)
 	if err != nil {
 		log.Error(fmt.Errorf("generating the link --> %w", err))
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "an error has ocurred creating the link"})
+		problem.AbortWithError(c, err)
+		return
+	}
+	link.Code = shortLink
+	link.Domain = shortDomain.Namespace
+	link.Shared = seed == link.OriginalURL
+
+	reservation, err := u.reserveQuota(c, 1)
+	if err != nil {
+		log.Error(fmt.Errorf("reserving the workspace quota --> %w", err))
+		problem.AbortWithError(c, err)
 		return
 	}
 
-	err = u.storageService.SaveURL(c, shortLink, createLinkReq.URL)
+	err = u.storageService.SaveLink(c, link)
 	if err != nil {
+		u.releaseQuota(c, reservation, 1)
 		log.Error(fmt.Errorf("saving the url --> %w", err))
-		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "an error has ocurred creating the link"})
+		problem.AbortWithError(c, err)
 		return
 	}
+	u.releaseQuota(c, reservation, 0)
+	u.auditChange(c, domain.ActionCreate, link.Key(), nil, link)
+	u.fetchTitle(link)
+
+	response := gin.H{
+		"message": "short url created successfully!",
+		"url":     shortDomain.ShortURL(shortLink),
+	}
+	if createLinkReq.QR {
+		qrCode, err := u.qrCodeService.Render(shortDomain.ShortURL(shortLink), domain.DefaultQROptions())
+		if err != nil {
+			log.Error(fmt.Errorf("rendering the QR code --> %w", err))
+			problem.AbortWithError(c, err)
+			return
+		}
+		response["qr_code"] = "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrCode)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// fetchTitle has the title of the destination of link fetched in the
+// background when it has none.
+func (u *URLShortenerHandler) fetchTitle(link domain.Link) {
+	if link.Title == "" {
+		u.titleService.Enqueue(link.Key(), link.OriginalURL)
+	}
+}
 
-	host := os.Getenv("HOST")
+// linkFromRequest validates the settings of a CreateLinkRequest and returns the
+// link they describe, still without a code.
+func linkFromRequest(req CreateLinkRequest) (domain.Link, error) {
+	link := domain.Link{OriginalURL: req.URL}
+
+	if req.MaxClicks < 0 {
+		return domain.Link{}, problem.New(problem.InvalidRequest, "max_clicks must be a positive number")
+	}
+	link.MaxClicks = req.MaxClicks
+
+	link.ActiveFrom = req.ActiveFrom
+	link.ActiveUntil = req.ActiveUntil
+	link.InactiveMode = req.InactiveMode
+	link.FallbackURL = req.FallbackURL
+	if err := link.ValidateSchedule(); err != nil {
+		return domain.Link{}, err
+	}
+
+	if err := domain.ValidateTargets(req.Targets); err != nil {
+		return domain.Link{}, err
+	}
+	link.Targets = req.Targets
+
+	if len(req.Variants) > 0 {
+		if err := domain.ValidateVariants(req.Variants); err != nil {
+			return domain.Link{}, err
+		}
+		link.Variants = req.Variants
+	}
+
+	link.ForwardQuery = req.ForwardQuery
+	link.QueryConflict = req.QueryConflict
+	link.Wildcard = req.Wildcard
+	if err := link.ValidatePassthrough(); err != nil {
+		return domain.Link{}, err
+	}
+	link.Preview = req.Preview
+
+	tags, err := domain.NormalizeTags(req.Tags)
+	if err != nil {
+		return domain.Link{}, err
+	}
+	link.Tags = tags
+	campaign, err := domain.NormalizeCampaign(req.Campaign)
+	if err != nil {
+		return domain.Link{}, err
+	}
+	link.Campaign = campaign
+
+	title, err := domain.NormalizeTitle(req.Title)
+	if err != nil {
+		return domain.Link{}, err
+	}
+	link.Title = title
+	if err := domain.ValidateNote(req.Note); err != nil {
+		return domain.Link{}, err
+	}
+	link.Note = req.Note
+
+	if req.Password != "" {
+		hash, err := domain.HashPassword(req.Password)
+		if err != nil {
+			return domain.Link{}, err
+		}
+		link.PasswordHash = hash
+	}
+
+	return link, nil
+}
+
+// codeSeed is the input the short code is generated from. Codes are derived
+// from the URL, so links to the same URL share one code, see
+// domain.Link.Shared. Links that carry any setting of their own, or an owner,
+// are seeded with a random suffix instead so that they never replace another
+// link to the same URL.
+func codeSeed(link domain.Link) (string, error) {
+	if reflect.DeepEqual(link, domain.Link{OriginalURL: link.OriginalURL}) {
+		return link.OriginalURL, nil
+	}
+	return randomSeed(link.OriginalURL)
+}
+
+func randomSeed(url string) (string, error) {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("reading a random seed --> %w", err)
+	}
+	return url + "#" + hex.EncodeToString(nonce), nil
+}
+
+// CreateLinksBulk shortens up to bulkMaxItems URLs in one request and saves
+// them through a single pipelined storage call. Items fail independently, so
+// the response reports a result per item and answers 207 when any failed.
+func (u *URLShortenerHandler) CreateLinksBulk(c *gin.Context) {
+	if !u.authorize(c, domain.ActionCreate, "", nil) {
+		return
+	}
+
+	items, err := parseBulkItems(c, u.bulkMaxItems)
+	if errors.Is(err, errTooManyItems) {
+		problem.Abort(c, problem.New(problem.BatchTooLarge,
+			fmt.Sprintf("a batch can contain at most %d items", u.bulkMaxItems)))
+		return
+	}
+	if err != nil {
+		log.Error(fmt.Errorf("parsing the bulk request --> %w", err))
+		problem.Abort(c, problem.New(problem.InvalidRequest, err.Error()))
+		return
+	}
+	if len(items) == 0 {
+		problem.Abort(c, problem.New(problem.InvalidRequest, "at least one url is required"))
+		return
+	}
+
+	results := make([]BulkLinkResult, len(items))
+	links := make([]domain.Link, 0, len(items))
+	// linkIndexes maps each entry of links back to its position in items.
+	linkIndexes := make([]int, 0, len(items))
+	aliases := make(map[string]bool)
+
+	for i, item := range items {
+		results[i] = BulkLinkResult{Index: i, URL: item.URL}
+
+		link, err := u.buildBulkLink(item, actorOf(c), aliases)
+		if err != nil {
+			results[i].setError(err)
+			continue
+		}
+		links = append(links, link)
+		linkIndexes = append(linkIndexes, i)
+	}
+
+	if len(links) > 0 {
+		// The quota is all or nothing: a batch it has no room for fails
+		// entirely rather than being cut at an arbitrary item.
+		saveErrs := make([]error, len(links))
+		if reservation, err := u.reserveQuota(c, len(links)); err != nil {
+			log.Error(fmt.Errorf("reserving the workspace quota --> %w", err))
+			for i := range saveErrs {
+				saveErrs[i] = err
+			}
+		} else {
+			saveErrs = u.storageService.SaveURLs(c, links)
+			unused := 0
+			for _, err := range saveErrs {
+				if err != nil {
+					unused++
+				}
+			}
+			u.releaseQuota(c, reservation, unused)
+		}
+		for i, err := range saveErrs {
+			result := &results[linkIndexes[i]]
+			if err != nil {
+				log.Error(fmt.Errorf("saving the url in bulk --> %w", err))
+				result.setError(err)
+				continue
+			}
+			// The namespace of a domain is its name, except for the
+			// default domain which has none, so it always finds it.
+			shortDomain, _ := u.domains.Get(links[i].Domain)
+			result.Code = links[i].Code
+			result.Short = shortDomain.ShortURL(links[i].Code)
+			u.auditChange(c, domain.ActionCreate, links[i].Key(), nil, links[i])
+			u.fetchTitle(links[i])
+		}
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+
+	status := http.StatusOK
+	if failed > 0 {
+		status = http.StatusMultiStatus
+	}
+	c.JSON(status, gin.H{
+		"created": len(results) - failed,
+		"failed":  failed,
+		"results": results,
+	})
+}
+
+// buildBulkLink validates item and returns the link it describes, owned by
+// actor when the server has workspaces.
+func (u *URLShortenerHandler) buildBulkLink(item BulkLinkItem, actor domain.Actor, aliases map[string]bool) (domain.Link, error) {
+	if item.URL == "" {
+		return domain.Link{}, problem.New(problem.InvalidRequest, "url is required")
+	}
+	if !domain.IsAbsoluteURL(item.URL) {
+		return domain.Link{}, problem.New(problem.InvalidRequest, "url is not valid")
+	}
+	if item.TTL < 0 {
+		return domain.Link{}, problem.New(problem.InvalidRequest, "ttl must be a positive number of seconds")
+	}
+
+	shortDomain, err := u.domains.Get(item.Domain)
+	if err != nil {
+		return domain.Link{}, err
+	}
+
+	link := domain.Link{
+		OriginalURL:  item.URL,
+		TTL:          time.Duration(item.TTL) * time.Second,
+		Domain:       shortDomain.Namespace,
+		Workspace:    actor.Workspace.ID,
+		CreatedBy:    actor.Key.Name,
+		CreatorTrust: actor.Workspace.Trust,
+		Team:         actor.Key.Team,
+	}
+
+	if item.Alias != "" {
+		if err := domain.ValidateAlias(item.Alias, u.reserved); err != nil {
+			return domain.Link{}, err
+		}
+		link.Code = item.Alias
+		if aliases[link.Key()] {
+			return domain.Link{}, problem.New(problem.InvalidRequest, "alias is repeated in the batch")
+		}
+		aliases[link.Key()] = true
+		link.Alias = true
+		return link, nil
+	}
+
+	seed := item.URL
+	if link.Workspace != "" {
+		if seed, err = randomSeed(item.URL); err != nil {
+			return domain.Link{}, err
+		}
+	}
+	code, err := u.shortenerService.GenerateShortLink(seed)
+	if err != nil {
+		log.Error(fmt.Errorf("generating the link in bulk --> %w", err))
+		return domain.Link{}, err
+	}
+	link.Code = code
+	link.Shared = seed == item.URL
+	return link, nil
+}
+
+// GetQRCode renders the full short URL of an existing link as a QR code. See
+// qrOptionsFromQuery for the supported query parameters.
+func (u *URLShortenerHandler) GetQRCode(c *gin.Context) {
+	code := c.Param("code")
+
+	options, err := qrOptionsFromQuery(c)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	shortDomain, err := u.queryDomain(c)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	key := managedKey(c, shortDomain, code)
+	if !u.authorize(c, domain.ActionRead, key, nil) {
+		return
+	}
+
+	if _, err := u.storageService.GetURL(c, key); err != nil {
+		log.Error(fmt.Errorf("retrieving the original url --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	qrCode, err := u.qrCodeService.Render(shortDomain.ShortURL(code), options)
+	if err != nil {
+		log.Error(fmt.Errorf("rendering the QR code --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Data(http.StatusOK, qrContentTypes[options.Format], qrCode)
+}
+
+// GetLinkStats reports the settings and current state of a link: its click
+// limit and remaining clicks, when its activation window opens and closes,
+// its click counts and the health of its destination.
+func (u *URLShortenerHandler) GetLinkStats(c *gin.Context) {
+	shortDomain, err := u.queryDomain(c)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	key := managedKey(c, shortDomain, c.Param("code"))
+	if !u.authorize(c, domain.ActionRead, key, nil) {
+		return
+	}
+
+	link, err := u.storageService.GetLink(c, key)
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the link --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	stats := domain.LinkStats{
+		Code:      link.Code,
+		URL:       link.OriginalURL,
+		Protected: link.Protected(),
+		Disabled:  link.Disabled,
+		MaxClicks: link.MaxClicks,
+		Tags:      link.Tags,
+		Campaign:  link.Campaign,
+		Title:     link.Title,
+		Note:      link.Note,
+		Variants:  link.Variants,
+		Window: domain.LinkWindow{
+			State:        link.State(time.Now()),
+			OpensAt:      link.ActiveFrom,
+			ClosesAt:     link.ActiveUntil,
+			InactiveMode: u.inactiveModeOf(link),
+		},
+	}
+	if link.MaxClicks > 0 {
+		remaining, err := u.storageService.RemainingClicks(c, link.Key())
+		if err != nil {
+			log.Error(fmt.Errorf("retrieving the remaining clicks --> %w", err))
+			problem.AbortWithError(c, err)
+			return
+		}
+		stats.RemainingClicks = &remaining
+	}
+
+	clicks, err := u.analyticsService.Clicks(c, link.Key())
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the clicks --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	stats.Clicks = *clicks
+
+	if stats.Health, err = u.healthService.Health(c, link.Key()); err != nil {
+		log.Error(fmt.Errorf("retrieving the health --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// DeleteLink removes a link, which stops redirecting at once. Editors can
+// only delete their own links and those of their team.
+func (u *URLShortenerHandler) DeleteLink(c *gin.Context) {
+	shortDomain, err := u.queryDomain(c)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	link, err := u.storageService.GetLink(c, managedKey(c, shortDomain, c.Param("code")))
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the link --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	if !u.authorize(c, domain.ActionDelete, link.Key(), link) {
+		return
+	}
+
+	if err := u.storageService.DeleteLink(c, *link); err != nil {
+		log.Error(fmt.Errorf("deleting the link --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	u.auditChange(c, domain.ActionDelete, link.Key(), link, nil)
+	c.Status(http.StatusNoContent)
+}
+
+// DisableLink stops a link redirecting, without deleting it, until
+// EnableLink. Like UpdateLink, it fails with 409 rather than overwrite a
+// change made in the meantime.
+func (u *URLShortenerHandler) DisableLink(c *gin.Context) {
+	u.setDisabled(c, true)
+}
+
+// EnableLink lets a link disabled by DisableLink redirect again.
+func (u *URLShortenerHandler) EnableLink(c *gin.Context) {
+	u.setDisabled(c, false)
+}
+
+func (u *URLShortenerHandler) setDisabled(c *gin.Context, disabled bool) {
+	shortDomain, err := u.queryDomain(c)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	link, err := u.storageService.GetLink(c, managedKey(c, shortDomain, c.Param("code")))
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the link --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	action := domain.ActionEnable
+	if disabled {
+		action = domain.ActionDisable
+	}
+	if !u.authorize(c, action, link.Key(), link) {
+		return
+	}
+	if link.Shared {
+		problem.AbortWithError(c, domain.ErrLinkShared)
+		return
+	}
+
+	before := *link
+	link.Disabled = disabled
+	if err := u.storageService.UpdateLink(c, *link, before); err != nil {
+		log.Error(fmt.Errorf("updating the link --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	u.auditChange(c, action, link.Key(), before, link)
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "short url created successfully!",
-		"url":     host + shortLink,
+		"code":     link.Code,
+		"disabled": link.Disabled,
 	})
 }
 
+// RedirectToURL sends the visitor to the original URL. Protected links answer
+// with a password form instead, and links the preview policy applies to with
+// a page showing the destination; both forms are submitted to UnlockLink.
+// Wildcard links are also reached below their code, see findLink. Codes are
+// looked up on the domain the request was sent to. Links that are disabled,
+// expired, out of clicks or broken send the visitor to their fallback URL,
+// see fallBack, when there is one.
 func (u *URLShortenerHandler) RedirectToURL(c *gin.Context) {
-	link := c.Param("link")
+	link, ok := u.visitableLink(c)
+	if !ok {
+		return
+	}
+
+	if link.Protected() {
+		renderPasswordPage(c, http.StatusOK, "")
+		return
+	}
+
+	v, ok := u.resolveVisit(c, link)
+	if !ok {
+		return
+	}
+	if u.previewPolicy.Show(*link, v.destination) {
+		renderPreviewPage(c, u.previewTheme, v.destination)
+		return
+	}
+	u.redirect(c, link, v, http.StatusFound)
+}
 
-	originalURL, err := u.storageService.GetURL(c, link)
+// UnlockLink checks the password submitted from the form of a protected link
+// and redirects on success. Failed attempts are counted per link and per
+// client IP, and either counter reaching the limit locks further attempts.
+func (u *URLShortenerHandler) UnlockLink(c *gin.Context) {
+	link, ok := u.visitableLink(c)
+	if !ok {
+		return
+	}
+	if !link.Protected() {
+		if v, ok := u.resolveVisit(c, link); ok {
+			u.redirect(c, link, v, http.StatusSeeOther)
+		}
+		return
+	}
+
+	// One counter per link stops a link being brute forced from many
+	// addresses, one per client IP stops one password being tried against
+	// many links. The attempt is counted before the password is checked, so
+	// that parallel guesses cannot go over the limit.
+	linkKey, clientKey := "link:"+link.Key(), "ip:"+c.ClientIP()
+	err := u.lockoutService.Reserve(c, linkKey, clientKey)
+	if errors.Is(err, domain.ErrTooManyAttempts) {
+		renderPasswordPage(c, http.StatusTooManyRequests, "Too many failed attempts. Try again later.")
+		return
+	}
 	if err != nil {
-		log.Error(fmt.Errorf("retrieving the original url --> %w", err))
-		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"error": "URL not found"})
+		log.Error(fmt.Errorf("checking the password lockout --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
 
+	if !link.CheckPassword(c.PostForm("password")) {
+		renderPasswordPage(c, http.StatusUnauthorized, "Incorrect password.")
 		return
 	}
 
-	c.Redirect(http.StatusFound, originalURL)
+	// Only the client's own counter is cleared: the link counter only gives
+	// back this attempt, so that a correct guess elsewhere does not reset an
+	// attack.
+	if err := u.lockoutService.Release(c, linkKey); err != nil {
+		log.Error(fmt.Errorf("releasing the password attempt --> %w", err))
+	}
+	if err := u.lockoutService.Reset(c, clientKey); err != nil {
+		log.Error(fmt.Errorf("resetting the password lockout --> %w", err))
+	}
+	if v, ok := u.resolveVisit(c, link); ok {
+		u.redirect(c, link, v, http.StatusSeeOther)
+	}
+}
+
+// visit is where one request for a link is sent, and why.
+type visit struct {
+	visitor     domain.Visitor
+	destination string
+	target      string
+	variant     string
+}
+
+// resolveVisit picks the destination of the request and reports whether it
+// could. Otherwise it has already responded.
+func (u *URLShortenerHandler) resolveVisit(c *gin.Context, link *domain.Link) (visit, bool) {
+	v := visit{visitor: u.visitorFromRequest(c, link.Code)}
+	v.destination, v.target, v.variant = link.Destination(v.visitor)
+
+	destination, err := link.Forward(v.destination, c.Param("path"), c.Request.URL.Query())
+	if err != nil {
+		log.Error(fmt.Errorf("forwarding the request --> %w", err))
+		problem.AbortWithError(c, err)
+		return visit{}, false
+	}
+	v.destination = destination
+	return v, true
+}
+
+// redirect sends the visitor to the destination of v, counting the click
+// against the limit of link when it has one and in analytics. Links out of
+// clicks fall back, see fallBack.
+func (u *URLShortenerHandler) redirect(c *gin.Context, link *domain.Link, v visit, status int) {
+	if link.MaxClicks > 0 {
+		_, err := u.storageService.ConsumeClick(c, link.Key())
+		if errors.Is(err, domain.ErrLinkExhausted) && u.fallBack(c, link, domain.PathExhausted) {
+			return
+		}
+		if err != nil {
+			log.Error(fmt.Errorf("consuming a click --> %w", err))
+			problem.AbortWithError(c, err)
+			return
+		}
+		// Every response of a limited link is unique to its visitor.
+		c.Header("Cache-Control", "no-store")
+	}
+	if len(link.Targets) > 0 || len(link.Variants) > 0 {
+		c.Header("Vary", "User-Agent, Cookie")
+		// Country rules and variants depend on the client IP, which no
+		// header describes, so shared caches must not store the redirect.
+		if c.Writer.Header().Get("Cache-Control") == "" {
+			c.Header("Cache-Control", "private")
+		}
+	}
+	if v.variant != "" && v.variant != v.visitor.Variant {
+		rememberVariant(c, link.Code, v.variant)
+	}
+
+	// A click that cannot be counted must not cost the visitor the redirect.
+	if err := u.analyticsService.RecordClick(c, link.Key(), domain.Click{Target: v.target, Country: v.visitor.Country, Variant: v.variant}); err != nil {
+		log.Error(fmt.Errorf("recording the click --> %w", err))
+	}
+
+	c.Redirect(status, v.destination)
 }
@@ -2,68 +2,225 @@ package urlshortener_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/dariomba/url-shortener/src/internal/domain"
 	urlshortener "github.com/dariomba/url-shortener/src/internal/handlers/url_shortener"
 	"github.com/dariomba/url-shortener/src/internal/mocks"
+	"github.com/dariomba/url-shortener/src/internal/problem"
 	"github.com/gin-gonic/gin"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
 
+// problemBody is the decoded problem+json document expected for a failure.
+func problemBody(problemType problem.Type, detail string, instance string) map[string]interface{} {
+	body := map[string]interface{}{
+		"type":     problemType.URI(),
+		"title":    problemType.Title,
+		"status":   float64(problemType.Status),
+		"instance": instance,
+	}
+	if detail != "" {
+		body["detail"] = detail
+	}
+	return body
+}
+
+func problemJSON(problemType problem.Type, detail string, instance string) string {
+	body, _ := json.Marshal(problemBody(problemType, detail, instance))
+	return string(body)
+}
+
+func reserved() *domain.ReservedWords {
+	return domain.NewReservedWords(domain.DefaultReservedWords...)
+}
+
+// handlerConfig loads the configuration of the handler from the environment
+// of the test.
+func handlerConfig(t *testing.T) urlshortener.Config {
+	cfg, err := urlshortener.ConfigFromEnv()
+	assert.NoError(t, err)
+	return cfg
+}
+
 type mocksShortenerHandler struct {
-	storageService   *mocks.MockStorageService
-	shortenerService *mocks.MockShortenerService
+	storageService     *mocks.MockStorageService
+	shortenerService   *mocks.MockShortenerService
+	idempotencyService *mocks.MockIdempotencyService
+	qrCodeService      *mocks.MockQRCodeService
+	lockoutService     *mocks.MockLockoutService
+	analyticsService   *mocks.MockAnalyticsService
+	geoIPService       *mocks.MockGeoIPService
+	quotaService       *mocks.MockQuotaService
+	keyService         *mocks.MockKeyService
+	auditService       *mocks.MockAuditService
+	titleService       *mocks.MockTitleService
+	healthService      *mocks.MockHealthService
 }
 
 func TestCreateLink(t *testing.T) {
 	type want struct {
 		statusCode int
-		body       map[string]string
+		body       map[string]interface{}
 	}
 
 	tests := []struct {
 		name        string
 		want        want
-		requestBody map[string]string
+		requestBody map[string]interface{}
 		mocks       func(m mocksShortenerHandler)
 	}{
 		{
 			name:        "WhenCreatesALinkWithEmptyURL_ThenReturnsBadRequest",
-			requestBody: map[string]string{},
-			want:        want{statusCode: http.StatusBadRequest, body: map[string]string{"error": "url parameter is required"}},
-			mocks:       func(m mocksShortenerHandler) {},
+			requestBody: map[string]interface{}{},
+			want: want{statusCode: http.StatusBadRequest,
+				body: problemBody(problem.InvalidRequest, "url parameter is required", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenCreatesALinkWithARelativeURL_ThenReturnsBadRequest",
+			requestBody: map[string]interface{}{"url": "/landing"},
+			want: want{statusCode: http.StatusBadRequest,
+				body: problemBody(problem.InvalidRequest, "url is not valid", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
 		},
 		{
 			name:        "WhenGenerateShortLinkFails_ThenReturnsInternalServerError",
-			requestBody: map[string]string{"url": "http://example.com"},
-			want:        want{statusCode: http.StatusInternalServerError, body: map[string]string{"error": "an error has ocurred creating the link"}},
+			requestBody: map[string]interface{}{"url": "http://example.com"},
+			want: want{statusCode: http.StatusInternalServerError,
+				body: problemBody(problem.CodeGenerationFailed, "", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink("http://example.com").
+					Return("", domain.Detailed(domain.ErrCodeGeneration, "new error"))
+			},
+		},
+		{
+			name:        "WhenAnUnexpectedErrorHappens_ThenReturnsAnInternalErrorWithoutDetails",
+			requestBody: map[string]interface{}{"url": "http://example.com"},
+			want: want{statusCode: http.StatusInternalServerError,
+				body: problemBody(problem.InternalError, "", "/api/v1/createLink")},
 			mocks: func(m mocksShortenerHandler) {
 				m.shortenerService.EXPECT().GenerateShortLink("http://example.com").Return("", errors.New("new error"))
 			},
 		},
 		{
-			name:        "WhenSaveURLFails_ThenReturnsInternalServerError",
-			requestBody: map[string]string{"url": "http://example.com"},
-			want:        want{statusCode: http.StatusInternalServerError, body: map[string]string{"error": "an error has ocurred creating the link"}},
+			name:        "WhenSaveURLFails_ThenReturnsServiceUnavailable",
+			requestBody: map[string]interface{}{"url": "http://example.com"},
+			want: want{statusCode: http.StatusServiceUnavailable,
+				body: problemBody(problem.StorageUnavailable, "", "/api/v1/createLink")},
 			mocks: func(m mocksShortenerHandler) {
 				m.shortenerService.EXPECT().GenerateShortLink("http://example.com").Return("shortLink", nil)
-				m.storageService.EXPECT().SaveURL(gomock.Any(), "shortLink", "http://example.com").Return(errors.New("new error"))
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "shortLink", OriginalURL: "http://example.com", Shared: true}).
+					Return(domain.Detailed(domain.ErrStorageUnavailable, "new error"))
+			},
+		},
+		{
+			name:        "WhenPasswordIsTooShort_ThenReturnsBadRequest",
+			requestBody: map[string]interface{}{"url": "http://example.com", "password": "abc"},
+			want: want{statusCode: http.StatusBadRequest,
+				body: problemBody(problem.InvalidRequest, domain.ErrInvalidPassword.Error(), "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenPasswordIsSet_ThenSavesItsHash",
+			requestBody: map[string]interface{}{"url": "http://example.com", "password": "open sesame"},
+			want: want{statusCode: http.StatusOK, body: map[string]interface{}{"message": "short url created successfully!",
+				"url": "http://localhost/shortLink"}},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink(gomock.Not("http://example.com")).Return("shortLink", nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, link domain.Link) error {
+						assert.True(t, link.Protected())
+						assert.True(t, link.CheckPassword("open sesame"))
+						return nil
+					})
+			},
+		},
+		{
+			name:        "WhenMaxClicksIsNegative_ThenReturnsBadRequest",
+			requestBody: map[string]interface{}{"url": "http://example.com", "max_clicks": -1},
+			want: want{statusCode: http.StatusBadRequest,
+				body: problemBody(problem.InvalidRequest, "max_clicks must be a positive number", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name: "WhenWindowIsReversed_ThenReturnsBadRequest",
+			requestBody: map[string]interface{}{"url": "http://example.com",
+				"active_from": "2030-06-30T18:00:00Z", "active_until": "2030-06-01T09:00:00Z"},
+			want: want{statusCode: http.StatusBadRequest, body: problemBody(problem.InvalidRequest,
+				"invalid activation window: active_until must be after active_from", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name: "WhenATargetingRuleMatchesNothing_ThenReturnsBadRequest",
+			requestBody: map[string]interface{}{"url": "http://example.com",
+				"targets": []map[string]string{{"url": "http://example.com/app"}}},
+			want: want{statusCode: http.StatusBadRequest, body: problemBody(problem.InvalidRequest,
+				"invalid targeting rules: rule 1 must match on os, device, browser or country", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenMaxClicksIsSet_ThenSavesTheLimitUnderAUniqueCode",
+			requestBody: map[string]interface{}{"url": "http://example.com", "max_clicks": 1},
+			want: want{statusCode: http.StatusOK, body: map[string]interface{}{"message": "short url created successfully!",
+				"url": "http://localhost/onceLink"}},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink(gomock.Not("http://example.com")).Return("onceLink", nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(),
+					domain.Link{Code: "onceLink", OriginalURL: "http://example.com", MaxClicks: 1}).Return(nil)
+			},
+		},
+		{
+			name: "WhenTagsAndCampaignAreSet_ThenSavesThemNormalized",
+			requestBody: map[string]interface{}{"url": "http://example.com",
+				"tags": []string{"Summer", "email", "summer"}, "campaign": "Launch"},
+			want: want{statusCode: http.StatusOK, body: map[string]interface{}{"message": "short url created successfully!",
+				"url": "http://localhost/taggedLink"}},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink(gomock.Not("http://example.com")).Return("taggedLink", nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "taggedLink", OriginalURL: "http://example.com",
+					Tags: []string{"summer", "email"}, Campaign: "launch"}).Return(nil)
 			},
 		},
+		{
+			name: "WhenTitleAndNoteAreSet_ThenSavesThem",
+			requestBody: map[string]interface{}{"url": "http://example.com",
+				"title": "  Spring\n sale ", "note": "Printed on the spring flyer"},
+			want: want{statusCode: http.StatusOK, body: map[string]interface{}{"message": "short url created successfully!",
+				"url": "http://localhost/titledLink"}},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink(gomock.Not("http://example.com")).Return("titledLink", nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "titledLink", OriginalURL: "http://example.com",
+					Title: "Spring sale", Note: "Printed on the spring flyer"}).Return(nil)
+			},
+		},
+		{
+			name:        "WhenTitleIsTooLong_ThenReturnsBadRequest",
+			requestBody: map[string]interface{}{"url": "http://example.com", "title": strings.Repeat("a", domain.MaxTitleLength+1)},
+			want: want{statusCode: http.StatusBadRequest, body: problemBody(problem.InvalidRequest,
+				"invalid title or note: title must be at most 200 characters", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
 		{
 			name:        "WhenEverythingOK_ThenReturnsFullShortURL",
-			requestBody: map[string]string{"url": "http://example.com"},
-			want: want{statusCode: http.StatusOK, body: map[string]string{"message": "short url created successfully!",
+			requestBody: map[string]interface{}{"url": "http://example.com"},
+			want: want{statusCode: http.StatusOK, body: map[string]interface{}{"message": "short url created successfully!",
 				"url": "http://localhost/shortLink"}},
 			mocks: func(m mocksShortenerHandler) {
 				m.shortenerService.EXPECT().GenerateShortLink("http://example.com").Return("shortLink", nil)
-				m.storageService.EXPECT().SaveURL(gomock.Any(), "shortLink", "http://example.com").Return(nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "shortLink", OriginalURL: "http://example.com", Shared: true}).Return(nil)
+				m.titleService.EXPECT().Enqueue("shortLink", "http://example.com")
 			},
 		},
 	}
@@ -74,27 +231,39 @@ func TestCreateLink(t *testing.T) {
 			defer ctrl.Finish()
 
 			m := mocksShortenerHandler{
-				storageService:   mocks.NewMockStorageService(ctrl),
-				shortenerService: mocks.NewMockShortenerService(ctrl),
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
 			}
 
 			tt.mocks(m)
+			m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
 
 			gin.SetMode(gin.TestMode)
 			router := gin.Default()
 			group := router.Group("/")
 
 			os.Setenv("HOST", "http://localhost/")
-			urlshortener.NewURLShortenerHandler(group, m.storageService, m.shortenerService)
+			urlshortener.NewURLShortenerHandler(group, m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
 
 			w := httptest.NewRecorder()
 			body, _ := json.Marshal(tt.requestBody)
-			req, _ := http.NewRequest("POST", "/createLink", bytes.NewBuffer(body))
+			req, _ := http.NewRequest("POST", "/api/v1/createLink", bytes.NewBuffer(body))
 			req.Header.Set("Content-Type", "application/json")
 
 			router.ServeHTTP(w, req)
 
-			var response map[string]string
+			var response map[string]interface{}
 			json.Unmarshal(w.Body.Bytes(), &response)
 
 			assert.Equal(t, tt.want.statusCode, w.Code)
@@ -104,24 +273,108 @@ func TestCreateLink(t *testing.T) {
 }
 
 func TestRedirectToURL(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	appLink := &domain.Link{Code: "appLink", OriginalURL: "https://example.com", Targets: []domain.TargetRule{
+		{Name: "ios", OS: "iOS", URL: "https://apps.apple.com/app/id1"},
+		{Name: "android", OS: "Android", URL: "https://play.google.com/store/apps/details?id=app"},
+		{Name: "spain", Country: "ES", URL: "https://example.es"},
+	}}
+	wildLink := &domain.Link{Code: "wild", OriginalURL: "https://example.com/docs?ref=short", ForwardQuery: true, Wildcard: true}
+
 	type want struct {
 		statusCode int
-		body       map[string]string
+		body       map[string]interface{}
 		URL        string
+		page       string
 	}
 
 	tests := []struct {
-		name  string
-		want  want
-		link  string
-		mocks func(m mocksShortenerHandler)
+		name      string
+		want      want
+		link      string
+		userAgent string
+		mocks     func(m mocksShortenerHandler)
 	}{
 		{
 			name: "WhenGetURLFails_ThenReturnsNotFound",
 			link: "noExists",
-			want: want{statusCode: http.StatusNotFound, body: map[string]string{"error": "URL not found"}},
+			want: want{statusCode: http.StatusNotFound,
+				body: problemBody(problem.LinkNotFound, "link not found", "/noExists")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "noExists").Return(nil, domain.ErrLinkNotFound)
+				m.storageService.EXPECT().ExpiredLink(gomock.Any(), "noExists").Return(nil, domain.ErrLinkNotFound)
+			},
+		},
+		{
+			name: "WhenLinkExpiredWithAFallback_ThenRedirectsToTheFallback",
+			link: "gone",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com/fallback"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "gone").Return(nil, domain.ErrLinkNotFound)
+				m.storageService.EXPECT().ExpiredLink(gomock.Any(), "gone").
+					Return(&domain.Link{Code: "gone", FallbackURL: "http://example.com/fallback"}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "gone", domain.Click{Path: domain.PathExpired}).Return(nil)
+			},
+		},
+		{
+			name: "WhenLinkExpiredWithoutAFallback_ThenReturnsNotFound",
+			link: "gone",
+			want: want{statusCode: http.StatusNotFound,
+				body: problemBody(problem.LinkNotFound, "link not found", "/gone")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "gone").Return(nil, domain.ErrLinkNotFound)
+				m.storageService.EXPECT().ExpiredLink(gomock.Any(), "gone").Return(nil, domain.ErrLinkNotFound)
+			},
+		},
+		{
+			name: "WhenLinkIsDisabled_ThenReturnsNotFound",
+			link: "someLink",
+			want: want{statusCode: http.StatusNotFound,
+				body: problemBody(problem.LinkDisabled, "link is disabled", "/someLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(&domain.Link{Code: "someLink",
+					OriginalURL: "http://example.com", Disabled: true}, nil)
+			},
+		},
+		{
+			name: "WhenStorageIsDown_ThenReturnsServiceUnavailable",
+			link: "someLink",
+			want: want{statusCode: http.StatusServiceUnavailable,
+				body: problemBody(problem.StorageUnavailable, "", "/someLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").
+					Return(nil, domain.Detailed(domain.ErrStorageUnavailable, "connection refused"))
+			},
+		},
+		{
+			name: "WhenLinkPassesThroughPathAndQuery_ThenMergesThemIntoTheDestination",
+			link: "wild/getting-started/install?utm_source=mail&ref=mail",
+			want: want{statusCode: http.StatusFound, URL: "https://example.com/docs/getting-started/install?ref=short&utm_source=mail"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "wild").Return(wildLink, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "wild", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+		{
+			name: "WhenWildcardPathClimbsOut_ThenReturnsBadRequest",
+			link: "wild/%2E%2E/admin",
+			want: want{statusCode: http.StatusBadRequest,
+				body: problemBody(problem.InvalidRequest, domain.ErrUnsafePath.Error(), "/wild/../admin")},
 			mocks: func(m mocksShortenerHandler) {
-				m.storageService.EXPECT().GetURL(gomock.Any(), "noExists").Return("", errors.New("not found"))
+				m.storageService.EXPECT().GetLink(gomock.Any(), "wild").Return(wildLink, nil)
+			},
+		},
+		{
+			name: "WhenLinkIsNotAWildcard_ThenPathsBelowItAreNotFound",
+			link: "someLink/docs",
+			want: want{statusCode: http.StatusNotFound,
+				body: problemBody(problem.LinkNotFound, "link not found", "/someLink/docs")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").
+					Return(&domain.Link{Code: "someLink", OriginalURL: "http://example.com"}, nil)
+				m.storageService.EXPECT().ExpiredLink(gomock.Any(), "someLink").
+					Return(&domain.Link{Code: "someLink", FallbackURL: "http://example.com/fallback"}, nil)
 			},
 		},
 		{
@@ -129,7 +382,179 @@ func TestRedirectToURL(t *testing.T) {
 			link: "someLink",
 			want: want{statusCode: http.StatusFound, URL: "http://example.com"},
 			mocks: func(m mocksShortenerHandler) {
-				m.storageService.EXPECT().GetURL(gomock.Any(), "someLink").Return("http://example.com", nil)
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").
+					Return(&domain.Link{Code: "someLink", OriginalURL: "http://example.com"}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+		{
+			name: "WhenLinkHasClicksLeft_ThenConsumesOneAndRedirects",
+			link: "onceLink",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "onceLink").
+					Return(&domain.Link{Code: "onceLink", OriginalURL: "http://example.com", MaxClicks: 1}, nil)
+				m.storageService.EXPECT().ConsumeClick(gomock.Any(), "onceLink").Return(int64(0), nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "onceLink", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+		{
+			name: "WhenLinkIsExhausted_ThenReturnsGone",
+			link: "onceLink",
+			want: want{statusCode: http.StatusGone,
+				body: problemBody(problem.LinkExhausted, "link has reached its maximum number of clicks", "/onceLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "onceLink").
+					Return(&domain.Link{Code: "onceLink", OriginalURL: "http://example.com", MaxClicks: 1}, nil)
+				m.storageService.EXPECT().ConsumeClick(gomock.Any(), "onceLink").Return(int64(0), domain.ErrLinkExhausted)
+			},
+		},
+		{
+			name:      "WhenVisitorMatchesATargetingRule_ThenRedirectsToItsURL",
+			link:      "appLink",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1",
+			want:      want{statusCode: http.StatusFound, URL: "https://apps.apple.com/app/id1"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "appLink").Return(appLink, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "appLink", domain.Click{Target: "ios"}).Return(nil)
+			},
+		},
+		{
+			name:      "WhenVisitorCountryMatchesATargetingRule_ThenRedirectsToItsURL",
+			link:      "appLink",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			want:      want{statusCode: http.StatusFound, URL: "https://example.es"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "appLink").Return(appLink, nil)
+				m.geoIPService.EXPECT().Country(gomock.Any()).Return("ES")
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "appLink", domain.Click{Target: "spain", Country: "ES"}).Return(nil)
+			},
+		},
+		{
+			name:      "WhenVisitorMatchesNoTargetingRule_ThenRedirectsToTheDefault",
+			link:      "appLink",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36",
+			want:      want{statusCode: http.StatusFound, URL: "https://example.com"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "appLink").Return(appLink, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "appLink", domain.Click{Target: domain.DefaultTarget}).
+					Return(errors.New("analytics are down"))
+			},
+		},
+		{
+			name: "WhenWindowHasNotOpened_ThenReturnsLinkInactive",
+			link: "launch",
+			want: want{statusCode: http.StatusNotFound,
+				body: problemBody(problem.LinkInactive, "link is not active", "/launch")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "launch").
+					Return(&domain.Link{Code: "launch", OriginalURL: "http://example.com", ActiveFrom: &future}, nil)
+			},
+		},
+		{
+			name: "WhenWindowHasNotOpenedAndModeIsComingSoon_ThenShowsWhenItOpens",
+			link: "launch",
+			want: want{statusCode: http.StatusNotFound, page: "This link opens on"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "launch").
+					Return(&domain.Link{Code: "launch", OriginalURL: "http://example.com", ActiveFrom: &future,
+						InactiveMode: domain.InactiveComingSoon}, nil)
+			},
+		},
+		{
+			name: "WhenWindowHasClosedAndModeIsFallback_ThenRedirectsToTheFallback",
+			link: "launch",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com/over"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "launch").
+					Return(&domain.Link{Code: "launch", OriginalURL: "http://example.com", ActiveUntil: &past,
+						InactiveMode: domain.InactiveFallback, FallbackURL: "http://example.com/over"}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "launch", domain.Click{Path: domain.PathExpired}).Return(nil)
+			},
+		},
+		{
+			name: "WhenWindowIsOpen_ThenRedirectsToURL",
+			link: "launch",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "launch").
+					Return(&domain.Link{Code: "launch", OriginalURL: "http://example.com", ActiveFrom: &past, ActiveUntil: &future}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "launch", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+		{
+			name: "WhenLinkIsProtected_ThenAsksForThePassword",
+			link: "someLink",
+			want: want{statusCode: http.StatusOK, page: `<form method="post" action="/someLink">`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").
+					Return(&domain.Link{Code: "someLink", OriginalURL: "http://example.com", PasswordHash: "$2a$10$hash"}, nil)
+			},
+		},
+		{
+			name: "WhenLinkIsDisabledAndHasAFallback_ThenRedirectsToTheFallback",
+			link: "someLink",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com/fallback"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(&domain.Link{Code: "someLink",
+					OriginalURL: "http://example.com", FallbackURL: "http://example.com/fallback", Disabled: true}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Path: domain.PathDisabled}).Return(nil)
+			},
+		},
+		{
+			name: "WhenWindowHasClosedAndLinkHasAFallback_ThenRedirectsToTheFallback",
+			link: "launch",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com/fallback"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "launch").Return(&domain.Link{Code: "launch",
+					OriginalURL: "http://example.com", ActiveUntil: &past, FallbackURL: "http://example.com/fallback"}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "launch", domain.Click{Path: domain.PathExpired}).Return(nil)
+			},
+		},
+		{
+			name: "WhenLinkIsExhaustedAndHasAFallback_ThenRedirectsToTheFallback",
+			link: "onceLink",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com/fallback"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "onceLink").Return(&domain.Link{Code: "onceLink",
+					OriginalURL: "http://example.com", MaxClicks: 1, FallbackURL: "http://example.com/fallback"}, nil)
+				m.healthService.EXPECT().Health(gomock.Any(), "onceLink").Return(nil, nil)
+				m.storageService.EXPECT().ConsumeClick(gomock.Any(), "onceLink").Return(int64(0), domain.ErrLinkExhausted)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "onceLink", domain.Click{Path: domain.PathExhausted}).Return(nil)
+			},
+		},
+		{
+			name: "WhenLinkIsBrokenAndHasAFallback_ThenRedirectsToTheFallback",
+			link: "someLink",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com/fallback"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(&domain.Link{Code: "someLink",
+					OriginalURL: "http://example.com", FallbackURL: "http://example.com/fallback"}, nil)
+				m.healthService.EXPECT().Health(gomock.Any(), "someLink").
+					Return(&domain.LinkHealth{Status: 404, Failures: 3, BrokenSince: &past}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Path: domain.PathBroken}).Return(nil)
+			},
+		},
+		{
+			name: "WhenLinkIsFailingButNotYetBroken_ThenRedirectsToURL",
+			link: "someLink",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(&domain.Link{Code: "someLink",
+					OriginalURL: "http://example.com", FallbackURL: "http://example.com/fallback"}, nil)
+				m.healthService.EXPECT().Health(gomock.Any(), "someLink").Return(&domain.LinkHealth{Status: 503, Failures: 1}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+		{
+			name: "WhenHealthIsUnavailable_ThenRedirectsToURL",
+			link: "someLink",
+			want: want{statusCode: http.StatusFound, URL: "http://example.com"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(&domain.Link{Code: "someLink",
+					OriginalURL: "http://example.com", FallbackURL: "http://example.com/fallback"}, nil)
+				m.healthService.EXPECT().Health(gomock.Any(), "someLink").Return(nil, domain.ErrStorageUnavailable)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Target: domain.DefaultTarget}).Return(nil)
 			},
 		},
 	}
@@ -140,35 +565,774 @@ func TestRedirectToURL(t *testing.T) {
 			defer ctrl.Finish()
 
 			m := mocksShortenerHandler{
-				storageService:   mocks.NewMockStorageService(ctrl),
-				shortenerService: mocks.NewMockShortenerService(ctrl),
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
 			}
 
 			tt.mocks(m)
+			m.geoIPService.EXPECT().Country(gomock.Any()).Return("").AnyTimes()
 
 			gin.SetMode(gin.TestMode)
 			router := gin.Default()
 			group := router.Group("/")
 
-			urlshortener.NewURLShortenerHandler(group, m.storageService, m.shortenerService)
+			urlshortener.NewURLShortenerHandler(group, m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
 
 			w := httptest.NewRecorder()
 
 			req, _ := http.NewRequest("GET", "/"+tt.link, nil)
+			req.Header.Set("User-Agent", tt.userAgent)
 
 			router.ServeHTTP(w, req)
 
-			var response map[string]string
-			json.Unmarshal(w.Body.Bytes(), &response)
-
 			assert.Equal(t, tt.want.statusCode, w.Code)
 			if tt.want.body != nil {
-				var response map[string]string
+				var response map[string]interface{}
 				json.Unmarshal(w.Body.Bytes(), &response)
+				assert.Equal(t, tt.want.body, response)
+				assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
 			}
 			if tt.want.URL != "" {
 				assert.Equal(t, tt.want.URL, w.Header().Get("Location"))
 			}
+			if tt.want.page != "" {
+				assert.Contains(t, w.Body.String(), tt.want.page)
+				assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+			}
+		})
+	}
+}
+
+func TestGetLinkStats(t *testing.T) {
+	from := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	until := time.Date(2030, 6, 30, 18, 0, 0, 0, time.UTC)
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name  string
+		want  want
+		mocks func(m mocksShortenerHandler)
+	}{
+		{
+			name: "WhenLinkDoesNotExist_ThenReturnsNotFound",
+			want: want{statusCode: http.StatusNotFound,
+				body: problemJSON(problem.LinkNotFound, "link not found", "/api/v1/links/someLink/stats")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(nil, domain.ErrLinkNotFound)
+			},
+		},
+		{
+			name: "WhenLinkIsScheduled_ThenReportsWhenTheWindowOpensAndCloses",
+			want: want{statusCode: http.StatusOK, body: `{"code":"someLink","url":"http://example.com","protected":false,` +
+				`"window":{"state":"pending","opens_at":"2030-06-01T09:00:00Z","closes_at":"2030-06-30T18:00:00Z","inactive_mode":"not_found"},` +
+				`"clicks":{"total":0}}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").
+					Return(&domain.Link{Code: "someLink", OriginalURL: "http://example.com", ActiveFrom: &from, ActiveUntil: &until}, nil)
+				m.analyticsService.EXPECT().Clicks(gomock.Any(), "someLink").Return(&domain.ClickStats{}, nil)
+				m.healthService.EXPECT().Health(gomock.Any(), "someLink").Return(nil, nil)
+			},
+		},
+		{
+			name: "WhenLinkHasAClickLimit_ThenReportsTheRemainingClicks",
+			want: want{statusCode: http.StatusOK, body: `{"code":"someLink","url":"http://example.com","protected":true,` +
+				`"max_clicks":5,"remaining_clicks":2,"window":{"state":"active","inactive_mode":"not_found"},` +
+				`"clicks":{"total":3,"targets":{"default":3}}}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").
+					Return(&domain.Link{Code: "someLink", OriginalURL: "http://example.com", PasswordHash: "$2a$10$hash", MaxClicks: 5}, nil)
+				m.storageService.EXPECT().RemainingClicks(gomock.Any(), "someLink").Return(int64(2), nil)
+				m.analyticsService.EXPECT().Clicks(gomock.Any(), "someLink").
+					Return(&domain.ClickStats{Total: 3, Targets: map[string]int64{domain.DefaultTarget: 3}}, nil)
+				m.healthService.EXPECT().Health(gomock.Any(), "someLink").Return(nil, nil)
+			},
+		},
+		{
+			name: "WhenDestinationWasChecked_ThenReportsItsHealth",
+			want: want{statusCode: http.StatusOK, body: `{"code":"someLink","url":"http://example.com","protected":false,` +
+				`"window":{"state":"active","inactive_mode":"not_found"},"clicks":{"total":0},` +
+				`"health":{"status":404,"latency_ms":85,"checked_at":"2030-06-01T09:00:00Z","failures":3,"broken_since":"2030-06-01T09:00:00Z"}}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").
+					Return(&domain.Link{Code: "someLink", OriginalURL: "http://example.com"}, nil)
+				m.analyticsService.EXPECT().Clicks(gomock.Any(), "someLink").Return(&domain.ClickStats{}, nil)
+				m.healthService.EXPECT().Health(gomock.Any(), "someLink").
+					Return(&domain.LinkHealth{Status: 404, LatencyMS: 85, CheckedAt: from, Failures: 3, BrokenSince: &from}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/v1/links/someLink/stats", nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.JSONEq(t, tt.want.body, w.Body.String())
+		})
+	}
+}
+
+func TestUnlockLink(t *testing.T) {
+	hash, _ := domain.HashPassword("open sesame")
+	protected := &domain.Link{Code: "someLink", OriginalURL: "http://example.com", PasswordHash: hash}
+
+	type want struct {
+		statusCode int
+		URL        string
+		page       string
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     want
+		mocks    func(m mocksShortenerHandler)
+	}{
+		{
+			name:     "WhenPasswordIsRight_ThenRedirectsAndResetsTheClientCounter",
+			password: "open sesame",
+			want:     want{statusCode: http.StatusSeeOther, URL: "http://example.com"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(protected, nil)
+				m.lockoutService.EXPECT().Reserve(gomock.Any(), "link:someLink", "ip:192.0.2.1").Return(nil)
+				m.lockoutService.EXPECT().Release(gomock.Any(), "link:someLink").Return(nil)
+				m.lockoutService.EXPECT().Reset(gomock.Any(), "ip:192.0.2.1").Return(nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+		{
+			name:     "WhenPasswordIsWrong_ThenKeepsTheAttemptAndShowsTheFormAgain",
+			password: "guess",
+			want:     want{statusCode: http.StatusUnauthorized, page: "Incorrect password."},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(protected, nil)
+				m.lockoutService.EXPECT().Reserve(gomock.Any(), "link:someLink", "ip:192.0.2.1").Return(nil)
+			},
+		},
+		{
+			name:     "WhenAttemptsAreLocked_ThenRejectsEvenTheRightPassword",
+			password: "open sesame",
+			want:     want{statusCode: http.StatusTooManyRequests, page: "Too many failed attempts."},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(protected, nil)
+				m.lockoutService.EXPECT().Reserve(gomock.Any(), "link:someLink", "ip:192.0.2.1").Return(domain.ErrTooManyAttempts)
+			},
+		},
+		{
+			name:     "WhenLinkIsPublic_ThenRedirects",
+			password: "",
+			want:     want{statusCode: http.StatusSeeOther, URL: "http://example.com"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").
+					Return(&domain.Link{Code: "someLink", OriginalURL: "http://example.com"}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Target: domain.DefaultTarget}).Return(nil)
+			},
+		},
+		{
+			name:     "WhenLinkIsDisabledAndHasAFallback_ThenRedirectsToTheFallback",
+			password: "open sesame",
+			want:     want{statusCode: http.StatusFound, URL: "http://example.com/fallback"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(&domain.Link{Code: "someLink",
+					OriginalURL: "http://example.com", PasswordHash: hash, FallbackURL: "http://example.com/fallback", Disabled: true}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Path: domain.PathDisabled}).Return(nil)
+			},
+		},
+		{
+			name:     "WhenLinkIsBrokenAndHasAFallback_ThenRedirectsToTheFallback",
+			password: "open sesame",
+			want:     want{statusCode: http.StatusFound, URL: "http://example.com/fallback"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(&domain.Link{Code: "someLink",
+					OriginalURL: "http://example.com", PasswordHash: hash, FallbackURL: "http://example.com/fallback"}, nil)
+				m.healthService.EXPECT().Health(gomock.Any(), "someLink").
+					Return(&domain.LinkHealth{Status: 404, Failures: 3, BrokenSince: &time.Time{}}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Path: domain.PathBroken}).Return(nil)
+			},
+		},
+		{
+			name:     "WhenLinkExpiredWithAFallback_ThenRedirectsToTheFallback",
+			password: "open sesame",
+			want:     want{statusCode: http.StatusFound, URL: "http://example.com/fallback"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "someLink").Return(nil, domain.ErrLinkNotFound)
+				m.storageService.EXPECT().ExpiredLink(gomock.Any(), "someLink").
+					Return(&domain.Link{Code: "someLink", FallbackURL: "http://example.com/fallback"}, nil)
+				m.analyticsService.EXPECT().RecordClick(gomock.Any(), "someLink", domain.Click{Path: domain.PathExpired}).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+			m.geoIPService.EXPECT().Country(gomock.Any()).Return("").AnyTimes()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			form := url.Values{"password": {tt.password}}
+			req, _ := http.NewRequest("POST", "/someLink", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.RemoteAddr = "192.0.2.1:1234"
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.Equal(t, tt.want.URL, w.Header().Get("Location"))
+			if tt.want.page != "" {
+				assert.Contains(t, w.Body.String(), tt.want.page)
+			}
+		})
+	}
+}
+
+func TestCreateLinksBulk(t *testing.T) {
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		requestBody string
+		want        want
+		mocks       func(m mocksShortenerHandler)
+	}{
+		{
+			name:        "WhenBodyIsEmpty_ThenReturnsBadRequest",
+			contentType: "application/json",
+			requestBody: `[]`,
+			want: want{statusCode: http.StatusBadRequest,
+				body: problemJSON(problem.InvalidRequest, "at least one url is required", "/api/v1/links/bulk")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenContentTypeIsUnsupported_ThenReturnsBadRequest",
+			contentType: "text/plain",
+			requestBody: `http://example.com`,
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"unsupported content type, use application/json, application/x-ndjson or text/csv", "/api/v1/links/bulk")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenBatchExceedsTheCap_ThenReturnsRequestEntityTooLarge",
+			contentType: "application/json",
+			requestBody: `[{"url":"http://example.com/1"},{"url":"http://example.com/2"},{"url":"http://example.com/3"}]`,
+			want: want{statusCode: http.StatusRequestEntityTooLarge,
+				body: problemJSON(problem.BatchTooLarge, "a batch can contain at most 2 items", "/api/v1/links/bulk")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenEveryJSONItemIsValid_ThenReturnsOKWithAllCodes",
+			contentType: "application/json",
+			requestBody: `[{"url":"http://example.com/1"},{"url":"http://example.com/2","alias":"promo","ttl":60}]`,
+			want: want{statusCode: http.StatusOK, body: `{"created":2,"failed":0,"results":[` +
+				`{"index":0,"url":"http://example.com/1","code":"gen1","short_url":"http://localhost/gen1"},` +
+				`{"index":1,"url":"http://example.com/2","code":"promo","short_url":"http://localhost/promo"}]}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink("http://example.com/1").Return("gen1", nil)
+				m.storageService.EXPECT().SaveURLs(gomock.Any(), []domain.Link{
+					{Code: "gen1", OriginalURL: "http://example.com/1", Shared: true},
+					{Code: "promo", OriginalURL: "http://example.com/2", Alias: true, TTL: time.Minute},
+				}).Return([]error{nil, nil})
+			},
+		},
+		{
+			name:        "WhenSomeNDJSONItemsFail_ThenReturnsMultiStatusWithPerItemErrors",
+			contentType: "application/x-ndjson",
+			requestBody: "{\"url\":\"not a url\"}\n{\"url\":\"http://example.com/1\",\"alias\":\"taken\"}\n",
+			want: want{statusCode: http.StatusMultiStatus, body: `{"created":0,"failed":2,"results":[` +
+				`{"index":0,"url":"not a url","error":"url is not valid","type":"` + problem.InvalidRequest.URI() + `"},` +
+				`{"index":1,"url":"http://example.com/1","error":"alias is already in use","type":"` + problem.AliasTaken.URI() + `"}]}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().SaveURLs(gomock.Any(), []domain.Link{
+					{Code: "taken", OriginalURL: "http://example.com/1", Alias: true},
+				}).Return([]error{domain.ErrAliasTaken})
+			},
+		},
+		{
+			name:        "WhenAliasIsReserved_ThenReportsTheItemAsInvalid",
+			contentType: "application/json",
+			requestBody: `[{"url":"http://example.com/1","alias":"Docs"}]`,
+			want: want{statusCode: http.StatusMultiStatus, body: `{"created":0,"failed":1,"results":[` +
+				`{"index":0,"url":"http://example.com/1","error":"alias is reserved for a system route","type":"` + problem.InvalidRequest.URI() + `"}]}`},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenDomainIsNotConfigured_ThenReportsTheItemAsInvalid",
+			contentType: "application/json",
+			requestBody: `[{"url":"http://example.com/1","domain":"acme.dev"}]`,
+			want: want{statusCode: http.StatusMultiStatus, body: `{"created":0,"failed":1,"results":[` +
+				`{"index":0,"url":"http://example.com/1","error":"domain is not configured: acme.dev","type":"` + problem.InvalidRequest.URI() + `"}]}`},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenCSVHasAHeader_ThenReadsColumnsByName",
+			contentType: "text/csv",
+			requestBody: "alias,url\nsale,http://example.com/sale\n",
+			want: want{statusCode: http.StatusOK, body: `{"created":1,"failed":0,"results":[` +
+				`{"index":0,"url":"http://example.com/sale","code":"sale","short_url":"http://localhost/sale"}]}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().SaveURLs(gomock.Any(), []domain.Link{
+					{Code: "sale", OriginalURL: "http://example.com/sale", Alias: true},
+				}).Return([]error{nil})
+			},
+		},
+		{
+			name:        "WhenSaveURLsFails_ThenHidesTheStorageError",
+			contentType: "application/json",
+			requestBody: `[{"url":"http://example.com/1"}]`,
+			want: want{statusCode: http.StatusMultiStatus, body: `{"created":0,"failed":1,"results":[` +
+				`{"index":0,"url":"http://example.com/1","error":"Storage unavailable","type":"` + problem.StorageUnavailable.URI() + `"}]}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink("http://example.com/1").Return("gen1", nil)
+				m.storageService.EXPECT().SaveURLs(gomock.Any(), gomock.Any()).
+					Return([]error{domain.Detailed(domain.ErrStorageUnavailable, "connection refused")})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+			m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			group := router.Group("/")
+
+			t.Setenv("HOST", "http://localhost/")
+			t.Setenv("BULK_MAX_ITEMS", "2")
+			urlshortener.NewURLShortenerHandler(group, m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/v1/links/bulk", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.JSONEq(t, tt.want.body, w.Body.String())
+		})
+	}
+}
+
+func TestCreateLinksBulkWhenCSVIsUploaded_ThenReadsTheFilePart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocksShortenerHandler{
+		storageService:     mocks.NewMockStorageService(ctrl),
+		shortenerService:   mocks.NewMockShortenerService(ctrl),
+		idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+		qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+		lockoutService:     mocks.NewMockLockoutService(ctrl),
+		analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+		geoIPService:       mocks.NewMockGeoIPService(ctrl),
+		quotaService:       mocks.NewMockQuotaService(ctrl),
+		keyService:         mocks.NewMockKeyService(ctrl),
+		auditService:       mocks.NewMockAuditService(ctrl),
+		titleService:       mocks.NewMockTitleService(ctrl),
+		healthService:      mocks.NewMockHealthService(ctrl),
+	}
+	m.shortenerService.EXPECT().GenerateShortLink("http://example.com/1").Return("gen1", nil)
+	m.storageService.EXPECT().SaveURLs(gomock.Any(), []domain.Link{
+		{Code: "gen1", OriginalURL: "http://example.com/1", TTL: 30 * time.Second, Shared: true},
+	}).Return([]error{nil})
+	m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	t.Setenv("HOST", "http://localhost/")
+	urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, _ := writer.CreateFormFile("file", "catalog.csv")
+	part.Write([]byte("http://example.com/1,,30\n"))
+	writer.Close()
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/links/bulk", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"created":1,"failed":0,"results":[`+
+		`{"index":0,"url":"http://example.com/1","code":"gen1","short_url":"http://localhost/gen1"}]}`, w.Body.String())
+}
+
+func TestCreateLinkWithIdempotencyKey(t *testing.T) {
+	type want struct {
+		statusCode int
+		body       string
+		replayed   string
+	}
+
+	tests := []struct {
+		name  string
+		key   string
+		want  want
+		mocks func(m mocksShortenerHandler)
+	}{
+		{
+			name: "WhenKeyIsNew_ThenCreatesTheLinkAndStoresTheResponse",
+			key:  "retry-1",
+			want: want{statusCode: http.StatusOK,
+				body: `{"message":"short url created successfully!","url":"http://localhost/shortLink"}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.idempotencyService.EXPECT().Reserve(gomock.Any(), "retry-1", gomock.Any()).Return(nil, nil)
+				m.shortenerService.EXPECT().GenerateShortLink("http://example.com").Return("shortLink", nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "shortLink", OriginalURL: "http://example.com", Shared: true}).Return(nil)
+				m.idempotencyService.EXPECT().Save(gomock.Any(), "retry-1", gomock.Any()).
+					DoAndReturn(func(_ interface{}, _ string, response domain.IdempotentResponse) error {
+						assert.Equal(t, http.StatusOK, response.StatusCode)
+						assert.Equal(t, "application/json; charset=utf-8", response.ContentType)
+						assert.JSONEq(t, `{"message":"short url created successfully!","url":"http://localhost/shortLink"}`,
+							string(response.Body))
+						return nil
+					})
+			},
+		},
+		{
+			name: "WhenKeyWasAlreadyAnswered_ThenReplaysTheStoredResponse",
+			key:  "retry-1",
+			want: want{statusCode: http.StatusOK, body: `{"message":"stored","url":"http://localhost/first"}`, replayed: "true"},
+			mocks: func(m mocksShortenerHandler) {
+				m.idempotencyService.EXPECT().Reserve(gomock.Any(), "retry-1", gomock.Any()).Return(&domain.IdempotentResponse{
+					StatusCode:  http.StatusOK,
+					ContentType: "application/json; charset=utf-8",
+					Body:        []byte(`{"message":"stored","url":"http://localhost/first"}`),
+				}, nil)
+			},
+		},
+		{
+			name: "WhenKeyIsReusedWithAnotherPayload_ThenReturnsUnprocessableEntity",
+			key:  "retry-1",
+			want: want{statusCode: http.StatusUnprocessableEntity, body: problemJSON(problem.IdempotencyKeyReused,
+				"idempotency key was already used with a different payload", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.idempotencyService.EXPECT().Reserve(gomock.Any(), "retry-1", gomock.Any()).Return(nil, domain.ErrIdempotencyKeyReused)
+			},
+		},
+		{
+			name: "WhenFirstRequestIsStillRunning_ThenReturnsConflict",
+			key:  "retry-1",
+			want: want{statusCode: http.StatusConflict, body: problemJSON(problem.IdempotencyKeyInProgress,
+				"a request with this idempotency key is still being processed", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.idempotencyService.EXPECT().Reserve(gomock.Any(), "retry-1", gomock.Any()).Return(nil, domain.ErrIdempotencyKeyInProgress)
+			},
+		},
+		{
+			name: "WhenCreationFails_ThenReleasesTheKey",
+			key:  "retry-1",
+			want: want{statusCode: http.StatusInternalServerError, body: problemJSON(problem.CodeGenerationFailed, "", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.idempotencyService.EXPECT().Reserve(gomock.Any(), "retry-1", gomock.Any()).Return(nil, nil)
+				m.shortenerService.EXPECT().GenerateShortLink("http://example.com").Return("", domain.ErrCodeGeneration)
+				m.idempotencyService.EXPECT().Release(gomock.Any(), "retry-1").Return(nil)
+			},
+		},
+		{
+			name: "WhenCreationConflicts_ThenReleasesTheKey",
+			key:  "retry-1",
+			want: want{statusCode: http.StatusConflict, body: problemJSON(problem.AliasTaken, "alias is already in use", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.idempotencyService.EXPECT().Reserve(gomock.Any(), "retry-1", gomock.Any()).Return(nil, nil)
+				m.shortenerService.EXPECT().GenerateShortLink("http://example.com").Return("shortLink", nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), gomock.Any()).Return(domain.ErrAliasTaken)
+				m.idempotencyService.EXPECT().Release(gomock.Any(), "retry-1").Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+			m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			group := router.Group("/")
+
+			t.Setenv("HOST", "http://localhost/")
+			urlshortener.NewURLShortenerHandler(group, m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/v1/createLink", bytes.NewBufferString(`{"url":"http://example.com"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Idempotency-Key", tt.key)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.JSONEq(t, tt.want.body, w.Body.String())
+			assert.Equal(t, tt.want.replayed, w.Header().Get("Idempotent-Replayed"))
+		})
+	}
+}
+
+func TestCreateLinkWithIdempotencyKeyWhenBodyIsTooLarge_ThenRejectsItUnread(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := newMocks(ctrl)
+	body := `{"url":"http://example.com/` + strings.Repeat("a", 1<<20) + `"}`
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/createLink", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "retry-1")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.JSONEq(t, problemJSON(problem.BodyTooLarge, "the request body must be at most 1048576 bytes", "/api/v1/createLink"), w.Body.String())
+}
+
+func TestCreateLinkWithQRCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocksShortenerHandler{
+		storageService:     mocks.NewMockStorageService(ctrl),
+		shortenerService:   mocks.NewMockShortenerService(ctrl),
+		idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+		qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+		lockoutService:     mocks.NewMockLockoutService(ctrl),
+		analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+		geoIPService:       mocks.NewMockGeoIPService(ctrl),
+		quotaService:       mocks.NewMockQuotaService(ctrl),
+		keyService:         mocks.NewMockKeyService(ctrl),
+		auditService:       mocks.NewMockAuditService(ctrl),
+		titleService:       mocks.NewMockTitleService(ctrl),
+		healthService:      mocks.NewMockHealthService(ctrl),
+	}
+	m.shortenerService.EXPECT().GenerateShortLink("http://example.com").Return("shortLink", nil)
+	m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "shortLink", OriginalURL: "http://example.com", Shared: true}).Return(nil)
+	m.qrCodeService.EXPECT().Render("http://localhost/shortLink", domain.DefaultQROptions()).Return([]byte("png"), nil)
+	m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	t.Setenv("HOST", "http://localhost/")
+	urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/api/v1/createLink", bytes.NewBufferString(`{"url":"http://example.com","qr":true}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"message":"short url created successfully!","url":"http://localhost/shortLink",`+
+		`"qr_code":"data:image/png;base64,cG5n"}`, w.Body.String())
+}
+
+func TestGetQRCode(t *testing.T) {
+	type want struct {
+		statusCode  int
+		contentType string
+		body        string
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  want
+		mocks func(m mocksShortenerHandler)
+	}{
+		{
+			name:  "WhenSizeIsNotANumber_ThenReturnsBadRequest",
+			query: "?size=big",
+			want: want{statusCode: http.StatusBadRequest, contentType: problem.ContentType,
+				body: problemJSON(problem.InvalidRequest, "invalid QR code options: size must be an integer", "/api/v1/links/someLink/qr")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name: "WhenLinkDoesNotExist_ThenReturnsNotFound",
+			want: want{statusCode: http.StatusNotFound, contentType: problem.ContentType,
+				body: problemJSON(problem.LinkNotFound, "link not found", "/api/v1/links/someLink/qr")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetURL(gomock.Any(), "someLink").Return("", domain.ErrLinkNotFound)
+			},
+		},
+		{
+			name:  "WhenOptionsAreOutOfRange_ThenReturnsBadRequest",
+			query: "?level=Z",
+			want: want{statusCode: http.StatusBadRequest, contentType: problem.ContentType,
+				body: problemJSON(problem.InvalidRequest, "invalid QR code options: level must be one of L, M, Q or H", "/api/v1/links/someLink/qr")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetURL(gomock.Any(), "someLink").Return("http://example.com", nil)
+				options := domain.DefaultQROptions()
+				options.Level = "Z"
+				m.qrCodeService.EXPECT().Render("http://localhost/someLink", options).
+					Return(nil, domain.Detailed(domain.ErrInvalidQROptions, "level must be one of L, M, Q or H"))
+			},
+		},
+		{
+			name:  "WhenEverythingOK_ThenReturnsTheImage",
+			query: "?format=svg&size=512&level=H&margin=2&fg=%23112233&bg=ffffff",
+			want:  want{statusCode: http.StatusOK, contentType: "image/svg+xml", body: "<svg/>"},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetURL(gomock.Any(), "someLink").Return("http://example.com", nil)
+				m.qrCodeService.EXPECT().Render("http://localhost/someLink", domain.QROptions{
+					Format: "svg", Size: 512, Level: "H", Margin: 2, Foreground: "#112233", Background: "ffffff",
+				}).Return([]byte("<svg/>"), nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+			group := router.Group("/")
+
+			t.Setenv("HOST", "http://localhost/")
+			urlshortener.NewURLShortenerHandler(group, m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/api/v1/links/someLink/qr"+tt.query, nil)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.Equal(t, tt.want.contentType, w.Header().Get("Content-Type"))
+			if tt.want.contentType == problem.ContentType {
+				assert.JSONEq(t, tt.want.body, w.Body.String())
+			} else {
+				assert.Equal(t, tt.want.body, w.Body.String())
+			}
 		})
 	}
 }
@@ -0,0 +1,66 @@
+package urlshortener
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	log "github.com/sirupsen/logrus"
+)
+
+// utmTemplatesFromEnv loads UTM_TEMPLATES, a JSON file mapping team names to
+// their UTM template. Teams without a template can tag links freely.
+func utmTemplatesFromEnv() map[string]domain.UTMTemplate {
+	path := os.Getenv("UTM_TEMPLATES")
+	if path == "" {
+		return nil
+	}
+	templates, err := loadUTMTemplates(path)
+	if err != nil {
+		log.Error(fmt.Errorf("loading the UTM templates, links are not checked against them --> %w", err))
+		return nil
+	}
+	return templates
+}
+
+func loadUTMTemplates(path string) (map[string]domain.UTMTemplate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s --> %w", path, err)
+	}
+	var templates map[string]domain.UTMTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("decoding %s --> %w", path, err)
+	}
+	for team, template := range templates {
+		if err := template.ValidateTemplate(); err != nil {
+			return nil, fmt.Errorf("validating the template of team %s --> %w", team, err)
+		}
+	}
+	return templates, nil
+}
+
+// applyUTM checks the UTM parameters of a creation request against the
+// template of team and writes them into its URL, so that the code is
+// generated from the tagged URL.
+func (u *URLShortenerHandler) applyUTM(req *CreateLinkRequest, team string) error {
+	if req.UTM == nil {
+		return nil
+	}
+	if err := req.UTM.Validate(); err != nil {
+		return err
+	}
+	if template, ok := u.utmTemplates[team]; ok {
+		if err := template.Check(*req.UTM); err != nil {
+			return err
+		}
+	}
+
+	tagged, err := req.UTM.Apply(req.URL)
+	if err != nil {
+		return err
+	}
+	req.URL = tagged
+	return nil
+}
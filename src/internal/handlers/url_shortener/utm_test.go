@@ -0,0 +1,115 @@
+package urlshortener_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	urlshortener "github.com/dariomba/url-shortener/src/internal/handlers/url_shortener"
+	"github.com/dariomba/url-shortener/src/internal/mocks"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateLinkWithUTM(t *testing.T) {
+	templates := filepath.Join(t.TempDir(), "utm_templates.json")
+	err := os.WriteFile(templates, []byte(`{"marketing": {"required": ["source", "medium", "campaign"], "allowed": {"medium": ["email", "social"]}}}`), 0o600)
+	assert.NoError(t, err)
+	t.Setenv("UTM_TEMPLATES", templates)
+	t.Setenv("HOST", "http://localhost/")
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name        string
+		requestBody string
+		want        want
+		mocks       func(m mocksShortenerHandler)
+	}{
+		{
+			name:        "WhenUTMIsSet_ThenGeneratesTheCodeFromTheTaggedURL",
+			requestBody: `{"url": "http://example.com/landing?utm_source=Mail", "utm": {"source": "newsletter", "medium": "email"}}`,
+			want: want{statusCode: http.StatusOK,
+				body: `{"message": "short url created successfully!", "url": "http://localhost/taggedLink"}`},
+			mocks: func(m mocksShortenerHandler) {
+				tagged := "http://example.com/landing?utm_source=newsletter&utm_medium=email"
+				m.shortenerService.EXPECT().GenerateShortLink(tagged).Return("taggedLink", nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "taggedLink", OriginalURL: tagged, Shared: true}).Return(nil)
+			},
+		},
+		{
+			name:        "WhenUTMFollowsTheTemplateOfTheTeam_ThenCreatesTheLink",
+			requestBody: `{"url": "http://example.com", "team": "marketing", "utm": {"source": "newsletter", "medium": "email", "campaign": "spring"}}`,
+			want: want{statusCode: http.StatusOK,
+				body: `{"message": "short url created successfully!", "url": "http://localhost/taggedLink"}`},
+			mocks: func(m mocksShortenerHandler) {
+				tagged := "http://example.com?utm_source=newsletter&utm_medium=email&utm_campaign=spring"
+				m.shortenerService.EXPECT().GenerateShortLink(tagged).Return("taggedLink", nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "taggedLink", OriginalURL: tagged, Shared: true}).Return(nil)
+			},
+		},
+		{
+			name:        "WhenUTMBreaksTheTemplateOfTheTeam_ThenReturnsBadRequest",
+			requestBody: `{"url": "http://example.com", "team": "marketing", "utm": {"source": "newsletter", "medium": "banner", "campaign": "spring"}}`,
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"invalid utm parameters: medium must be one of email, social", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenUTMIsEmpty_ThenReturnsBadRequest",
+			requestBody: `{"url": "http://example.com", "utm": {}}`,
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"invalid utm parameters: at least one parameter must be set", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+			m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/api/v1/createLink", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.JSONEq(t, tt.want.body, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package urlshortener
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// variantCookiePrefix names the cookie that remembers the variant a
+	// visitor was sent to, one per link.
+	variantCookiePrefix = "variant_"
+	variantCookieMaxAge = 30 * 24 * 60 * 60
+)
+
+type UpdateVariantsRequest struct {
+	Variants []domain.Variant `json:"variants" binding:"required"`
+}
+
+// UpdateLinkVariants replaces the variants of a split link, so that its
+// weights can be tuned while it is live. Visitors keep the variant they were
+// given unless its weight drops to zero. The previous weights are kept in the
+// history of the link, see RollbackLink. Links created without variants may
+// share their code with other links to the same URL and cannot be split
+// afterwards.
+func (u *URLShortenerHandler) UpdateLinkVariants(c *gin.Context) {
+	var req UpdateVariantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Error(fmt.Errorf("binding the JSON --> %w", err))
+		problem.Abort(c, problem.New(problem.InvalidRequest, "variants parameter is required"))
+		return
+	}
+	if err := domain.ValidateVariants(req.Variants); err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	shortDomain, err := u.queryDomain(c)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+
+	link, err := u.storageService.GetLink(c, managedKey(c, shortDomain, c.Param("code")))
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the link --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	if !u.authorize(c, domain.ActionUpdate, link.Key(), link) {
+		return
+	}
+	if len(link.Variants) == 0 {
+		problem.Abort(c, problem.New(problem.InvalidRequest, "the link has no variants, create a new link with variants to split traffic"))
+		return
+	}
+
+	before := *link
+	link.Variants = req.Variants
+	if err := u.reviseLink(c, before, link); err != nil {
+		log.Error(fmt.Errorf("updating the variants --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	u.auditChange(c, domain.ActionUpdate, link.Key(), before, link)
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":     link.Code,
+		"variants": link.Variants,
+	})
+}
+
+// rememberVariant keeps the visitor on the same variant on their next visits,
+// even from another IP.
+func rememberVariant(c *gin.Context, code string, variant string) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(variantCookiePrefix+code, variant, variantCookieMaxAge, "/"+code, "", c.Request.TLS != nil, true)
+}
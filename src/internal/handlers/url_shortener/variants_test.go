@@ -0,0 +1,181 @@
+package urlshortener_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	urlshortener "github.com/dariomba/url-shortener/src/internal/handlers/url_shortener"
+	"github.com/dariomba/url-shortener/src/internal/mocks"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateLinkVariants(t *testing.T) {
+	variants := []domain.Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 50},
+		{Name: "b", URL: "https://example.com/b", Weight: 50},
+	}
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name        string
+		requestBody string
+		want        want
+		mocks       func(m mocksShortenerHandler)
+	}{
+		{
+			name:        "WhenWeightsChange_ThenSavesTheNewVariants",
+			requestBody: `{"variants":[{"name":"a","url":"https://example.com/a","weight":70},{"name":"b","url":"https://example.com/b","weight":30}]}`,
+			want: want{statusCode: http.StatusOK, body: `{"code":"split","variants":[` +
+				`{"name":"a","url":"https://example.com/a","weight":70},{"name":"b","url":"https://example.com/b","weight":30}]}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "split").
+					Return(&domain.Link{Code: "split", OriginalURL: "https://example.com", MaxClicks: 10, Variants: variants}, nil)
+				m.storageService.EXPECT().ReviseLink(gomock.Any(), revisedLink{Code: "split", OriginalURL: "https://example.com", MaxClicks: 10,
+					Version: 2, Variants: []domain.Variant{
+						{Name: "a", URL: "https://example.com/a", Weight: 70},
+						{Name: "b", URL: "https://example.com/b", Weight: 30},
+					}}, domain.LinkVersion{Version: 1, URL: "https://example.com", Variants: variants}).Return(nil)
+			},
+		},
+		{
+			name:        "WhenVariantsAreInvalid_ThenReturnsBadRequest",
+			requestBody: `{"variants":[{"name":"a","url":"https://example.com/a","weight":0},{"name":"b","url":"https://example.com/b","weight":0}]}`,
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"invalid variants: at least one variant must have a weight", "/api/v1/links/split/variants")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenLinkHasNoVariants_ThenReturnsBadRequest",
+			requestBody: `{"variants":[{"name":"a","url":"https://example.com/a","weight":1},{"name":"b","url":"https://example.com/b","weight":1}]}`,
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"the link has no variants, create a new link with variants to split traffic", "/api/v1/links/split/variants")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "split").
+					Return(&domain.Link{Code: "split", OriginalURL: "https://example.com"}, nil)
+			},
+		},
+		{
+			name:        "WhenLinkDoesNotExist_ThenReturnsNotFound",
+			requestBody: `{"variants":[{"name":"a","url":"https://example.com/a","weight":1},{"name":"b","url":"https://example.com/b","weight":1}]}`,
+			want: want{statusCode: http.StatusNotFound,
+				body: problemJSON(problem.LinkNotFound, "link not found", "/api/v1/links/split/variants")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "split").Return(nil, domain.ErrLinkNotFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+			m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/api/v1/links/split/variants", strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			assert.JSONEq(t, tt.want.body, w.Body.String())
+		})
+	}
+}
+
+func TestRedirectToURLWhenLinkIsSplit_ThenKeepsVisitorsOnTheirVariant(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	m := mocksShortenerHandler{
+		storageService:     mocks.NewMockStorageService(ctrl),
+		shortenerService:   mocks.NewMockShortenerService(ctrl),
+		idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+		qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+		lockoutService:     mocks.NewMockLockoutService(ctrl),
+		analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+		geoIPService:       mocks.NewMockGeoIPService(ctrl),
+		quotaService:       mocks.NewMockQuotaService(ctrl),
+		keyService:         mocks.NewMockKeyService(ctrl),
+		auditService:       mocks.NewMockAuditService(ctrl),
+		titleService:       mocks.NewMockTitleService(ctrl),
+		healthService:      mocks.NewMockHealthService(ctrl),
+	}
+	link := &domain.Link{Code: "split", OriginalURL: "https://example.com", Variants: []domain.Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 1},
+		{Name: "b", URL: "https://example.com/b", Weight: 1},
+	}}
+	m.storageService.EXPECT().GetLink(gomock.Any(), "split").Return(link, nil).Times(2)
+	m.geoIPService.EXPECT().Country(gomock.Any()).Return("").Times(2)
+	var clicks []domain.Click
+	m.analyticsService.EXPECT().RecordClick(gomock.Any(), "split", gomock.Any()).
+		DoAndReturn(func(_ interface{}, _ string, click domain.Click) error {
+			clicks = append(clicks, click)
+			return nil
+		}).Times(2)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+	urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/split", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	cookies := w.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.Equal(t, "variant_split", cookies[0].Name)
+	assert.Equal(t, "/split", cookies[0].Path)
+	assert.Equal(t, "https://example.com/"+cookies[0].Value, w.Header().Get("Location"))
+	assert.Equal(t, "private", w.Header().Get("Cache-Control"))
+
+	// The same visitor from another network keeps their variant.
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/split", nil)
+	req.RemoteAddr = "198.51.100.9:1234"
+	req.AddCookie(cookies[0])
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "https://example.com/"+cookies[0].Value, w.Header().Get("Location"))
+	assert.Empty(t, w.Result().Cookies())
+	assert.Equal(t, []domain.Click{
+		{Target: domain.DefaultTarget, Variant: cookies[0].Value},
+		{Target: domain.DefaultTarget, Variant: cookies[0].Value},
+	}, clicks)
+}
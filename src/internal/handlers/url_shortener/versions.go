@@ -0,0 +1,276 @@
+package urlshortener
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// UpdateLinkRequest changes the destination, targeting or activation window
+// of a link, how it is grouped or described. Fields left out keep their
+// value, and a null active_from or active_until removes it.
+type UpdateLinkRequest struct {
+	URL          *string              `json:"url"`
+	Targets      *[]domain.TargetRule `json:"targets"`
+	ActiveFrom   NullableTime         `json:"active_from"`
+	ActiveUntil  NullableTime         `json:"active_until"`
+	InactiveMode *string              `json:"inactive_mode"`
+	FallbackURL  *string              `json:"fallback_url"`
+	Tags         *[]string            `json:"tags"`
+	Campaign     *string              `json:"campaign"`
+	Title        *string              `json:"title"`
+	Note         *string              `json:"note"`
+}
+
+// NullableTime is a time a request can leave out, to keep the current one,
+// or set to null, to remove it.
+type NullableTime struct {
+	Set  bool
+	Time *time.Time
+}
+
+func (t *NullableTime) UnmarshalJSON(data []byte) error {
+	t.Set = true
+	if string(data) == "null" {
+		t.Time = nil
+		return nil
+	}
+	var value time.Time
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	t.Time = &value
+	return nil
+}
+
+type LinkVersionsResponse struct {
+	Code string `json:"code"`
+	// Versions holds the current version of the link and then the past
+	// ones, newest first.
+	Versions []domain.LinkVersion `json:"versions"`
+}
+
+// UpdateLink changes where a link sends its visitors. The version it replaces
+// is kept in the history of the link, see RollbackLink. Tags, campaign, title
+// and note are not versioned: changing only them saves the link as it is. A
+// link left without a title, or sent elsewhere without one, gets the title of
+// its new destination. Either way it fails with 409 rather than overwrite a
+// change made in the meantime.
+func (u *URLShortenerHandler) UpdateLink(c *gin.Context) {
+	var req UpdateLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Error(fmt.Errorf("binding the JSON --> %w", err))
+		problem.Abort(c, problem.New(problem.InvalidRequest, "the body must be a JSON object"))
+		return
+	}
+
+	link, ok := u.managedLink(c, domain.ActionUpdate)
+	if !ok {
+		return
+	}
+	before := *link
+	err := applyLinkUpdate(link, req)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	if reflect.DeepEqual(before, *link) {
+		c.JSON(http.StatusOK, revisionResponse(link))
+		return
+	}
+
+	if reflect.DeepEqual(before.Snapshot(), link.Snapshot()) {
+		err = u.storageService.UpdateLink(c, *link, before)
+	} else {
+		err = u.reviseLink(c, before, link)
+	}
+	if err != nil {
+		log.Error(fmt.Errorf("updating the link --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	u.auditChange(c, domain.ActionUpdate, link.Key(), before, link)
+	if before.Title != link.Title || before.OriginalURL != link.OriginalURL {
+		u.fetchTitle(*link)
+	}
+	c.JSON(http.StatusOK, revisionResponse(link))
+}
+
+// revisionResponse is the body of the answer to a change of link.
+func revisionResponse(link *domain.Link) gin.H {
+	return gin.H{"code": link.Code, "current": link.Snapshot(), "tags": tagsOf(*link), "campaign": link.Campaign,
+		"title": link.Title, "note": link.Note}
+}
+
+// tagsOf returns the tags of link, empty rather than nil so that they encode
+// as a JSON array.
+func tagsOf(link domain.Link) []string {
+	if link.Tags == nil {
+		return []string{}
+	}
+	return link.Tags
+}
+
+// applyLinkUpdate sets the fields of req on link and validates the result.
+func applyLinkUpdate(link *domain.Link, req UpdateLinkRequest) error {
+	if req.URL != nil {
+		if *req.URL == "" {
+			return problem.New(problem.InvalidRequest, "url cannot be empty")
+		}
+		if !domain.IsAbsoluteURL(*req.URL) {
+			return problem.New(problem.InvalidRequest, "url is not valid")
+		}
+		link.OriginalURL = *req.URL
+	}
+	if req.Targets != nil {
+		if err := domain.ValidateTargets(*req.Targets); err != nil {
+			return err
+		}
+		link.Targets = *req.Targets
+	}
+	if req.ActiveFrom.Set {
+		link.ActiveFrom = req.ActiveFrom.Time
+	}
+	if req.ActiveUntil.Set {
+		link.ActiveUntil = req.ActiveUntil.Time
+	}
+	if req.InactiveMode != nil {
+		link.InactiveMode = *req.InactiveMode
+	}
+	if req.FallbackURL != nil {
+		link.FallbackURL = *req.FallbackURL
+	}
+	if req.Tags != nil {
+		tags, err := domain.NormalizeTags(*req.Tags)
+		if err != nil {
+			return err
+		}
+		link.Tags = tags
+	}
+	if req.Campaign != nil {
+		campaign, err := domain.NormalizeCampaign(*req.Campaign)
+		if err != nil {
+			return err
+		}
+		link.Campaign = campaign
+	}
+	if req.Title != nil {
+		title, err := domain.NormalizeTitle(*req.Title)
+		if err != nil {
+			return err
+		}
+		link.Title = title
+	}
+	if req.Note != nil {
+		if err := domain.ValidateNote(*req.Note); err != nil {
+			return err
+		}
+		link.Note = *req.Note
+	}
+	return link.ValidateSchedule()
+}
+
+// ListLinkVersions returns the current version of a link and the past ones
+// it keeps, at most domain.MaxLinkVersions.
+func (u *URLShortenerHandler) ListLinkVersions(c *gin.Context) {
+	link, ok := u.managedLink(c, domain.ActionRead)
+	if !ok {
+		return
+	}
+
+	versions, err := u.storageService.LinkVersions(c, *link)
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the versions --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, LinkVersionsResponse{
+		Code:     link.Code,
+		Versions: append([]domain.LinkVersion{link.Snapshot()}, versions...),
+	})
+}
+
+// RollbackLink sends a link back to where one of its past versions did. The
+// rollback is saved as a new version, so it can itself be rolled back, and
+// fails with 409 rather than overwrite a change made in the meantime.
+func (u *URLShortenerHandler) RollbackLink(c *gin.Context) {
+	number, err := strconv.ParseInt(c.Query("version"), 10, 64)
+	if err != nil || number < 1 {
+		problem.Abort(c, problem.New(problem.InvalidRequest, "version must be a positive integer"))
+		return
+	}
+
+	link, ok := u.managedLink(c, domain.ActionRollback)
+	if !ok {
+		return
+	}
+	if number == link.CurrentVersion() {
+		problem.Abort(c, problem.New(problem.InvalidRequest, fmt.Sprintf("version %d is the current version", number)))
+		return
+	}
+
+	versions, err := u.storageService.LinkVersions(c, *link)
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the versions --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	i := slices.IndexFunc(versions, func(version domain.LinkVersion) bool { return version.Version == number })
+	if i < 0 {
+		problem.AbortWithError(c, domain.Detailed(domain.ErrVersionNotFound, "%d", number))
+		return
+	}
+
+	before := *link
+	link.Restore(versions[i])
+	if err := u.reviseLink(c, before, link); err != nil {
+		log.Error(fmt.Errorf("rolling back the link --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	u.auditChange(c, domain.ActionRollback, link.Key(), before, link)
+	c.JSON(http.StatusOK, revisionResponse(link))
+}
+
+// managedLink returns the link named by the code route parameter and the
+// domain query parameter when the API key of the request may perform action
+// on it, and the action only reads it or the link is not shared. Otherwise
+// it answers with the problem.
+func (u *URLShortenerHandler) managedLink(c *gin.Context, action string) (*domain.Link, bool) {
+	shortDomain, err := u.queryDomain(c)
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return nil, false
+	}
+
+	link, err := u.storageService.GetLink(c, managedKey(c, shortDomain, c.Param("code")))
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the link --> %w", err))
+		problem.AbortWithError(c, err)
+		return nil, false
+	}
+	if !u.authorize(c, action, link.Key(), link) {
+		return nil, false
+	}
+	if action != domain.ActionRead && link.Shared {
+		problem.AbortWithError(c, domain.ErrLinkShared)
+		return nil, false
+	}
+	return link, true
+}
+
+// reviseLink saves link as the version that follows before, which is kept in
+// the history of the link.
+func (u *URLShortenerHandler) reviseLink(c *gin.Context, before domain.Link, link *domain.Link) error {
+	link.Revise(before, actorOf(c).Key.Name, time.Now().UTC())
+	return u.storageService.ReviseLink(c, *link, before.Snapshot())
+}
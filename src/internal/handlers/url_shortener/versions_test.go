@@ -0,0 +1,280 @@
+package urlshortener_test
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// revisedLink matches a link saved as a new version, regardless of when.
+type revisedLink domain.Link
+
+func (l revisedLink) Matches(x interface{}) bool {
+	link, ok := x.(domain.Link)
+	if !ok || link.UpdatedAt == nil {
+		return false
+	}
+	link.UpdatedAt = nil
+	return reflect.DeepEqual(link, domain.Link(l))
+}
+
+func (l revisedLink) String() string {
+	return fmt.Sprintf("is revised link %+v", domain.Link(l))
+}
+
+func TestLinkVersions(t *testing.T) {
+	updatedAt := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	link := func() *domain.Link {
+		return &domain.Link{Code: "promo", OriginalURL: "http://example.net", MaxClicks: 5, Version: 3, UpdatedAt: &updatedAt}
+	}
+	history := []domain.LinkVersion{
+		{Version: 2, Time: &updatedAt, URL: "http://example.org"},
+		{Version: 1, URL: "http://example.com", Targets: []domain.TargetRule{{OS: "iOS", URL: "http://apps.example.com"}}},
+	}
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   want
+		mocks  func(m mocksShortenerHandler)
+	}{
+		{
+			name:   "WhenDestinationIsUpdated_ThenSavesANewVersion",
+			method: "PATCH",
+			path:   "/api/v1/links/promo",
+			body:   `{"url": "http://example.com/new"}`,
+			want:   want{statusCode: http.StatusOK},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com"}, nil)
+				m.storageService.EXPECT().ReviseLink(gomock.Any(), revisedLink{Code: "promo", OriginalURL: "http://example.com/new", Version: 2},
+					domain.LinkVersion{Version: 1, URL: "http://example.com"}).Return(nil)
+			},
+		},
+		{
+			name:   "WhenActivationWindowIsCleared_ThenSavesANewVersionWithoutIt",
+			method: "PATCH",
+			path:   "/api/v1/links/promo",
+			body:   `{"active_from": null}`,
+			want:   want{statusCode: http.StatusOK},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").
+					Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com", ActiveFrom: &updatedAt, ActiveUntil: &updatedAt}, nil)
+				m.storageService.EXPECT().ReviseLink(gomock.Any(),
+					revisedLink{Code: "promo", OriginalURL: "http://example.com", ActiveUntil: &updatedAt, Version: 2},
+					domain.LinkVersion{Version: 1, URL: "http://example.com", ActiveFrom: &updatedAt, ActiveUntil: &updatedAt}).Return(nil)
+			},
+		},
+		{
+			name:   "WhenLinkIsShared_ThenRefusesToUpdateIt",
+			method: "PATCH",
+			path:   "/api/v1/links/promo",
+			body:   `{"url": "http://example.com/new"}`,
+			want: want{statusCode: http.StatusConflict, body: problemJSON(problem.LinkShared,
+				domain.ErrLinkShared.Error(), "/api/v1/links/promo")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com", Shared: true}, nil)
+			},
+		},
+		{
+			name:   "WhenLinkIsShared_ThenRefusesToRollItBack",
+			method: "POST",
+			path:   "/api/v1/links/promo/rollback?version=1",
+			want: want{statusCode: http.StatusConflict, body: problemJSON(problem.LinkShared,
+				domain.ErrLinkShared.Error(), "/api/v1/links/promo/rollback")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com", Version: 2, Shared: true}, nil)
+			},
+		},
+		{
+			name:   "WhenLinkIsShared_ThenRefusesToDisableIt",
+			method: "POST",
+			path:   "/api/v1/links/promo/disable",
+			want: want{statusCode: http.StatusConflict, body: problemJSON(problem.LinkShared,
+				domain.ErrLinkShared.Error(), "/api/v1/links/promo/disable")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com", Shared: true}, nil)
+			},
+		},
+		{
+			name:   "WhenURLIsNotValid_ThenReturnsBadRequest",
+			method: "PATCH",
+			path:   "/api/v1/links/promo",
+			body:   `{"url": "/relative/path"}`,
+			want:   want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest, "url is not valid", "/api/v1/links/promo")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com"}, nil)
+			},
+		},
+		{
+			name:   "WhenOnlyTagsAndCampaignChange_ThenSavesTheLinkWithoutANewVersion",
+			method: "PATCH",
+			path:   "/api/v1/links/promo",
+			body:   `{"tags": ["Summer", "email"], "campaign": "Launch"}`,
+			want: want{statusCode: http.StatusOK, body: `{"code": "promo", "tags": ["summer", "email"], "campaign": "launch",
+				"title": "", "note": "", "current": {"version": 1, "url": "http://example.com"}}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com"}, nil)
+				m.storageService.EXPECT().UpdateLink(gomock.Any(), domain.Link{Code: "promo", OriginalURL: "http://example.com",
+					Tags: []string{"summer", "email"}, Campaign: "launch"}, domain.Link{Code: "promo", OriginalURL: "http://example.com"}).Return(nil)
+			},
+		},
+		{
+			name:   "WhenTitleIsCleared_ThenFetchesTheTitleOfTheDestination",
+			method: "PATCH",
+			path:   "/api/v1/links/promo",
+			body:   `{"title": "", "note": "Printed on the spring flyer"}`,
+			want: want{statusCode: http.StatusOK, body: `{"code": "promo", "tags": [], "campaign": "", "title": "",
+				"note": "Printed on the spring flyer", "current": {"version": 1, "url": "http://example.com"}}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com", Title: "Old"}, nil)
+				m.storageService.EXPECT().UpdateLink(gomock.Any(), domain.Link{Code: "promo", OriginalURL: "http://example.com",
+					Note: "Printed on the spring flyer"}, domain.Link{Code: "promo", OriginalURL: "http://example.com", Title: "Old"}).Return(nil)
+				m.titleService.EXPECT().Enqueue("promo", "http://example.com")
+			},
+		},
+		{
+			name:   "WhenTagIsNotValid_ThenReturnsBadRequest",
+			method: "PATCH",
+			path:   "/api/v1/links/promo",
+			body:   `{"tags": ["q3 launch"]}`,
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				`invalid tags or campaign: tag "q3 launch" must be 1-64 lowercase letters, digits, '-' or '_'`, "/api/v1/links/promo")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com"}, nil)
+			},
+		},
+		{
+			name:   "WhenUpdateBreaksTheSchedule_ThenReturnsBadRequest",
+			method: "PATCH",
+			path:   "/api/v1/links/promo",
+			body:   `{"inactive_mode": "fallback"}`,
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"invalid activation window: fallback_url is required when inactive_mode is fallback", "/api/v1/links/promo")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com"}, nil)
+			},
+		},
+		{
+			name:   "WhenLinkChangedMeanwhile_ThenReturnsConflict",
+			method: "PATCH",
+			path:   "/api/v1/links/promo",
+			body:   `{"url": "http://example.com/new"}`,
+			want: want{statusCode: http.StatusConflict, body: problemJSON(problem.LinkChanged,
+				domain.ErrLinkChanged.Error(), "/api/v1/links/promo")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com"}, nil)
+				m.storageService.EXPECT().ReviseLink(gomock.Any(), gomock.Any(), gomock.Any()).Return(domain.ErrLinkChanged)
+			},
+		},
+		{
+			name:   "WhenLinkChangedMeanwhileAndOnlyTagsChange_ThenReturnsConflict",
+			method: "PATCH",
+			path:   "/api/v1/links/promo",
+			body:   `{"tags": ["summer"]}`,
+			want: want{statusCode: http.StatusConflict, body: problemJSON(problem.LinkChanged,
+				domain.ErrLinkChanged.Error(), "/api/v1/links/promo")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com"}, nil)
+				m.storageService.EXPECT().UpdateLink(gomock.Any(), gomock.Any(), gomock.Any()).Return(domain.ErrLinkChanged)
+			},
+		},
+		{
+			name:   "WhenLinkChangedMeanwhileItIsDisabled_ThenReturnsConflict",
+			method: "POST",
+			path:   "/api/v1/links/promo/disable",
+			want: want{statusCode: http.StatusConflict, body: problemJSON(problem.LinkChanged,
+				domain.ErrLinkChanged.Error(), "/api/v1/links/promo/disable")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(&domain.Link{Code: "promo", OriginalURL: "http://example.com"}, nil)
+				m.storageService.EXPECT().UpdateLink(gomock.Any(), gomock.Any(), gomock.Any()).Return(domain.ErrLinkChanged)
+			},
+		},
+		{
+			name:   "WhenVersionsAreListed_ThenReturnsTheCurrentOneFirst",
+			method: "GET",
+			path:   "/api/v1/links/promo/versions",
+			want: want{statusCode: http.StatusOK, body: `{"code": "promo", "versions": [
+				{"version": 3, "time": "2030-06-01T09:00:00Z", "url": "http://example.net"},
+				{"version": 2, "time": "2030-06-01T09:00:00Z", "url": "http://example.org"},
+				{"version": 1, "url": "http://example.com", "targets": [{"os": "iOS", "url": "http://apps.example.com"}]}]}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(link(), nil)
+				m.storageService.EXPECT().LinkVersions(gomock.Any(), *link()).Return(history, nil)
+			},
+		},
+		{
+			name:   "WhenRolledBack_ThenRestoresTheVersionAsANewOne",
+			method: "POST",
+			path:   "/api/v1/links/promo/rollback?version=1",
+			want:   want{statusCode: http.StatusOK},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(link(), nil)
+				m.storageService.EXPECT().LinkVersions(gomock.Any(), *link()).Return(history, nil)
+				m.storageService.EXPECT().ReviseLink(gomock.Any(), revisedLink{Code: "promo", OriginalURL: "http://example.com", MaxClicks: 5,
+					Version: 4, Targets: history[1].Targets}, link().Snapshot()).Return(nil)
+			},
+		},
+		{
+			name:   "WhenVersionIsNoLongerKept_ThenReturnsNotFound",
+			method: "POST",
+			path:   "/api/v1/links/promo/rollback?version=7",
+			want: want{statusCode: http.StatusNotFound, body: problemJSON(problem.VersionNotFound,
+				"version not found: 7", "/api/v1/links/promo/rollback")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(link(), nil)
+				m.storageService.EXPECT().LinkVersions(gomock.Any(), *link()).Return(history, nil)
+			},
+		},
+		{
+			name:   "WhenVersionIsTheCurrentOne_ThenReturnsBadRequest",
+			method: "POST",
+			path:   "/api/v1/links/promo/rollback?version=3",
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"version 3 is the current version", "/api/v1/links/promo/rollback")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "promo").Return(link(), nil)
+			},
+		},
+		{
+			name:   "WhenVersionIsMissing_ThenReturnsBadRequest",
+			method: "POST",
+			path:   "/api/v1/links/promo/rollback",
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"version must be a positive integer", "/api/v1/links/promo/rollback")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := newMocks(ctrl)
+			tt.mocks(m)
+			m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+			w := serveAPI(t, m, tt.method, tt.path, tt.body, "")
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			if tt.want.body != "" {
+				assert.JSONEq(t, tt.want.body, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package urlshortener
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// actorContextKey holds the workspace and API key of an authenticated
+// request.
+const actorContextKey = "actor"
+
+// workspacesFromEnv loads WORKSPACES, a JSON file listing the workspaces and
+// their API keys. Without it the API is open and links belong to no
+// workspace.
+func workspacesFromEnv() (*domain.Workspaces, error) {
+	path := os.Getenv("WORKSPACES")
+	if path == "" {
+		return nil, nil
+	}
+	workspaces, err := loadWorkspaces(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading the workspaces --> %w", err)
+	}
+	return workspaces, nil
+}
+
+func loadWorkspaces(path string) (*domain.Workspaces, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s --> %w", path, err)
+	}
+	var list []domain.Workspace
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("decoding %s --> %w", path, err)
+	}
+	workspaces, err := domain.NewWorkspaces(list)
+	if err != nil {
+		return nil, fmt.Errorf("validating %s --> %w", path, err)
+	}
+	return workspaces, nil
+}
+
+// authenticate finds the workspace and role of the API key sent as a bearer
+// token, among the keys of the configuration and then those created through
+// the API, and keeps them in the context for the handlers. It lets every
+// request through when the server has no workspaces.
+func (u *URLShortenerHandler) authenticate(c *gin.Context) {
+	if u.workspaces == nil {
+		c.Next()
+		return
+	}
+
+	apiKey, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	actor, err := u.workspaces.Authenticate(apiKey)
+	if errors.Is(err, domain.ErrUnauthorized) && apiKey != "" {
+		actor, err = u.storedKeyActor(c, apiKey)
+	}
+	if errors.Is(err, domain.ErrUnauthorized) {
+		c.Header("WWW-Authenticate", "Bearer")
+	}
+	if err != nil {
+		problem.AbortWithError(c, err)
+		return
+	}
+	c.Set(actorContextKey, actor)
+	c.Next()
+}
+
+// storedKeyActor authenticates apiKey among the keys created through the API.
+// Keys of a workspace removed from the configuration no longer authenticate.
+func (u *URLShortenerHandler) storedKeyActor(c *gin.Context, apiKey string) (domain.Actor, error) {
+	id, key, err := u.keyService.Find(c, domain.HashAPIKey(apiKey))
+	if err != nil {
+		return domain.Actor{}, err
+	}
+	workspace, ok := u.workspaces.Get(id)
+	if !ok {
+		return domain.Actor{}, domain.ErrUnauthorized
+	}
+	return domain.Actor{Workspace: workspace, Key: *key}, nil
+}
+
+// actorOf returns the workspace and API key of the request, the zero Actor
+// when the server has no workspaces.
+func actorOf(c *gin.Context) domain.Actor {
+	actor, _ := c.Value(actorContextKey).(domain.Actor)
+	return actor
+}
+
+// workspaceOf returns the workspace of the request, the zero Workspace when
+// the server has none.
+func workspaceOf(c *gin.Context) domain.Workspace {
+	return actorOf(c).Workspace
+}
+
+// ownWorkspace returns the workspace of the request when it is the one named
+// by the id route parameter. Otherwise it answers 404, so that callers cannot
+// tell which other workspaces exist.
+func ownWorkspace(c *gin.Context) (domain.Workspace, bool) {
+	workspace := workspaceOf(c)
+	if workspace.ID == "" || workspace.ID != c.Param("id") {
+		problem.AbortWithError(c, domain.ErrWorkspaceNotFound)
+		return domain.Workspace{}, false
+	}
+	return workspace, true
+}
+
+// managedKey is the storage key of code on shortDomain in the workspace of
+// the request, so that management routes only reach the links of the
+// caller.
+func managedKey(c *gin.Context, shortDomain domain.ShortDomain, code string) string {
+	return domain.WorkspaceKey(workspaceOf(c).ID, domain.LinkKey(shortDomain.Namespace, code))
+}
+
+// reserveQuota holds room for links links in the quotas of the workspace of
+// the request. It returns no reservation for requests without a workspace.
+func (u *URLShortenerHandler) reserveQuota(c *gin.Context, links int) (*domain.QuotaReservation, error) {
+	workspace := workspaceOf(c)
+	if workspace.ID == "" {
+		return nil, nil
+	}
+	return u.quotaService.Reserve(c, workspace, links)
+}
+
+// releaseQuota ends reservation once its links are saved, giving back the
+// room of the unused ones, those that could not be saved.
+func (u *URLShortenerHandler) releaseQuota(c *gin.Context, reservation *domain.QuotaReservation, unused int) {
+	if reservation == nil {
+		return
+	}
+	if err := u.quotaService.Release(c, reservation, unused); err != nil {
+		log.Error(fmt.Errorf("releasing the workspace quota --> %w", err))
+	}
+}
+
+// GetWorkspaceUsage reports how much of its quotas a workspace uses. Only
+// the API keys of the workspace can read it.
+func (u *URLShortenerHandler) GetWorkspaceUsage(c *gin.Context) {
+	workspace, ok := ownWorkspace(c)
+	if !ok || !u.authorize(c, domain.ActionRead, "", nil) {
+		return
+	}
+
+	usage, err := u.quotaService.Usage(c, workspace)
+	if err != nil {
+		log.Error(fmt.Errorf("retrieving the workspace usage --> %w", err))
+		problem.AbortWithError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
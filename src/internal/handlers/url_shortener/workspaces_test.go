@@ -0,0 +1,363 @@
+package urlshortener_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	urlshortener "github.com/dariomba/url-shortener/src/internal/handlers/url_shortener"
+	"github.com/dariomba/url-shortener/src/internal/mocks"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+// setWorkspaces configures the marketing workspace, with an admin key, an
+// editor key of the growth team and a viewer key, and returns it. It also
+// configures the untrusted interns workspace, with an editor key.
+func setWorkspaces(t *testing.T) domain.Workspace {
+	marketing := domain.Workspace{ID: "marketing", MaxLinks: 10, APIKeys: []domain.APIKey{
+		{Name: "ops", Role: domain.RoleAdmin, Hash: domain.HashAPIKey("admin-key")},
+		{Name: "ci", Role: domain.RoleEditor, Team: "growth", Hash: domain.HashAPIKey("editor-key")},
+		{Name: "dashboard", Role: domain.RoleViewer, Hash: domain.HashAPIKey("viewer-key")},
+	}}
+	interns := domain.Workspace{ID: "interns", Trust: domain.TrustUntrusted, APIKeys: []domain.APIKey{
+		{Name: "intern", Role: domain.RoleEditor, Hash: domain.HashAPIKey("intern-key")},
+	}}
+	data, err := json.Marshal([]domain.Workspace{marketing, interns})
+	assert.NoError(t, err)
+	workspaces := filepath.Join(t.TempDir(), "workspaces.json")
+	err = os.WriteFile(workspaces, data, 0o600)
+	assert.NoError(t, err)
+	t.Setenv("WORKSPACES", workspaces)
+	t.Setenv("HOST", "http://localhost/")
+	return marketing
+}
+
+// auditEvent matches an audit event regardless of its time.
+type auditEvent domain.AuditEvent
+
+func (e auditEvent) Matches(x interface{}) bool {
+	event, ok := x.(domain.AuditEvent)
+	if !ok {
+		return false
+	}
+	event.Time = time.Time{}
+	return reflect.DeepEqual(event, domain.AuditEvent(e))
+}
+
+func (e auditEvent) String() string {
+	return fmt.Sprintf("is audit event %+v", domain.AuditEvent(e))
+}
+
+func TestWorkspaces(t *testing.T) {
+	marketing := setWorkspaces(t)
+	templates := filepath.Join(t.TempDir(), "utm_templates.json")
+	err := os.WriteFile(templates, []byte(`{"growth": {"required": ["campaign"]}}`), 0o600)
+	assert.NoError(t, err)
+	t.Setenv("UTM_TEMPLATES", templates)
+	reservation := &domain.QuotaReservation{Workspace: "marketing", ID: "r1", Links: 1}
+
+	type want struct {
+		statusCode int
+		body       string
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		requestBody string
+		apiKey      string
+		want        want
+		mocks       func(m mocksShortenerHandler)
+	}{
+		{
+			name:        "WhenAPIKeyIsMissing_ThenReturnsUnauthorized",
+			method:      "POST",
+			path:        "/api/v1/createLink",
+			requestBody: `{"url": "http://example.com"}`,
+			want: want{statusCode: http.StatusUnauthorized, body: problemJSON(problem.Unauthorized,
+				domain.ErrUnauthorized.Error(), "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenAPIKeyIsUnknown_ThenReturnsUnauthorized",
+			method:      "POST",
+			path:        "/api/v1/createLink",
+			requestBody: `{"url": "http://example.com"}`,
+			apiKey:      "sales-key",
+			want: want{statusCode: http.StatusUnauthorized, body: problemJSON(problem.Unauthorized,
+				domain.ErrUnauthorized.Error(), "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.keyService.EXPECT().Find(gomock.Any(), domain.HashAPIKey("sales-key")).Return("", nil, domain.ErrUnauthorized)
+			},
+		},
+		{
+			name:   "WhenAPIKeyWasCreatedThroughTheAPI_ThenAuthenticatesIt",
+			method: "GET",
+			path:   "/api/v1/workspaces/marketing/usage",
+			apiKey: "stored-key",
+			want: want{statusCode: http.StatusOK,
+				body: `{"workspace": "marketing", "links": 4, "max_links": 10, "creations_this_hour": 2}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.keyService.EXPECT().Find(gomock.Any(), domain.HashAPIKey("stored-key")).
+					Return("marketing", &domain.APIKey{Name: "report", Role: domain.RoleViewer}, nil)
+				m.quotaService.EXPECT().Usage(gomock.Any(), marketing).
+					Return(&domain.WorkspaceUsage{Workspace: "marketing", Links: 4, MaxLinks: 10, CreationsThisHour: 2}, nil)
+			},
+		},
+		{
+			name:        "WhenAPIKeyBelongsToAWorkspace_ThenCreatesTheLinkInItForTheKeyAndItsTeam",
+			method:      "POST",
+			path:        "/api/v1/createLink",
+			requestBody: `{"url": "http://example.com"}`,
+			apiKey:      "editor-key",
+			want: want{statusCode: http.StatusOK,
+				body: `{"message": "short url created successfully!", "url": "http://localhost/someLink"}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink(gomock.Not("http://example.com")).Return("someLink", nil)
+				m.quotaService.EXPECT().Reserve(gomock.Any(), marketing, 1).Return(reservation, nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "someLink", OriginalURL: "http://example.com",
+					Workspace: "marketing", CreatedBy: "ci", Team: "growth"}).Return(nil)
+				m.quotaService.EXPECT().Release(gomock.Any(), reservation, 0).Return(nil)
+			},
+		},
+		{
+			name:        "WhenUTMBreaksTheTemplateOfTheTeamOfTheKey_ThenReturnsBadRequest",
+			method:      "POST",
+			path:        "/api/v1/createLink",
+			requestBody: `{"url": "http://example.com", "utm": {"source": "newsletter"}}`,
+			apiKey:      "editor-key",
+			want: want{statusCode: http.StatusBadRequest, body: problemJSON(problem.InvalidRequest,
+				"invalid utm parameters: campaign is required", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+		{
+			name:        "WhenTheWorkspaceIsUntrusted_ThenCreatesTheLinkForAnUntrustedCreator",
+			method:      "POST",
+			path:        "/api/v1/createLink",
+			requestBody: `{"url": "http://example.com"}`,
+			apiKey:      "intern-key",
+			want: want{statusCode: http.StatusOK,
+				body: `{"message": "short url created successfully!", "url": "http://localhost/someLink"}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink(gomock.Not("http://example.com")).Return("someLink", nil)
+				m.quotaService.EXPECT().Reserve(gomock.Any(), gomock.Any(), 1).Return(reservation, nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), domain.Link{Code: "someLink", OriginalURL: "http://example.com",
+					Workspace: "interns", CreatedBy: "intern", CreatorTrust: domain.TrustUntrusted}).Return(nil)
+				m.quotaService.EXPECT().Release(gomock.Any(), reservation, 0).Return(nil)
+			},
+		},
+		{
+			name:        "WhenTheLinkCannotBeSaved_ThenGivesTheQuotaBack",
+			method:      "POST",
+			path:        "/api/v1/createLink",
+			requestBody: `{"url": "http://example.com"}`,
+			apiKey:      "editor-key",
+			want: want{statusCode: http.StatusServiceUnavailable, body: problemJSON(problem.StorageUnavailable,
+				"", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink(gomock.Any()).Return("someLink", nil)
+				m.quotaService.EXPECT().Reserve(gomock.Any(), marketing, 1).Return(reservation, nil)
+				m.storageService.EXPECT().SaveLink(gomock.Any(), gomock.Any()).Return(domain.ErrStorageUnavailable)
+				m.quotaService.EXPECT().Release(gomock.Any(), reservation, 1).Return(nil)
+			},
+		},
+		{
+			name:        "WhenViewerCreatesALink_ThenReturnsForbiddenAndAuditsIt",
+			method:      "POST",
+			path:        "/api/v1/createLink",
+			requestBody: `{"url": "http://example.com"}`,
+			apiKey:      "viewer-key",
+			want: want{statusCode: http.StatusForbidden, body: problemJSON(problem.Forbidden,
+				domain.ErrForbidden.Error(), "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.auditService.EXPECT().Record(gomock.Any(), auditEvent{Workspace: "marketing", Actor: "dashboard",
+					Action: domain.ActionCreate, Outcome: domain.AuditDenied}).Return(nil)
+			},
+		},
+		{
+			name:        "WhenEditorCreatesALinkForAnotherTeam_ThenReturnsForbidden",
+			method:      "POST",
+			path:        "/api/v1/createLink",
+			requestBody: `{"url": "http://example.com", "team": "sales"}`,
+			apiKey:      "editor-key",
+			want: want{statusCode: http.StatusForbidden, body: problemJSON(problem.Forbidden,
+				domain.ErrForbidden.Error(), "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.auditService.EXPECT().Record(gomock.Any(), auditEvent{Workspace: "marketing", Actor: "ci",
+					Action: domain.ActionCreate, Outcome: domain.AuditDenied}).Return(nil)
+			},
+		},
+		{
+			name:        "WhenEditorUpdatesALinkOfAnotherTeam_ThenReturnsForbiddenAndAuditsIt",
+			method:      "PUT",
+			path:        "/api/v1/links/someLink/variants",
+			requestBody: `{"variants": [{"name": "a", "url": "https://example.com/a", "weight": 1}, {"name": "b", "url": "https://example.com/b", "weight": 1}]}`,
+			apiKey:      "editor-key",
+			want: want{statusCode: http.StatusForbidden, body: problemJSON(problem.Forbidden,
+				domain.ErrForbidden.Error(), "/api/v1/links/someLink/variants")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "ws:marketing:someLink").Return(&domain.Link{Code: "someLink",
+					Workspace: "marketing", CreatedBy: "sam", Team: "sales"}, nil)
+				m.auditService.EXPECT().Record(gomock.Any(), auditEvent{Workspace: "marketing", Actor: "ci",
+					Action: domain.ActionUpdate, Target: "ws:marketing:someLink", Outcome: domain.AuditDenied}).Return(nil)
+			},
+		},
+		{
+			name:   "WhenEditorDeletesALinkOfItsTeam_ThenDeletesIt",
+			method: "DELETE",
+			path:   "/api/v1/links/someLink",
+			apiKey: "editor-key",
+			want:   want{statusCode: http.StatusNoContent},
+			mocks: func(m mocksShortenerHandler) {
+				link := &domain.Link{Code: "someLink", Workspace: "marketing", CreatedBy: "sam", Team: "growth"}
+				m.storageService.EXPECT().GetLink(gomock.Any(), "ws:marketing:someLink").Return(link, nil)
+				m.storageService.EXPECT().DeleteLink(gomock.Any(), *link).Return(nil)
+			},
+		},
+		{
+			name:   "WhenEditorDisablesItsOwnLink_ThenDisablesItAndAuditsTheChange",
+			method: "POST",
+			path:   "/api/v1/links/someLink/disable",
+			apiKey: "editor-key",
+			want:   want{statusCode: http.StatusOK, body: `{"code": "someLink", "disabled": true}`},
+			mocks: func(m mocksShortenerHandler) {
+				link := domain.Link{Code: "someLink", OriginalURL: "http://example.com", Workspace: "marketing", CreatedBy: "ci"}
+				m.storageService.EXPECT().GetLink(gomock.Any(), "ws:marketing:someLink").Return(&link, nil)
+				disabled := link
+				disabled.Disabled = true
+				m.storageService.EXPECT().UpdateLink(gomock.Any(), disabled, link).Return(nil)
+				m.auditService.EXPECT().Record(gomock.Any(), auditEvent{Workspace: "marketing", Actor: "ci",
+					Action: domain.ActionDisable, Target: "ws:marketing:someLink", Outcome: domain.AuditSucceeded,
+					Changes: map[string]domain.Change{"disabled": {After: json.RawMessage("true")}}}).Return(nil)
+			},
+		},
+		{
+			name:   "WhenAdminDeletesAnyLink_ThenDeletesIt",
+			method: "DELETE",
+			path:   "/api/v1/links/someLink",
+			apiKey: "admin-key",
+			want:   want{statusCode: http.StatusNoContent},
+			mocks: func(m mocksShortenerHandler) {
+				link := &domain.Link{Code: "someLink", Workspace: "marketing", CreatedBy: "sam", Team: "sales"}
+				m.storageService.EXPECT().GetLink(gomock.Any(), "ws:marketing:someLink").Return(link, nil)
+				m.storageService.EXPECT().DeleteLink(gomock.Any(), *link).Return(nil)
+			},
+		},
+		{
+			name:        "WhenTheQuotaIsExceeded_ThenReturnsTooManyRequests",
+			method:      "POST",
+			path:        "/api/v1/createLink",
+			requestBody: `{"url": "http://example.com"}`,
+			apiKey:      "editor-key",
+			want: want{statusCode: http.StatusTooManyRequests, body: problemJSON(problem.QuotaExceeded,
+				"workspace quota exceeded: the workspace can have at most 10 links", "/api/v1/createLink")},
+			mocks: func(m mocksShortenerHandler) {
+				m.shortenerService.EXPECT().GenerateShortLink(gomock.Any()).Return("someLink", nil)
+				m.quotaService.EXPECT().Reserve(gomock.Any(), marketing, 1).
+					Return(nil, domain.Detailed(domain.ErrQuotaExceeded, "the workspace can have at most 10 links"))
+			},
+		},
+		{
+			name:   "WhenLinkIsManaged_ThenOnlyLooksInTheWorkspace",
+			method: "GET",
+			path:   "/api/v1/links/someLink/stats",
+			apiKey: "viewer-key",
+			want: want{statusCode: http.StatusNotFound,
+				body: problemJSON(problem.LinkNotFound, "link not found", "/api/v1/links/someLink/stats")},
+			mocks: func(m mocksShortenerHandler) {
+				m.storageService.EXPECT().GetLink(gomock.Any(), "ws:marketing:someLink").Return(nil, domain.ErrLinkNotFound)
+			},
+		},
+		{
+			name:   "WhenUsageOfTheOwnWorkspaceIsRequested_ThenReportsIt",
+			method: "GET",
+			path:   "/api/v1/workspaces/marketing/usage",
+			apiKey: "viewer-key",
+			want: want{statusCode: http.StatusOK,
+				body: `{"workspace": "marketing", "links": 4, "max_links": 10, "creations_this_hour": 2}`},
+			mocks: func(m mocksShortenerHandler) {
+				m.quotaService.EXPECT().Usage(gomock.Any(), marketing).
+					Return(&domain.WorkspaceUsage{Workspace: "marketing", Links: 4, MaxLinks: 10, CreationsThisHour: 2}, nil)
+			},
+		},
+		{
+			name:   "WhenUsageOfAnotherWorkspaceIsRequested_ThenReturnsNotFound",
+			method: "GET",
+			path:   "/api/v1/workspaces/sales/usage",
+			apiKey: "viewer-key",
+			want: want{statusCode: http.StatusNotFound, body: problemJSON(problem.WorkspaceNotFound,
+				domain.ErrWorkspaceNotFound.Error(), "/api/v1/workspaces/sales/usage")},
+			mocks: func(m mocksShortenerHandler) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksShortenerHandler{
+				storageService:     mocks.NewMockStorageService(ctrl),
+				shortenerService:   mocks.NewMockShortenerService(ctrl),
+				idempotencyService: mocks.NewMockIdempotencyService(ctrl),
+				qrCodeService:      mocks.NewMockQRCodeService(ctrl),
+				lockoutService:     mocks.NewMockLockoutService(ctrl),
+				analyticsService:   mocks.NewMockAnalyticsService(ctrl),
+				geoIPService:       mocks.NewMockGeoIPService(ctrl),
+				quotaService:       mocks.NewMockQuotaService(ctrl),
+				keyService:         mocks.NewMockKeyService(ctrl),
+				auditService:       mocks.NewMockAuditService(ctrl),
+				titleService:       mocks.NewMockTitleService(ctrl),
+				healthService:      mocks.NewMockHealthService(ctrl),
+			}
+
+			tt.mocks(m)
+			m.auditService.EXPECT().Record(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			m.titleService.EXPECT().Enqueue(gomock.Any(), gomock.Any()).AnyTimes()
+
+			gin.SetMode(gin.TestMode)
+			router := gin.Default()
+
+			urlshortener.NewURLShortenerHandler(router.Group("/"), m.storageService, m.shortenerService, m.idempotencyService, m.qrCodeService, m.lockoutService, m.analyticsService, m.geoIPService, m.quotaService, m.keyService, m.auditService, m.titleService, m.healthService, handlerConfig(t), reserved())
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.apiKey != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.apiKey)
+			}
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.want.statusCode, w.Code)
+			if tt.want.body != "" {
+				assert.JSONEq(t, tt.want.body, w.Body.String())
+			}
+			if tt.want.statusCode == http.StatusUnauthorized {
+				assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestConfigFromEnvWhenWorkspacesAreInvalid_ThenReturnsAnError(t *testing.T) {
+	workspaces := filepath.Join(t.TempDir(), "workspaces.json")
+	err := os.WriteFile(workspaces, []byte(`[{"id": "Marketing Team"}]`), 0o600)
+	assert.NoError(t, err)
+	t.Setenv("WORKSPACES", workspaces)
+
+	_, err = urlshortener.ConfigFromEnv()
+	assert.ErrorIs(t, err, domain.ErrInvalidWorkspaces)
+}
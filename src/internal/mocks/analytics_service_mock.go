@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./analytics_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/dariomba/url-shortener/src/internal/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAnalyticsService is a mock of AnalyticsService interface.
+type MockAnalyticsService struct {
+	ctrl     *gomock.Controller
+	recorder *MockAnalyticsServiceMockRecorder
+}
+
+// MockAnalyticsServiceMockRecorder is the mock recorder for MockAnalyticsService.
+type MockAnalyticsServiceMockRecorder struct {
+	mock *MockAnalyticsService
+}
+
+// NewMockAnalyticsService creates a new mock instance.
+func NewMockAnalyticsService(ctrl *gomock.Controller) *MockAnalyticsService {
+	mock := &MockAnalyticsService{ctrl: ctrl}
+	mock.recorder = &MockAnalyticsServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAnalyticsService) EXPECT() *MockAnalyticsServiceMockRecorder {
+	return m.recorder
+}
+
+// Clicks mocks base method.
+func (m *MockAnalyticsService) Clicks(ctx context.Context, code string) (*domain.ClickStats, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Clicks", ctx, code)
+	ret0, _ := ret[0].(*domain.ClickStats)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Clicks indicates an expected call of Clicks.
+func (mr *MockAnalyticsServiceMockRecorder) Clicks(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Clicks", reflect.TypeOf((*MockAnalyticsService)(nil).Clicks), ctx, code)
+}
+
+// RecordClick mocks base method.
+func (m *MockAnalyticsService) RecordClick(ctx context.Context, code string, click domain.Click) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordClick", ctx, code, click)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordClick indicates an expected call of RecordClick.
+func (mr *MockAnalyticsServiceMockRecorder) RecordClick(ctx, code, click interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordClick", reflect.TypeOf((*MockAnalyticsService)(nil).RecordClick), ctx, code, click)
+}
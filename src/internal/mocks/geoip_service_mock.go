@@ -0,0 +1,48 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./geoip_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockGeoIPService is a mock of GeoIPService interface.
+type MockGeoIPService struct {
+	ctrl     *gomock.Controller
+	recorder *MockGeoIPServiceMockRecorder
+}
+
+// MockGeoIPServiceMockRecorder is the mock recorder for MockGeoIPService.
+type MockGeoIPServiceMockRecorder struct {
+	mock *MockGeoIPService
+}
+
+// NewMockGeoIPService creates a new mock instance.
+func NewMockGeoIPService(ctrl *gomock.Controller) *MockGeoIPService {
+	mock := &MockGeoIPService{ctrl: ctrl}
+	mock.recorder = &MockGeoIPServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockGeoIPService) EXPECT() *MockGeoIPServiceMockRecorder {
+	return m.recorder
+}
+
+// Country mocks base method.
+func (m *MockGeoIPService) Country(ip string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Country", ip)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Country indicates an expected call of Country.
+func (mr *MockGeoIPServiceMockRecorder) Country(ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Country", reflect.TypeOf((*MockGeoIPService)(nil).Country), ip)
+}
@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./health_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/dariomba/url-shortener/src/internal/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockHealthService is a mock of HealthService interface.
+type MockHealthService struct {
+	ctrl     *gomock.Controller
+	recorder *MockHealthServiceMockRecorder
+}
+
+// MockHealthServiceMockRecorder is the mock recorder for MockHealthService.
+type MockHealthServiceMockRecorder struct {
+	mock *MockHealthService
+}
+
+// NewMockHealthService creates a new mock instance.
+func NewMockHealthService(ctrl *gomock.Controller) *MockHealthService {
+	mock := &MockHealthService{ctrl: ctrl}
+	mock.recorder = &MockHealthServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockHealthService) EXPECT() *MockHealthServiceMockRecorder {
+	return m.recorder
+}
+
+// BrokenLinks mocks base method.
+func (m *MockHealthService) BrokenLinks(ctx context.Context, workspace string, limit int) ([]domain.BrokenLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BrokenLinks", ctx, workspace, limit)
+	ret0, _ := ret[0].([]domain.BrokenLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BrokenLinks indicates an expected call of BrokenLinks.
+func (mr *MockHealthServiceMockRecorder) BrokenLinks(ctx, workspace, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BrokenLinks", reflect.TypeOf((*MockHealthService)(nil).BrokenLinks), ctx, workspace, limit)
+}
+
+// Health mocks base method.
+func (m *MockHealthService) Health(ctx context.Context, key string) (*domain.LinkHealth, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Health", ctx, key)
+	ret0, _ := ret[0].(*domain.LinkHealth)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Health indicates an expected call of Health.
+func (mr *MockHealthServiceMockRecorder) Health(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Health", reflect.TypeOf((*MockHealthService)(nil).Health), ctx, key)
+}
@@ -0,0 +1,95 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./key_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/dariomba/url-shortener/src/internal/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockKeyService is a mock of KeyService interface.
+type MockKeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyServiceMockRecorder
+}
+
+// MockKeyServiceMockRecorder is the mock recorder for MockKeyService.
+type MockKeyServiceMockRecorder struct {
+	mock *MockKeyService
+}
+
+// NewMockKeyService creates a new mock instance.
+func NewMockKeyService(ctrl *gomock.Controller) *MockKeyService {
+	mock := &MockKeyService{ctrl: ctrl}
+	mock.recorder = &MockKeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockKeyService) EXPECT() *MockKeyServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockKeyService) Create(ctx context.Context, workspace string, key domain.APIKey) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, workspace, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockKeyServiceMockRecorder) Create(ctx, workspace, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockKeyService)(nil).Create), ctx, workspace, key)
+}
+
+// Find mocks base method.
+func (m *MockKeyService) Find(ctx context.Context, hash string) (string, *domain.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Find", ctx, hash)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(*domain.APIKey)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Find indicates an expected call of Find.
+func (mr *MockKeyServiceMockRecorder) Find(ctx, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Find", reflect.TypeOf((*MockKeyService)(nil).Find), ctx, hash)
+}
+
+// List mocks base method.
+func (m *MockKeyService) List(ctx context.Context, workspace string) ([]domain.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, workspace)
+	ret0, _ := ret[0].([]domain.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List.
+func (mr *MockKeyServiceMockRecorder) List(ctx, workspace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockKeyService)(nil).List), ctx, workspace)
+}
+
+// Revoke mocks base method.
+func (m *MockKeyService) Revoke(ctx context.Context, workspace, name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", ctx, workspace, name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Revoke indicates an expected call of Revoke.
+func (mr *MockKeyServiceMockRecorder) Revoke(ctx, workspace, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockKeyService)(nil).Revoke), ctx, workspace, name)
+}
@@ -0,0 +1,50 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./qr_code_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	domain "github.com/dariomba/url-shortener/src/internal/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockQRCodeService is a mock of QRCodeService interface.
+type MockQRCodeService struct {
+	ctrl     *gomock.Controller
+	recorder *MockQRCodeServiceMockRecorder
+}
+
+// MockQRCodeServiceMockRecorder is the mock recorder for MockQRCodeService.
+type MockQRCodeServiceMockRecorder struct {
+	mock *MockQRCodeService
+}
+
+// NewMockQRCodeService creates a new mock instance.
+func NewMockQRCodeService(ctrl *gomock.Controller) *MockQRCodeService {
+	mock := &MockQRCodeService{ctrl: ctrl}
+	mock.recorder = &MockQRCodeServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQRCodeService) EXPECT() *MockQRCodeServiceMockRecorder {
+	return m.recorder
+}
+
+// Render mocks base method.
+func (m *MockQRCodeService) Render(content string, options domain.QROptions) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Render", content, options)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Render indicates an expected call of Render.
+func (mr *MockQRCodeServiceMockRecorder) Render(content, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Render", reflect.TypeOf((*MockQRCodeService)(nil).Render), content, options)
+}
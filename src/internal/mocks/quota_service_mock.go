@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./quota_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/dariomba/url-shortener/src/internal/domain"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockQuotaService is a mock of QuotaService interface.
+type MockQuotaService struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuotaServiceMockRecorder
+}
+
+// MockQuotaServiceMockRecorder is the mock recorder for MockQuotaService.
+type MockQuotaServiceMockRecorder struct {
+	mock *MockQuotaService
+}
+
+// NewMockQuotaService creates a new mock instance.
+func NewMockQuotaService(ctrl *gomock.Controller) *MockQuotaService {
+	mock := &MockQuotaService{ctrl: ctrl}
+	mock.recorder = &MockQuotaServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockQuotaService) EXPECT() *MockQuotaServiceMockRecorder {
+	return m.recorder
+}
+
+// Release mocks base method.
+func (m *MockQuotaService) Release(ctx context.Context, reservation *domain.QuotaReservation, unused int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, reservation, unused)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockQuotaServiceMockRecorder) Release(ctx, reservation, unused interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockQuotaService)(nil).Release), ctx, reservation, unused)
+}
+
+// Reserve mocks base method.
+func (m *MockQuotaService) Reserve(ctx context.Context, workspace domain.Workspace, links int) (*domain.QuotaReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", ctx, workspace, links)
+	ret0, _ := ret[0].(*domain.QuotaReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve.
+func (mr *MockQuotaServiceMockRecorder) Reserve(ctx, workspace, links interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockQuotaService)(nil).Reserve), ctx, workspace, links)
+}
+
+// Usage mocks base method.
+func (m *MockQuotaService) Usage(ctx context.Context, workspace domain.Workspace) (*domain.WorkspaceUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Usage", ctx, workspace)
+	ret0, _ := ret[0].(*domain.WorkspaceUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Usage indicates an expected call of Usage.
+func (mr *MockQuotaServiceMockRecorder) Usage(ctx, workspace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Usage", reflect.TypeOf((*MockQuotaService)(nil).Usage), ctx, workspace)
+}
@@ -8,6 +8,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	domain "github.com/dariomba/url-shortener/src/internal/domain"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -34,6 +35,95 @@ func (m *MockStorageService) EXPECT() *MockStorageServiceMockRecorder {
 	return m.recorder
 }
 
+// CampaignLinks mocks base method.
+func (m *MockStorageService) CampaignLinks(ctx context.Context, workspace, campaign string) ([]domain.ListedLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CampaignLinks", ctx, workspace, campaign)
+	ret0, _ := ret[0].([]domain.ListedLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CampaignLinks indicates an expected call of CampaignLinks.
+func (mr *MockStorageServiceMockRecorder) CampaignLinks(ctx, workspace, campaign interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CampaignLinks", reflect.TypeOf((*MockStorageService)(nil).CampaignLinks), ctx, workspace, campaign)
+}
+
+// ConsumeClick mocks base method.
+func (m *MockStorageService) ConsumeClick(ctx context.Context, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeClick", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConsumeClick indicates an expected call of ConsumeClick.
+func (mr *MockStorageServiceMockRecorder) ConsumeClick(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeClick", reflect.TypeOf((*MockStorageService)(nil).ConsumeClick), ctx, key)
+}
+
+// DeleteLink mocks base method.
+func (m *MockStorageService) DeleteLink(ctx context.Context, link domain.Link) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLink", ctx, link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLink indicates an expected call of DeleteLink.
+func (mr *MockStorageServiceMockRecorder) DeleteLink(ctx, link interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLink", reflect.TypeOf((*MockStorageService)(nil).DeleteLink), ctx, link)
+}
+
+// ExpiredLink mocks base method.
+func (m *MockStorageService) ExpiredLink(ctx context.Context, address string) (*domain.Link, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpiredLink", ctx, address)
+	ret0, _ := ret[0].(*domain.Link)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpiredLink indicates an expected call of ExpiredLink.
+func (mr *MockStorageServiceMockRecorder) ExpiredLink(ctx, address interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpiredLink", reflect.TypeOf((*MockStorageService)(nil).ExpiredLink), ctx, address)
+}
+
+// FindCodes mocks base method.
+func (m *MockStorageService) FindCodes(ctx context.Context, namespace, code string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindCodes", ctx, namespace, code)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindCodes indicates an expected call of FindCodes.
+func (mr *MockStorageServiceMockRecorder) FindCodes(ctx, namespace, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCodes", reflect.TypeOf((*MockStorageService)(nil).FindCodes), ctx, namespace, code)
+}
+
+// GetLink mocks base method.
+func (m *MockStorageService) GetLink(ctx context.Context, key string) (*domain.Link, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLink", ctx, key)
+	ret0, _ := ret[0].(*domain.Link)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLink indicates an expected call of GetLink.
+func (mr *MockStorageServiceMockRecorder) GetLink(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLink", reflect.TypeOf((*MockStorageService)(nil).GetLink), ctx, key)
+}
+
 // GetURL mocks base method.
 func (m *MockStorageService) GetURL(ctx context.Context, shortURL string) (string, error) {
 	m.ctrl.T.Helper()
@@ -49,16 +139,117 @@ func (mr *MockStorageServiceMockRecorder) GetURL(ctx, shortURL interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURL", reflect.TypeOf((*MockStorageService)(nil).GetURL), ctx, shortURL)
 }
 
-// SaveURL mocks base method.
-func (m *MockStorageService) SaveURL(ctx context.Context, shortURL, originalURL string) error {
+// LinkVersions mocks base method.
+func (m *MockStorageService) LinkVersions(ctx context.Context, link domain.Link) ([]domain.LinkVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkVersions", ctx, link)
+	ret0, _ := ret[0].([]domain.LinkVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LinkVersions indicates an expected call of LinkVersions.
+func (mr *MockStorageServiceMockRecorder) LinkVersions(ctx, link interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkVersions", reflect.TypeOf((*MockStorageService)(nil).LinkVersions), ctx, link)
+}
+
+// ListLinks mocks base method.
+func (m *MockStorageService) ListLinks(ctx context.Context, query domain.LinkQuery) (*domain.LinkPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListLinks", ctx, query)
+	ret0, _ := ret[0].(*domain.LinkPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListLinks indicates an expected call of ListLinks.
+func (mr *MockStorageServiceMockRecorder) ListLinks(ctx, query interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListLinks", reflect.TypeOf((*MockStorageService)(nil).ListLinks), ctx, query)
+}
+
+// RemainingClicks mocks base method.
+func (m *MockStorageService) RemainingClicks(ctx context.Context, key string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemainingClicks", ctx, key)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemainingClicks indicates an expected call of RemainingClicks.
+func (mr *MockStorageServiceMockRecorder) RemainingClicks(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemainingClicks", reflect.TypeOf((*MockStorageService)(nil).RemainingClicks), ctx, key)
+}
+
+// ReviseLink mocks base method.
+func (m *MockStorageService) ReviseLink(ctx context.Context, link domain.Link, previous domain.LinkVersion) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReviseLink", ctx, link, previous)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReviseLink indicates an expected call of ReviseLink.
+func (mr *MockStorageServiceMockRecorder) ReviseLink(ctx, link, previous interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReviseLink", reflect.TypeOf((*MockStorageService)(nil).ReviseLink), ctx, link, previous)
+}
+
+// SaveLink mocks base method.
+func (m *MockStorageService) SaveLink(ctx context.Context, link domain.Link) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveLink", ctx, link)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveLink indicates an expected call of SaveLink.
+func (mr *MockStorageServiceMockRecorder) SaveLink(ctx, link interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveLink", reflect.TypeOf((*MockStorageService)(nil).SaveLink), ctx, link)
+}
+
+// SaveURLs mocks base method.
+func (m *MockStorageService) SaveURLs(ctx context.Context, links []domain.Link) []error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveURLs", ctx, links)
+	ret0, _ := ret[0].([]error)
+	return ret0
+}
+
+// SaveURLs indicates an expected call of SaveURLs.
+func (mr *MockStorageServiceMockRecorder) SaveURLs(ctx, links interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveURLs", reflect.TypeOf((*MockStorageService)(nil).SaveURLs), ctx, links)
+}
+
+// SetLinkTitle mocks base method.
+func (m *MockStorageService) SetLinkTitle(ctx context.Context, key, url, title string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLinkTitle", ctx, key, url, title)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLinkTitle indicates an expected call of SetLinkTitle.
+func (mr *MockStorageServiceMockRecorder) SetLinkTitle(ctx, key, url, title interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLinkTitle", reflect.TypeOf((*MockStorageService)(nil).SetLinkTitle), ctx, key, url, title)
+}
+
+// UpdateLink mocks base method.
+func (m *MockStorageService) UpdateLink(ctx context.Context, link, previous domain.Link) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveURL", ctx, shortURL, originalURL)
+	ret := m.ctrl.Call(m, "UpdateLink", ctx, link, previous)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SaveURL indicates an expected call of SaveURL.
-func (mr *MockStorageServiceMockRecorder) SaveURL(ctx, shortURL, originalURL interface{}) *gomock.Call {
+// UpdateLink indicates an expected call of UpdateLink.
+func (mr *MockStorageServiceMockRecorder) UpdateLink(ctx, link, previous interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveURL", reflect.TypeOf((*MockStorageService)(nil).SaveURL), ctx, shortURL, originalURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLink", reflect.TypeOf((*MockStorageService)(nil).UpdateLink), ctx, link, previous)
 }
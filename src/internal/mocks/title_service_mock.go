@@ -0,0 +1,46 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: ./title_service.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockTitleService is a mock of TitleService interface.
+type MockTitleService struct {
+	ctrl     *gomock.Controller
+	recorder *MockTitleServiceMockRecorder
+}
+
+// MockTitleServiceMockRecorder is the mock recorder for MockTitleService.
+type MockTitleServiceMockRecorder struct {
+	mock *MockTitleService
+}
+
+// NewMockTitleService creates a new mock instance.
+func NewMockTitleService(ctrl *gomock.Controller) *MockTitleService {
+	mock := &MockTitleService{ctrl: ctrl}
+	mock.recorder = &MockTitleServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockTitleService) EXPECT() *MockTitleServiceMockRecorder {
+	return m.recorder
+}
+
+// Enqueue mocks base method.
+func (m *MockTitleService) Enqueue(key, url string) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Enqueue", key, url)
+}
+
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockTitleServiceMockRecorder) Enqueue(key, url interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockTitleService)(nil).Enqueue), key, url)
+}
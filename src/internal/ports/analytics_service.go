@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+)
+
+//go:generate mockgen -source=./analytics_service.go -destination=../mocks/analytics_service_mock.go -package=mocks
+type AnalyticsService interface {
+	RecordClick(ctx context.Context, code string, click domain.Click) error
+	Clicks(ctx context.Context, code string) (*domain.ClickStats, error)
+}
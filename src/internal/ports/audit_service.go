@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+)
+
+//go:generate mockgen -source=./audit_service.go -destination=../mocks/audit_service_mock.go -package=mocks
+type AuditService interface {
+	Record(ctx context.Context, event domain.AuditEvent) error
+	Query(ctx context.Context, query domain.AuditQuery) (*domain.AuditPage, error)
+}
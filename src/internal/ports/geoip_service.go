@@ -0,0 +1,6 @@
+package ports
+
+//go:generate mockgen -source=./geoip_service.go -destination=../mocks/geoip_service_mock.go -package=mocks
+type GeoIPService interface {
+	Country(ip string) string
+}
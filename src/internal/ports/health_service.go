@@ -0,0 +1,13 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+)
+
+//go:generate mockgen -source=./health_service.go -destination=../mocks/health_service_mock.go -package=mocks
+type HealthService interface {
+	Health(ctx context.Context, key string) (*domain.LinkHealth, error)
+	BrokenLinks(ctx context.Context, workspace string, limit int) ([]domain.BrokenLink, error)
+}
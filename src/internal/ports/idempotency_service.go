@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+)
+
+//go:generate mockgen -source=./idempotency_service.go -destination=../mocks/idempotency_service_mock.go -package=mocks
+type IdempotencyService interface {
+	Reserve(ctx context.Context, key string, fingerprint string) (*domain.IdempotentResponse, error)
+	Save(ctx context.Context, key string, response domain.IdempotentResponse) error
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+)
+
+//go:generate mockgen -source=./key_service.go -destination=../mocks/key_service_mock.go -package=mocks
+type KeyService interface {
+	Create(ctx context.Context, workspace string, key domain.APIKey) error
+	Find(ctx context.Context, hash string) (string, *domain.APIKey, error)
+	List(ctx context.Context, workspace string) ([]domain.APIKey, error)
+	Revoke(ctx context.Context, workspace string, name string) error
+}
@@ -0,0 +1,10 @@
+package ports
+
+import "context"
+
+//go:generate mockgen -source=./lockout_service.go -destination=../mocks/lockout_service_mock.go -package=mocks
+type LockoutService interface {
+	Reserve(ctx context.Context, keys ...string) error
+	Release(ctx context.Context, keys ...string) error
+	Reset(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,8 @@
+package ports
+
+import "github.com/dariomba/url-shortener/src/internal/domain"
+
+//go:generate mockgen -source=./qr_code_service.go -destination=../mocks/qr_code_service_mock.go -package=mocks
+type QRCodeService interface {
+	Render(content string, options domain.QROptions) ([]byte, error)
+}
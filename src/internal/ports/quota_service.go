@@ -0,0 +1,14 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+)
+
+//go:generate mockgen -source=./quota_service.go -destination=../mocks/quota_service_mock.go -package=mocks
+type QuotaService interface {
+	Reserve(ctx context.Context, workspace domain.Workspace, links int) (*domain.QuotaReservation, error)
+	Release(ctx context.Context, reservation *domain.QuotaReservation, unused int) error
+	Usage(ctx context.Context, workspace domain.Workspace) (*domain.WorkspaceUsage, error)
+}
@@ -1,9 +1,26 @@
 package ports
 
-import "context"
+import (
+	"context"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+)
 
 //go:generate mockgen -source=./storage_service.go -destination=../mocks/storage_service_mock.go -package=mocks
 type StorageService interface {
-	SaveURL(ctx context.Context, shortURL string, originalURL string) error
+	SaveLink(ctx context.Context, link domain.Link) error
+	SaveURLs(ctx context.Context, links []domain.Link) []error
+	UpdateLink(ctx context.Context, link domain.Link, previous domain.Link) error
+	ReviseLink(ctx context.Context, link domain.Link, previous domain.LinkVersion) error
+	SetLinkTitle(ctx context.Context, key string, url string, title string) error
+	LinkVersions(ctx context.Context, link domain.Link) ([]domain.LinkVersion, error)
+	DeleteLink(ctx context.Context, link domain.Link) error
+	GetLink(ctx context.Context, key string) (*domain.Link, error)
+	ListLinks(ctx context.Context, query domain.LinkQuery) (*domain.LinkPage, error)
+	CampaignLinks(ctx context.Context, workspace string, campaign string) ([]domain.ListedLink, error)
+	ExpiredLink(ctx context.Context, address string) (*domain.Link, error)
 	GetURL(ctx context.Context, shortURL string) (string, error)
+	FindCodes(ctx context.Context, namespace string, code string) ([]string, error)
+	ConsumeClick(ctx context.Context, key string) (int64, error)
+	RemainingClicks(ctx context.Context, key string) (int64, error)
 }
@@ -0,0 +1,6 @@
+package ports
+
+//go:generate mockgen -source=./title_service.go -destination=../mocks/title_service_mock.go -package=mocks
+type TitleService interface {
+	Enqueue(key string, url string)
+}
@@ -0,0 +1,165 @@
+package problem
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ContentType = "application/problem+json"
+	// typeBaseURI points at docs/problems.md, where every type is documented
+	// under a heading matching its slug.
+	typeBaseURI = "https://github.com/dariomba/url-shortener/blob/main/docs/problems.md#"
+)
+
+// Type is a documented kind of failure. Its URI is stable, so clients can
+// branch on it instead of matching error messages.
+type Type struct {
+	Slug   string
+	Title  string
+	Status int
+}
+
+func (t Type) URI() string {
+	return typeBaseURI + t.Slug
+}
+
+var (
+	InvalidRequest           = Type{"invalid-request", "Invalid request", http.StatusBadRequest}
+	Unauthorized             = Type{"unauthorized", "Unauthorized", http.StatusUnauthorized}
+	Forbidden                = Type{"forbidden", "Forbidden", http.StatusForbidden}
+	LinkNotFound             = Type{"link-not-found", "Link not found", http.StatusNotFound}
+	LinkInactive             = Type{"link-inactive", "Link not active", http.StatusNotFound}
+	LinkExhausted            = Type{"link-exhausted", "Link exhausted", http.StatusGone}
+	LinkDisabled             = Type{"link-disabled", "Link disabled", http.StatusNotFound}
+	WorkspaceNotFound        = Type{"workspace-not-found", "Workspace not found", http.StatusNotFound}
+	KeyNotFound              = Type{"key-not-found", "API key not found", http.StatusNotFound}
+	VersionNotFound          = Type{"version-not-found", "Version not found", http.StatusNotFound}
+	AliasTaken               = Type{"alias-taken", "Alias already in use", http.StatusConflict}
+	KeyNameTaken             = Type{"key-name-taken", "API key name already in use", http.StatusConflict}
+	LinkChanged              = Type{"link-changed", "Link changed", http.StatusConflict}
+	LinkShared               = Type{"link-shared", "Link shared", http.StatusConflict}
+	IdempotencyKeyInProgress = Type{"idempotency-key-in-progress", "Request still in progress", http.StatusConflict}
+	BatchTooLarge            = Type{"batch-too-large", "Batch too large", http.StatusRequestEntityTooLarge}
+	BodyTooLarge             = Type{"body-too-large", "Request body too large", http.StatusRequestEntityTooLarge}
+	IdempotencyKeyReused     = Type{"idempotency-key-reused", "Idempotency key reused", http.StatusUnprocessableEntity}
+	TooManyAttempts          = Type{"too-many-attempts", "Too many attempts", http.StatusTooManyRequests}
+	QuotaExceeded            = Type{"quota-exceeded", "Quota exceeded", http.StatusTooManyRequests}
+	InternalError            = Type{"internal-error", "Internal error", http.StatusInternalServerError}
+	CodeGenerationFailed     = Type{"code-generation-failed", "Short code could not be generated", http.StatusInternalServerError}
+	StorageUnavailable       = Type{"storage-unavailable", "Storage unavailable", http.StatusServiceUnavailable}
+)
+
+// Problem is an RFC 7807 problem details object. It implements error so it
+// can travel through the usual error paths before being rendered.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func New(problemType Type, detail string) *Problem {
+	return &Problem{
+		Type:   problemType.URI(),
+		Title:  problemType.Title,
+		Status: problemType.Status,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// FromError maps the domain errors returned by the services to their problem
+// type. The detail is the message of the domain error, or the explanation of
+// a domain.DetailedError, never the context wrapped around them. Errors
+// without a documented type become an internal error whose detail does not
+// leak the cause.
+func FromError(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	mapped := []struct {
+		err         error
+		problemType Type
+	}{
+		{domain.ErrLinkNotFound, LinkNotFound},
+		{domain.ErrLinkInactive, LinkInactive},
+		{domain.ErrLinkExhausted, LinkExhausted},
+		{domain.ErrLinkDisabled, LinkDisabled},
+		{domain.ErrAliasTaken, AliasTaken},
+		{domain.ErrInvalidAlias, InvalidRequest},
+		{domain.ErrReservedAlias, InvalidRequest},
+		{domain.ErrInvalidQROptions, InvalidRequest},
+		{domain.ErrInvalidPassword, InvalidRequest},
+		{domain.ErrInvalidSchedule, InvalidRequest},
+		{domain.ErrInvalidTargets, InvalidRequest},
+		{domain.ErrInvalidVariants, InvalidRequest},
+		{domain.ErrInvalidPassthrough, InvalidRequest},
+		{domain.ErrUnsafePath, InvalidRequest},
+		{domain.ErrInvalidUTM, InvalidRequest},
+		{domain.ErrUnknownDomain, InvalidRequest},
+		{domain.ErrTooManyAttempts, TooManyAttempts},
+		{domain.ErrInvalidAPIKey, InvalidRequest},
+		{domain.ErrInvalidAuditQuery, InvalidRequest},
+		{domain.ErrInvalidLinkQuery, InvalidRequest},
+		{domain.ErrInvalidGrouping, InvalidRequest},
+		{domain.ErrInvalidDescription, InvalidRequest},
+		{domain.ErrUnauthorized, Unauthorized},
+		{domain.ErrForbidden, Forbidden},
+		{domain.ErrWorkspaceNotFound, WorkspaceNotFound},
+		{domain.ErrKeyNotFound, KeyNotFound},
+		{domain.ErrKeyNameTaken, KeyNameTaken},
+		{domain.ErrVersionNotFound, VersionNotFound},
+		{domain.ErrLinkChanged, LinkChanged},
+		{domain.ErrLinkShared, LinkShared},
+		{domain.ErrQuotaExceeded, QuotaExceeded},
+		{domain.ErrIdempotencyKeyReused, IdempotencyKeyReused},
+		{domain.ErrIdempotencyKeyInProgress, IdempotencyKeyInProgress},
+		{domain.ErrCodeGeneration, CodeGenerationFailed},
+		{domain.ErrStorageUnavailable, StorageUnavailable},
+	}
+	for _, m := range mapped {
+		if errors.Is(err, m.err) {
+			// Storage and generation errors wrap low level causes that are
+			// logged but not shown to clients.
+			if m.problemType.Status >= http.StatusInternalServerError {
+				return New(m.problemType, "")
+			}
+			var detailed *domain.DetailedError
+			if errors.As(err, &detailed) && errors.Is(detailed.Err, m.err) {
+				return New(m.problemType, detailed.Error())
+			}
+			return New(m.problemType, m.err.Error())
+		}
+	}
+	return New(InternalError, "")
+}
+
+// Abort renders p as application/problem+json and stops the handler chain.
+func Abort(c *gin.Context, p *Problem) {
+	rendered := *p
+	rendered.Instance = c.Request.URL.Path
+	rendered.RequestID = requestid.FromContext(c)
+
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(rendered.Status, rendered)
+}
+
+// AbortWithError renders the problem type that err maps to.
+func AbortWithError(c *gin.Context, err error) {
+	Abort(c, FromError(err))
+}
@@ -0,0 +1,105 @@
+package problem_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/problem"
+	"github.com/dariomba/url-shortener/src/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected *problem.Problem
+	}{
+		{
+			name:     "WhenErrorIsAProblem_ThenReturnsIt",
+			err:      fmt.Errorf("wrapped --> %w", problem.New(problem.InvalidRequest, "url is required")),
+			expected: problem.New(problem.InvalidRequest, "url is required"),
+		},
+		{
+			name:     "WhenLinkIsNotFound_ThenReturnsLinkNotFoundWithDetail",
+			err:      domain.ErrLinkNotFound,
+			expected: problem.New(problem.LinkNotFound, "link not found"),
+		},
+		{
+			name:     "WhenDomainErrorIsWrapped_ThenHidesTheContext",
+			err:      fmt.Errorf("an error has occurred while saving link abc in db:4 --> %w", domain.ErrAliasTaken),
+			expected: problem.New(problem.AliasTaken, "alias is already in use"),
+		},
+		{
+			name:     "WhenDomainErrorIsDetailed_ThenReturnsTheExplanation",
+			err:      fmt.Errorf("rendering the QR code of abc --> %w", domain.Detailed(domain.ErrInvalidQROptions, "level must be one of L, M, Q or H")),
+			expected: problem.New(problem.InvalidRequest, "invalid QR code options: level must be one of L, M, Q or H"),
+		},
+		{
+			name:     "WhenDomainErrorWrapsAnInternalCause_ThenHidesTheCause",
+			err:      fmt.Errorf("%w: %w", domain.ErrInvalidQROptions, errors.New("strconv.Atoi: parsing \"x\": invalid syntax")),
+			expected: problem.New(problem.InvalidRequest, "invalid QR code options"),
+		},
+		{
+			name:     "WhenStorageFails_ThenHidesTheCause",
+			err:      fmt.Errorf("saving --> %w: dial tcp: connection refused", domain.ErrStorageUnavailable),
+			expected: problem.New(problem.StorageUnavailable, ""),
+		},
+		{
+			name:     "WhenCodeGenerationFails_ThenReturnsCodeGenerationFailed",
+			err:      fmt.Errorf("generating --> %w: bad input", domain.ErrCodeGeneration),
+			expected: problem.New(problem.CodeGenerationFailed, ""),
+		},
+		{
+			name:     "WhenTooManyPasswordsFailed_ThenReturnsTooManyAttempts",
+			err:      domain.ErrTooManyAttempts,
+			expected: problem.New(problem.TooManyAttempts, "too many failed password attempts"),
+		},
+		{
+			name:     "WhenErrorIsUnknown_ThenReturnsInternalError",
+			err:      errors.New("something odd"),
+			expected: problem.New(problem.InternalError, ""),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, problem.FromError(tt.err))
+		})
+	}
+}
+
+func TestAbort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(requestid.Middleware())
+	router.GET("/:link", func(c *gin.Context) {
+		problem.AbortWithError(c, domain.ErrLinkNotFound)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/abc", nil)
+	req.Header.Set(requestid.Header, "req-1")
+
+	router.ServeHTTP(w, req)
+
+	var body map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &body)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, problem.ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, map[string]interface{}{
+		"type":       "https://github.com/dariomba/url-shortener/blob/main/docs/problems.md#link-not-found",
+		"title":      "Link not found",
+		"status":     float64(http.StatusNotFound),
+		"detail":     "link not found",
+		"instance":   "/abc",
+		"request_id": "req-1",
+	}, body)
+}
@@ -0,0 +1,40 @@
+package requestid
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	Header     = "X-Request-ID"
+	contextKey = "request_id"
+	maxLength  = 128
+)
+
+// Middleware tags every request with an ID, reusing the X-Request-ID sent by
+// the client or a proxy when present, and echoes it in the response.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if id == "" || len(id) > maxLength {
+			id = newID()
+		}
+		c.Set(contextKey, id)
+		c.Header(Header, id)
+		c.Next()
+	}
+}
+
+// FromContext returns the ID of the request, or an empty string when the
+// middleware did not run.
+func FromContext(c *gin.Context) string {
+	return c.GetString(contextKey)
+}
+
+func newID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,56 @@
+package requestid_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		incoming   string
+		expectSame bool
+	}{
+		{
+			name:       "WhenClientSendsAnID_ThenReusesIt",
+			incoming:   "abc-123",
+			expectSame: true,
+		},
+		{
+			name:       "WhenClientSendsNoID_ThenGeneratesOne",
+			incoming:   "",
+			expectSame: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(requestid.Middleware())
+
+			var seen string
+			router.GET("/", func(c *gin.Context) {
+				seen = requestid.FromContext(c)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/", nil)
+			req.Header.Set(requestid.Header, tt.incoming)
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, seen, w.Header().Get(requestid.Header))
+			if tt.expectSame {
+				assert.Equal(t, tt.incoming, seen)
+			} else {
+				assert.Len(t, seen, 32)
+			}
+		})
+	}
+}
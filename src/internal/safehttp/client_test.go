@@ -0,0 +1,62 @@
+package safehttp_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/safehttp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		want bool
+	}{
+		{name: "WhenAddressIsPublicIPv4_ThenIsPublic", addr: "93.184.216.34", want: true},
+		{name: "WhenAddressIsPublicIPv6_ThenIsPublic", addr: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{name: "WhenAddressIsLoopback_ThenIsNot", addr: "127.0.0.1"},
+		{name: "WhenAddressIsIPv6Loopback_ThenIsNot", addr: "::1"},
+		{name: "WhenAddressIsPrivate_ThenIsNot", addr: "10.1.2.3"},
+		{name: "WhenAddressIsCloudMetadata_ThenIsNot", addr: "169.254.169.254"},
+		{name: "WhenAddressIsCarrierGradeNAT_ThenIsNot", addr: "100.64.0.1"},
+		{name: "WhenAddressIsUnspecified_ThenIsNot", addr: "0.0.0.0"},
+		{name: "WhenAddressIsPrivateMappedToIPv6_ThenIsNot", addr: "::ffff:192.168.1.1"},
+		{name: "WhenAddressIsUniqueLocalIPv6_ThenIsNot", addr: "fd00::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, safehttp.IsPublic(netip.MustParseAddr(tt.addr)))
+		})
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	for _, target := range []string{"http://example.com", "https://example.com/a?b=c"} {
+		parsed, _ := url.Parse(target)
+		assert.NoError(t, safehttp.CheckURL(parsed))
+	}
+	for _, target := range []string{"file:///etc/passwd", "gopher://example.com", "ftp://example.com"} {
+		parsed, _ := url.Parse(target)
+		assert.ErrorIs(t, safehttp.CheckURL(parsed), safehttp.ErrBlockedScheme)
+	}
+}
+
+func TestNewClientWhenServerIsLocal_ThenRefusesToConnect(t *testing.T) {
+	requested := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer server.Close()
+
+	_, err := safehttp.NewClient(time.Second).Get(server.URL)
+
+	assert.ErrorIs(t, err, safehttp.ErrBlockedAddress)
+	assert.False(t, requested)
+}
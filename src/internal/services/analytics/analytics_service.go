@@ -0,0 +1,110 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/ports"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyPrefix = "analytics:"
+
+	fieldTotal         = "total"
+	targetFieldPrefix  = "target:"
+	countryFieldPrefix = "country:"
+	variantFieldPrefix = "variant:"
+	pathFieldPrefix    = "path:"
+)
+
+// AnalyticsService counts the clicks of every link in a hash holding the total
+// and one field per dimension value, such as "target:app-store".
+type AnalyticsService struct {
+	client ports.StorageClient
+	// retention is how long the counters of a link are kept after its last
+	// click.
+	retention time.Duration
+}
+
+func NewAnalyticsService(client ports.StorageClient, retention time.Duration) *AnalyticsService {
+	return &AnalyticsService{
+		client:    client,
+		retention: retention,
+	}
+}
+
+// RecordClick adds click to the counters of the link, and to its clicks in
+// the index links are listed by, see domain.LinkIndexKey.
+func (s AnalyticsService) RecordClick(ctx context.Context, code string, click domain.Click) error {
+	key := keyPrefix + code
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, fieldTotal, 1)
+		if click.Target != "" {
+			pipe.HIncrBy(ctx, key, targetFieldPrefix+click.Target, 1)
+		}
+		country := click.Country
+		if country == "" {
+			country = domain.UnknownCountry
+		}
+		pipe.HIncrBy(ctx, key, countryFieldPrefix+country, 1)
+		if click.Variant != "" {
+			pipe.HIncrBy(ctx, key, variantFieldPrefix+click.Variant, 1)
+		}
+		path := click.Path
+		if path == "" {
+			path = domain.PathPrimary
+		}
+		pipe.HIncrBy(ctx, key, pathFieldPrefix+path, 1)
+		pipe.Expire(ctx, key, s.retention)
+		workspace, _ := domain.SplitWorkspaceKey(code)
+		pipe.ZIncrBy(ctx, domain.LinkIndexKey(workspace, domain.SortClicks), 1, code)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("an error has occurred recording the click | Code %s --> %w: %w", code, domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Clicks returns the counters of the link. Links never clicked have none.
+func (s AnalyticsService) Clicks(ctx context.Context, code string) (*domain.ClickStats, error) {
+	fields, err := s.client.HGetAll(ctx, keyPrefix+code).Result()
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred retrieving the clicks | Code %s --> %w: %w", code, domain.ErrStorageUnavailable, err)
+	}
+
+	stats := &domain.ClickStats{}
+	for field, value := range fields {
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("decoding the clicks | Code %s | Field %s --> %w", code, field, err)
+		}
+		switch {
+		case field == fieldTotal:
+			stats.Total = count
+		case strings.HasPrefix(field, targetFieldPrefix):
+			setCount(&stats.Targets, strings.TrimPrefix(field, targetFieldPrefix), count)
+		case strings.HasPrefix(field, countryFieldPrefix):
+			setCount(&stats.Countries, strings.TrimPrefix(field, countryFieldPrefix), count)
+		case strings.HasPrefix(field, variantFieldPrefix):
+			setCount(&stats.Variants, strings.TrimPrefix(field, variantFieldPrefix), count)
+		case strings.HasPrefix(field, pathFieldPrefix):
+			setCount(&stats.Paths, strings.TrimPrefix(field, pathFieldPrefix), count)
+		}
+	}
+	return stats, nil
+}
+
+// setCount sets one count of a dimension, creating its map on first use so
+// that dimensions without clicks are left out of the response.
+func setCount(counts *map[string]int64, label string, count int64) {
+	if *counts == nil {
+		*counts = make(map[string]int64)
+	}
+	(*counts)[label] = count
+}
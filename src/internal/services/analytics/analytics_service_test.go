@@ -0,0 +1,114 @@
+package analytics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/services/analytics"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+const retention = 24 * time.Hour
+
+func TestClicks(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		clicks   []domain.Click
+		expected *domain.ClickStats
+	}{
+		{
+			name:     "WhenLinkWasNeverClicked_ThenReturnsZero",
+			expected: &domain.ClickStats{},
+		},
+		{
+			name: "WhenClicksHaveTargets_ThenCountsThemPerTarget",
+			clicks: []domain.Click{
+				{Target: "app-store", Country: "ES"},
+				{Target: domain.DefaultTarget, Country: "US"},
+				{Target: "app-store"},
+			},
+			expected: &domain.ClickStats{Total: 3,
+				Targets:   map[string]int64{"app-store": 2, domain.DefaultTarget: 1},
+				Countries: map[string]int64{"ES": 1, "US": 1, domain.UnknownCountry: 1},
+				Paths:     map[string]int64{domain.PathPrimary: 3}},
+		},
+		{
+			name: "WhenClicksHaveVariants_ThenCountsThemPerVariant",
+			clicks: []domain.Click{
+				{Target: domain.DefaultTarget, Country: "ES", Variant: "a"},
+				{Target: domain.DefaultTarget, Country: "ES", Variant: "b"},
+				{Target: domain.DefaultTarget, Country: "ES", Variant: "a"},
+			},
+			expected: &domain.ClickStats{Total: 3,
+				Targets:   map[string]int64{domain.DefaultTarget: 3},
+				Countries: map[string]int64{"ES": 3},
+				Variants:  map[string]int64{"a": 2, "b": 1},
+				Paths:     map[string]int64{domain.PathPrimary: 3}},
+		},
+		{
+			name: "WhenClicksFellBack_ThenCountsThemPerPath",
+			clicks: []domain.Click{
+				{Target: domain.DefaultTarget, Country: "ES"},
+				{Country: "ES", Path: domain.PathExhausted},
+				{Country: "ES", Path: domain.PathBroken},
+				{Country: "ES", Path: domain.PathExhausted},
+			},
+			expected: &domain.ClickStats{Total: 4,
+				Targets:   map[string]int64{domain.DefaultTarget: 1},
+				Countries: map[string]int64{"ES": 4},
+				Paths:     map[string]int64{domain.PathPrimary: 1, domain.PathExhausted: 2, domain.PathBroken: 1}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+			service := analytics.NewAnalyticsService(client, retention)
+
+			for _, click := range tt.clicks {
+				assert.NoError(t, service.RecordClick(ctx, "someLink", click))
+			}
+
+			stats, err := service.Clicks(ctx, "someLink")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, stats)
+			if len(tt.clicks) > 0 {
+				assert.Equal(t, retention, server.TTL("analytics:someLink"))
+			}
+		})
+	}
+}
+
+func TestRecordClickWhenStorageIsDown_ThenReturnsStorageUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	server.Close()
+
+	service := analytics.NewAnalyticsService(client, retention)
+
+	err := service.RecordClick(context.Background(), "someLink", domain.Click{})
+	assert.ErrorIs(t, err, domain.ErrStorageUnavailable)
+}
+
+func TestRecordClickWhenLinkBelongsToAWorkspace_ThenCountsItInTheListingOfTheWorkspace(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := analytics.NewAnalyticsService(client, retention)
+
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, service.RecordClick(ctx, "ws:marketing:promo", domain.Click{Target: domain.DefaultTarget}))
+	}
+
+	clicks, err := client.ZScore(ctx, domain.LinkIndexKey("marketing", domain.SortClicks), "ws:marketing:promo").Result()
+	assert.NoError(t, err)
+	assert.Equal(t, float64(2), clicks)
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/ports"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// streamKeyPrefix holds the audit log of each workspace as a Redis
+	// Stream, oldest event first. The log of the links created without a
+	// workspace is the bare prefix.
+	streamKeyPrefix = "audit_log"
+	eventField      = "event"
+	// scanBatch is how many events a query reads per round trip, and
+	// maxScanned how many it reads at most before returning a page, full or
+	// not, with a cursor to continue.
+	scanBatch  = 200
+	maxScanned = 10000
+)
+
+// AuditService appends the events of the audit log to Redis Streams, which
+// keep them in order and never rewrite them.
+type AuditService struct {
+	client ports.StorageClient
+	// maxEvents caps the log of each workspace, dropping the oldest events
+	// first. Zero keeps every event.
+	maxEvents int64
+}
+
+func NewAuditService(client ports.StorageClient, maxEvents int64) *AuditService {
+	return &AuditService{
+		client:    client,
+		maxEvents: maxEvents,
+	}
+}
+
+// Record appends event to the audit log of its workspace.
+func (s AuditService) Record(ctx context.Context, event domain.AuditEvent) error {
+	event.ID = ""
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding the audit event | Action %s --> %w", event.Action, err)
+	}
+
+	err = s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(event.Workspace),
+		MaxLen: s.maxEvents,
+		Approx: true,
+		Values: []interface{}{eventField, string(value)},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("an error has occurred recording the audit event | Action %s --> %w: %w",
+			event.Action, domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Query returns the events of the audit log of query.Workspace that match
+// query, newest first. The time range is looked up through the IDs of the
+// stream, which start with the time of each event; the other filters are
+// applied while reading, so a page may come back short, with a cursor, when
+// few events match.
+func (s AuditService) Query(ctx context.Context, query domain.AuditQuery) (*domain.AuditPage, error) {
+	start, end := "-", "+"
+	if query.From != nil {
+		start = strconv.FormatInt(query.From.UnixMilli(), 10)
+	}
+	if query.To != nil {
+		end = strconv.FormatInt(query.To.UnixMilli(), 10)
+	}
+	if query.Cursor != "" {
+		end = "(" + query.Cursor
+	}
+
+	page := &domain.AuditPage{Events: []domain.AuditEvent{}}
+	for scanned := 0; scanned < maxScanned; scanned += scanBatch {
+		entries, err := s.client.XRevRangeN(ctx, streamKey(query.Workspace), end, start, scanBatch).Result()
+		if err != nil {
+			return nil, fmt.Errorf("an error has occurred reading the audit log | Workspace %s --> %w: %w",
+				query.Workspace, domain.ErrStorageUnavailable, err)
+		}
+
+		for _, entry := range entries {
+			event, err := decodeEvent(entry)
+			if err != nil {
+				return nil, err
+			}
+			if !query.Matches(event) {
+				continue
+			}
+			page.Events = append(page.Events, event)
+			if len(page.Events) == query.Limit {
+				page.NextCursor = entry.ID
+				return page, nil
+			}
+		}
+		if len(entries) < scanBatch {
+			return page, nil
+		}
+		end = "(" + entries[len(entries)-1].ID
+	}
+	page.NextCursor = end[1:]
+	return page, nil
+}
+
+func decodeEvent(entry redis.XMessage) (domain.AuditEvent, error) {
+	value, _ := entry.Values[eventField].(string)
+	var event domain.AuditEvent
+	if err := json.Unmarshal([]byte(value), &event); err != nil {
+		return domain.AuditEvent{}, fmt.Errorf("decoding the audit event | ID %s --> %w", entry.ID, err)
+	}
+	event.ID = entry.ID
+	return event, nil
+}
+
+func streamKey(workspace string) string {
+	if workspace == "" {
+		return streamKeyPrefix
+	}
+	return streamKeyPrefix + ":" + workspace
+}
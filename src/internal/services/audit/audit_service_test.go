@@ -0,0 +1,73 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/services/audit"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := audit.NewAuditService(client, 1000)
+
+	event := domain.AuditEvent{Time: time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC), Workspace: "marketing", Actor: "ci",
+		Action: domain.ActionDelete, Target: "ws:marketing:promo", Outcome: domain.AuditDenied, RequestID: "req-1"}
+	assert.NoError(t, service.Record(ctx, event))
+
+	entries, err := client.XRange(ctx, "audit_log:marketing", "-", "+").Result()
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	var recorded domain.AuditEvent
+	assert.NoError(t, json.Unmarshal([]byte(entries[0].Values["event"].(string)), &recorded))
+	assert.Equal(t, event, recorded)
+}
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := audit.NewAuditService(client, 1000)
+
+	start := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	for i, actor := range []string{"ci", "ops", "ci", "ci", "ops"} {
+		server.SetTime(start.Add(time.Duration(i) * time.Minute))
+		event := domain.AuditEvent{Time: start.Add(time.Duration(i) * time.Minute), Workspace: "marketing", Actor: actor,
+			Action: domain.ActionCreate, Target: fmt.Sprintf("ws:marketing:link%d", i), Outcome: domain.AuditSucceeded}
+		assert.NoError(t, service.Record(ctx, event))
+	}
+	assert.NoError(t, service.Record(ctx, domain.AuditEvent{Workspace: "sales", Actor: "ci", Action: domain.ActionCreate}))
+
+	targets := func(page *domain.AuditPage) []string {
+		var targets []string
+		for _, event := range page.Events {
+			targets = append(targets, event.Target)
+		}
+		return targets
+	}
+
+	page, err := service.Query(ctx, domain.AuditQuery{Workspace: "marketing", Actor: "ci", Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ws:marketing:link3", "ws:marketing:link2"}, targets(page))
+	assert.NotEmpty(t, page.NextCursor)
+
+	page, err = service.Query(ctx, domain.AuditQuery{Workspace: "marketing", Actor: "ci", Limit: 2, Cursor: page.NextCursor})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ws:marketing:link0"}, targets(page))
+	assert.Empty(t, page.NextCursor)
+
+	from, to := start.Add(time.Minute), start.Add(3*time.Minute)
+	page, err = service.Query(ctx, domain.AuditQuery{Workspace: "marketing", From: &from, To: &to, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ws:marketing:link3", "ws:marketing:link2", "ws:marketing:link1"}, targets(page))
+}
@@ -0,0 +1,106 @@
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/oschwald/geoip2-golang"
+	log "github.com/sirupsen/logrus"
+)
+
+// GeoIPService resolves client IPs to ISO 3166-1 countries with a MaxMind
+// format .mmdb database. The file is read into memory, so it can be replaced
+// on disk at any time and picked up by Reload without stopping lookups.
+type GeoIPService struct {
+	path   string
+	reader atomic.Pointer[geoip2.Reader]
+
+	// reloadMu serialises reloads, modTime is the file version loaded.
+	reloadMu sync.Mutex
+	modTime  time.Time
+}
+
+// NewGeoIPService loads the database at path. An empty path disables the
+// lookups: every IP then resolves to no country.
+func NewGeoIPService(path string) (*GeoIPService, error) {
+	s := &GeoIPService{path: path}
+	if path == "" {
+		return s, nil
+	}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Country returns the ISO code of the country of ip, or "" when it is unknown.
+func (s *GeoIPService) Country(ip string) string {
+	reader := s.reader.Load()
+	parsed := net.ParseIP(ip)
+	if reader == nil || parsed == nil {
+		return ""
+	}
+	country, err := reader.Country(parsed)
+	if err != nil {
+		return ""
+	}
+	return country.Country.IsoCode
+}
+
+// Reload reads the database again if the file changed since it was loaded,
+// and reports whether it did. On error the loaded database stays in use.
+func (s *GeoIPService) Reload() (bool, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, fmt.Errorf("reading the GeoIP database | Path %s --> %w", s.path, err)
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return false, nil
+	}
+
+	content, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, fmt.Errorf("reading the GeoIP database | Path %s --> %w", s.path, err)
+	}
+	reader, err := geoip2.FromBytes(content)
+	if err != nil {
+		return false, fmt.Errorf("opening the GeoIP database | Path %s --> %w", s.path, err)
+	}
+
+	s.reader.Store(reader)
+	s.modTime = info.ModTime()
+	return true, nil
+}
+
+// Watch calls Reload every interval until ctx is done. An interval of zero or
+// less turns reloading off.
+func (s *GeoIPService) Watch(ctx context.Context, interval time.Duration) {
+	if s.path == "" || interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := s.Reload()
+			if err != nil {
+				log.Error(fmt.Errorf("reloading the GeoIP database --> %w", err))
+				continue
+			}
+			if reloaded {
+				log.Infof("reloaded the GeoIP database %s", s.path)
+			}
+		}
+	}
+}
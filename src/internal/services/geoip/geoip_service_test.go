@@ -0,0 +1,113 @@
+package geoip_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/services/geoip"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDatabase = "testdata/GeoIP2-Country-Test.mmdb"
+
+func TestCountry(t *testing.T) {
+	service, err := geoip.NewGeoIPService(testDatabase)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		ip       string
+		expected string
+	}{
+		{
+			name:     "WhenIPIsInTheDatabase_ThenReturnsItsCountry",
+			ip:       "81.2.3.4",
+			expected: "ES",
+		},
+		{
+			name:     "WhenIPIsNotInTheDatabase_ThenReturnsEmpty",
+			ip:       "1.1.1.1",
+			expected: "",
+		},
+		{
+			name:     "WhenIPIsNotValid_ThenReturnsEmpty",
+			ip:       "not an ip",
+			expected: "",
+		},
+		{
+			name:     "WhenIPIsIPv6AndTheDatabaseIsNot_ThenReturnsEmpty",
+			ip:       "2001:db8::1",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, service.Country(tt.ip))
+		})
+	}
+}
+
+func TestNewGeoIPServiceWhenPathIsEmpty_ThenResolvesNothing(t *testing.T) {
+	service, err := geoip.NewGeoIPService("")
+	require.NoError(t, err)
+	assert.Equal(t, "", service.Country("81.2.3.4"))
+}
+
+func TestNewGeoIPServiceWhenFileIsNotADatabase_ThenReturnsError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0o600))
+
+	_, err := geoip.NewGeoIPService(path)
+	assert.Error(t, err)
+}
+
+func TestReload(t *testing.T) {
+	content, err := os.ReadFile(testDatabase)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "country.mmdb")
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+
+	service, err := geoip.NewGeoIPService(path)
+	require.NoError(t, err)
+
+	reloaded, err := service.Reload()
+	assert.NoError(t, err)
+	assert.False(t, reloaded, "an unchanged file is not read again")
+
+	// A broken update keeps the loaded database in use.
+	require.NoError(t, os.WriteFile(path, []byte("truncated"), 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+	_, err = service.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, "ES", service.Country("81.2.3.4"))
+
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(2*time.Minute)))
+	reloaded, err = service.Reload()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, "US", service.Country("8.8.8.8"))
+}
+
+func TestWatchWhenIntervalIsNotPositive_ThenReturns(t *testing.T) {
+	service, err := geoip.NewGeoIPService(testDatabase)
+	require.NoError(t, err)
+
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		done := make(chan struct{})
+		go func() {
+			service.Watch(context.Background(), interval)
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("Watch(%s) did not return", interval)
+		}
+	}
+}
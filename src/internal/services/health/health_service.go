@@ -0,0 +1,411 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/ports"
+	"github.com/dariomba/url-shortener/src/internal/safehttp"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	userAgent = "Mozilla/5.0 (compatible; url-shortener link checker)"
+
+	// claimBatch is how many due links a round claims at a time.
+	claimBatch = 100
+	// maxPoll is the longest Run waits between rounds, so that edited
+	// links, due right away, are checked soon whatever the interval.
+	maxPoll = time.Minute
+	// maxDiscarded is how much of a body is read, so that the connection
+	// can be reused, before it is closed.
+	maxDiscarded = 64 * 1024
+)
+
+const (
+	EventBroken    = "link.broken"
+	EventRecovered = "link.recovered"
+)
+
+// claimScript takes the links due a check, up to a batch, and schedules
+// their next check, so that servers sharing the schedule never check the
+// same link twice.
+var claimScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[3])
+for _, key in ipairs(due) do
+	redis.call('ZADD', KEYS[1], ARGV[2], key)
+end
+return due
+`)
+
+type Options struct {
+	// Interval is how often the destination of every link is checked.
+	Interval time.Duration
+	// Concurrency is how many destinations are checked at once, at least
+	// one, and HostDelay how long to wait between two requests to the same
+	// host.
+	Concurrency int
+	HostDelay   time.Duration
+	// Threshold is how many checks in a row must fail before a link is
+	// broken, at least one.
+	Threshold int
+	// WebhookURL, when set, is told when a link breaks or recovers.
+	WebhookURL string
+}
+
+// Event is what the webhook is sent when a link breaks or recovers.
+type Event struct {
+	Event     string            `json:"event"`
+	Code      string            `json:"code"`
+	Domain    string            `json:"domain,omitempty"`
+	Workspace string            `json:"workspace,omitempty"`
+	URL       string            `json:"url"`
+	Health    domain.LinkHealth `json:"health"`
+}
+
+// HealthService checks the destinations of links in the background, see Run,
+// and keeps what it found next to each link, see domain.LinkHealth. Links
+// are checked in the order of domain.HealthScheduleKey.
+type HealthService struct {
+	client  ports.StorageClient
+	storage ports.StorageService
+	checker *http.Client
+	webhook *http.Client
+	options Options
+	hosts   *hostGate
+}
+
+// NewHealthService checks destinations with checker, see
+// safehttp.NewClient. A Concurrency or Threshold below one is taken as one.
+func NewHealthService(client ports.StorageClient, storage ports.StorageService, checker *http.Client, options Options) *HealthService {
+	options.Concurrency = max(options.Concurrency, 1)
+	options.Threshold = max(options.Threshold, 1)
+	return &HealthService{
+		client:  client,
+		storage: storage,
+		checker: checker,
+		webhook: &http.Client{Timeout: checker.Timeout},
+		options: options,
+		hosts:   &hostGate{delay: options.HostDelay, next: make(map[string]time.Time)},
+	}
+}
+
+// Run checks the links as they fall due until ctx is done.
+func (s *HealthService) Run(ctx context.Context) {
+	poll := min(s.options.Interval, maxPoll)
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+	for {
+		if _, err := s.CheckDue(ctx); err != nil {
+			log.Error(fmt.Errorf("checking the links --> %w", err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckDue checks every link due a check and returns how many it checked.
+func (s *HealthService) CheckDue(ctx context.Context) (int, error) {
+	checked := 0
+	for ctx.Err() == nil {
+		now := time.Now()
+		keys, err := claimScript.Run(ctx, s.client, []string{domain.HealthScheduleKey},
+			now.UnixMilli(), now.Add(s.options.Interval).UnixMilli(), claimBatch).StringSlice()
+		if err != nil {
+			return checked, fmt.Errorf("an error has occurred claiming the due links --> %w: %w", domain.ErrStorageUnavailable, err)
+		}
+
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, s.options.Concurrency)
+		for _, key := range keys {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() { <-slots; wg.Done() }()
+				if err := s.check(ctx, key); err != nil {
+					log.Error(fmt.Errorf("checking the link | Key %s --> %w", key, err))
+				}
+			}()
+		}
+		wg.Wait()
+		s.hosts.forget(time.Now())
+
+		checked += len(keys)
+		if len(keys) < claimBatch {
+			break
+		}
+	}
+	return checked, nil
+}
+
+// check checks the destination of the link stored under key and saves its
+// health. Links that are gone are dropped from the checks.
+func (s *HealthService) check(ctx context.Context, key string) error {
+	link, err := s.storage.GetLink(ctx, key)
+	if errors.Is(err, domain.ErrLinkNotFound) {
+		return s.forget(ctx, key)
+	}
+	if err != nil {
+		return err
+	}
+	previous, err := s.Health(ctx, key)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		previous = &domain.LinkHealth{}
+	}
+
+	health := previous.Next(s.Probe(ctx, link.OriginalURL), s.options.Threshold)
+	if err := s.save(ctx, *link, health); err != nil {
+		return err
+	}
+	switch {
+	case health.Broken() && !previous.Broken():
+		s.notify(ctx, EventBroken, *link, health)
+	case !health.Broken() && previous.Broken():
+		s.notify(ctx, EventRecovered, *link, health)
+	}
+	return nil
+}
+
+// Probe requests target, with HEAD, or GET from servers that refuse HEAD.
+// Hosts are requested at most once every HostDelay.
+func (s *HealthService) Probe(ctx context.Context, target string) domain.HealthCheck {
+	parsed, err := url.Parse(target)
+	if err == nil {
+		err = safehttp.CheckURL(parsed)
+	}
+	if err != nil {
+		return domain.HealthCheck{Error: err.Error(), Time: time.Now()}
+	}
+	if err := s.hosts.wait(ctx, parsed.Host); err != nil {
+		return domain.HealthCheck{Error: err.Error(), Time: time.Now()}
+	}
+
+	check := s.request(ctx, http.MethodHead, parsed)
+	if check.Status == http.StatusMethodNotAllowed || check.Status == http.StatusNotImplemented {
+		check = s.request(ctx, http.MethodGet, parsed)
+	}
+	return check
+}
+
+func (s *HealthService) request(ctx context.Context, method string, target *url.URL) domain.HealthCheck {
+	start := time.Now()
+	check := domain.HealthCheck{Time: start}
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), nil)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := s.checker.Do(req)
+	check.Latency = time.Since(start)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxDiscarded))
+	resp.Body.Close()
+	check.Status = resp.StatusCode
+	return check
+}
+
+// save keeps health for a few intervals, so that the health of links no
+// longer checked goes away, and lists the link among the broken ones of its
+// workspace while it is.
+func (s *HealthService) save(ctx context.Context, link domain.Link, health domain.LinkHealth) error {
+	value, err := json.Marshal(health)
+	if err != nil {
+		return fmt.Errorf("encoding the health --> %w", err)
+	}
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, domain.HealthKey(link.Key()), value, 3*s.options.Interval)
+		if health.Broken() {
+			pipe.ZAdd(ctx, domain.BrokenIndexKey(link.Workspace),
+				redis.Z{Score: float64(health.BrokenSince.UnixMilli()), Member: link.Key()})
+		} else {
+			pipe.ZRem(ctx, domain.BrokenIndexKey(link.Workspace), link.Key())
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("an error has occurred saving the health | Code %s --> %w: %w", link.Code, domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// forget drops the link stored under key, deleted or expired, from the
+// checks.
+func (s *HealthService) forget(ctx context.Context, keys ...string) error {
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			workspace, _ := domain.SplitWorkspaceKey(key)
+			pipe.Del(ctx, domain.HealthKey(key))
+			pipe.ZRem(ctx, domain.HealthScheduleKey, key)
+			pipe.ZRem(ctx, domain.BrokenIndexKey(workspace), key)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("an error has occurred forgetting the links --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// notify tells the webhook, if any, that link broke or recovered. Failures
+// are logged: the next transition is notified all the same.
+func (s *HealthService) notify(ctx context.Context, event string, link domain.Link, health domain.LinkHealth) {
+	if s.options.WebhookURL == "" {
+		return
+	}
+	body, err := json.Marshal(Event{
+		Event:     event,
+		Code:      link.Code,
+		Domain:    link.Domain,
+		Workspace: link.Workspace,
+		URL:       link.OriginalURL,
+		Health:    health,
+	})
+	if err != nil {
+		log.Error(fmt.Errorf("encoding the webhook event --> %w", err))
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.options.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		log.Error(fmt.Errorf("building the webhook request --> %w", err))
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.webhook.Do(req)
+	if err != nil {
+		log.Warn(fmt.Errorf("calling the webhook | Event %s | Code %s --> %w", event, link.Code, err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		log.Warnf("the webhook answered %s | Event %s | Code %s", resp.Status, event, link.Code)
+	}
+}
+
+// Health returns the health of the link stored under key, nil when it was
+// never checked.
+func (s *HealthService) Health(ctx context.Context, key string) (*domain.LinkHealth, error) {
+	value, err := s.client.Get(ctx, domain.HealthKey(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred retrieving the health | Key %s --> %w: %w", key, domain.ErrStorageUnavailable, err)
+	}
+	var health domain.LinkHealth
+	if err := json.Unmarshal([]byte(value), &health); err != nil {
+		return nil, fmt.Errorf("decoding the health | Key %s --> %w", key, err)
+	}
+	return &health, nil
+}
+
+// BrokenLinks returns up to limit broken links of workspace, most recently
+// broken first. Links deleted, or whose health expired, since they broke are
+// dropped from the list on the way, so a page may come back short.
+func (s *HealthService) BrokenLinks(ctx context.Context, workspace string, limit int) ([]domain.BrokenLink, error) {
+	keys, err := s.client.ZRevRange(ctx, domain.BrokenIndexKey(workspace), 0, int64(limit-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred listing the broken links | Workspace %s --> %w: %w",
+			workspace, domain.ErrStorageUnavailable, err)
+	}
+
+	broken := []domain.BrokenLink{}
+	var gone, recovered []string
+	for _, key := range keys {
+		link, err := s.storage.GetLink(ctx, key)
+		if errors.Is(err, domain.ErrLinkNotFound) {
+			gone = append(gone, key)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		health, err := s.Health(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if health == nil || !health.Broken() {
+			recovered = append(recovered, key)
+			continue
+		}
+		broken = append(broken, domain.BrokenLink{Link: *link, Health: *health})
+	}
+	if len(gone) > 0 {
+		if err := s.forget(ctx, gone...); err != nil {
+			log.Error(err)
+		}
+	}
+	if len(recovered) > 0 {
+		if err := s.client.ZRem(ctx, domain.BrokenIndexKey(workspace), toMembers(recovered)...).Err(); err != nil {
+			log.Error(fmt.Errorf("an error has occurred unlisting the recovered links --> %w: %w", domain.ErrStorageUnavailable, err))
+		}
+	}
+	return broken, nil
+}
+
+func toMembers(keys []string) []interface{} {
+	members := make([]interface{}, len(keys))
+	for i, key := range keys {
+		members[i] = key
+	}
+	return members
+}
+
+// hostGate spaces the requests to each host by delay.
+type hostGate struct {
+	delay time.Duration
+	mu    sync.Mutex
+	// next is when each host may be requested again.
+	next map[string]time.Time
+}
+
+// wait waits for the turn of host, or for ctx to be done.
+func (g *hostGate) wait(ctx context.Context, host string) error {
+	g.mu.Lock()
+	now := time.Now()
+	turn := g.next[host]
+	if turn.Before(now) {
+		turn = now
+	}
+	g.next[host] = turn.Add(g.delay)
+	g.mu.Unlock()
+
+	timer := time.NewTimer(turn.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// forget drops the hosts that may be requested again right away.
+func (g *hostGate) forget(now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for host, turn := range g.next {
+		if turn.Before(now) {
+			delete(g.next, host)
+		}
+	}
+}
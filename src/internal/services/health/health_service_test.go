@@ -0,0 +1,230 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/services/health"
+	"github.com/dariomba/url-shortener/src/internal/services/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/private":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/failing":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantFailed bool
+	}{
+		{name: "WhenDestinationAnswers_ThenReturnsItsStatus", target: server.URL + "/ok", wantStatus: 200},
+		{name: "WhenDestinationRefusesHEAD_ThenFallsBackToGET", target: server.URL + "/no-head", wantStatus: 200},
+		{name: "WhenDestinationRedirects_ThenFollowsTheRedirect", target: server.URL + "/moved", wantStatus: 200},
+		{name: "WhenDestinationNeedsCredentials_ThenIsNotFailed", target: server.URL + "/private", wantStatus: 401},
+		{name: "WhenDestinationIsMissing_ThenFails", target: server.URL + "/missing", wantStatus: 404, wantFailed: true},
+		{name: "WhenDestinationFails_ThenFails", target: server.URL + "/failing", wantStatus: 502, wantFailed: true},
+		{name: "WhenDestinationIsUnreachable_ThenFailsWithoutStatus", target: closed.URL, wantFailed: true},
+		{name: "WhenURLIsNotHTTP_ThenFailsWithoutRequest", target: "file:///etc/passwd", wantFailed: true},
+	}
+
+	service := health.NewHealthService(nil, nil, server.Client(), health.Options{Interval: time.Hour, Concurrency: 1})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := service.Probe(context.Background(), tt.target)
+
+			assert.Equal(t, tt.wantStatus, check.Status)
+			assert.Equal(t, tt.wantFailed, check.Failed())
+			assert.Equal(t, tt.wantStatus == 0, check.Error != "")
+		})
+	}
+}
+
+// checker is a destination whose status can be changed, and a webhook
+// recording the events it is sent.
+type checker struct {
+	status      atomic.Int32
+	destination *httptest.Server
+	webhook     *httptest.Server
+	events      chan health.Event
+}
+
+func newChecker(t *testing.T) *checker {
+	c := &checker{events: make(chan health.Event, 10)}
+	c.status.Store(http.StatusOK)
+	c.destination = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(c.status.Load()))
+	}))
+	c.webhook = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event health.Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		c.events <- event
+	}))
+	t.Cleanup(c.destination.Close)
+	t.Cleanup(c.webhook.Close)
+	return c
+}
+
+func TestCheckDue(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	storageService := storage.NewStorageService(client)
+	c := newChecker(t)
+	service := health.NewHealthService(client, storageService, c.destination.Client(), health.Options{
+		Interval: time.Hour, Concurrency: 2, Threshold: 2, WebhookURL: c.webhook.URL,
+	})
+
+	link := domain.Link{Code: "promo", OriginalURL: c.destination.URL + "/promo", Workspace: "marketing"}
+	assert.NoError(t, storageService.SaveLink(ctx, link))
+	due := func() {
+		t.Helper()
+		_, err := server.ZAdd(domain.HealthScheduleKey, 0, link.Key())
+		assert.NoError(t, err)
+	}
+
+	c.status.Store(http.StatusNotFound)
+	checked, err := service.CheckDue(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, checked)
+	current, err := service.Health(ctx, link.Key())
+	assert.NoError(t, err)
+	assert.Equal(t, 404, current.Status)
+	assert.Equal(t, 1, current.Failures)
+	assert.False(t, current.Broken())
+
+	// The link is not due again before the interval.
+	checked, err = service.CheckDue(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, checked)
+
+	due()
+	_, err = service.CheckDue(ctx)
+	assert.NoError(t, err)
+	event := <-c.events
+	assert.Equal(t, health.EventBroken, event.Event)
+	assert.Equal(t, "promo", event.Code)
+	assert.Equal(t, "marketing", event.Workspace)
+	assert.Equal(t, 2, event.Health.Failures)
+	broken, err := service.BrokenLinks(ctx, "marketing", 10)
+	assert.NoError(t, err)
+	assert.Len(t, broken, 1)
+	assert.Equal(t, link.OriginalURL, broken[0].Link.OriginalURL)
+	assert.True(t, broken[0].Health.Broken())
+
+	// Other workspaces do not see the link.
+	broken, err = service.BrokenLinks(ctx, "", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, broken)
+
+	c.status.Store(http.StatusOK)
+	due()
+	_, err = service.CheckDue(ctx)
+	assert.NoError(t, err)
+	event = <-c.events
+	assert.Equal(t, health.EventRecovered, event.Event)
+	broken, err = service.BrokenLinks(ctx, "marketing", 10)
+	assert.NoError(t, err)
+	assert.Empty(t, broken)
+	assert.Empty(t, c.events)
+}
+
+func TestCheckDueWhenLinkIsGone_ThenForgetsIt(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	c := newChecker(t)
+	service := health.NewHealthService(client, storage.NewStorageService(client), c.destination.Client(), health.Options{
+		Interval: time.Hour, Concurrency: 1, Threshold: 1,
+	})
+	_, err := server.ZAdd(domain.HealthScheduleKey, 0, "expired")
+	assert.NoError(t, err)
+	_, err = server.ZAdd(domain.BrokenIndexKey(""), 0, "expired")
+	assert.NoError(t, err)
+
+	checked, err := service.CheckDue(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, checked)
+	assert.False(t, server.Exists(domain.HealthScheduleKey))
+	assert.False(t, server.Exists(domain.BrokenIndexKey("")))
+	assert.False(t, server.Exists(domain.HealthKey("expired")))
+}
+
+func TestCheckDueWhenLinksShareAHost_ThenSpacesTheirRequests(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	storageService := storage.NewStorageService(client)
+
+	var mu sync.Mutex
+	var requests []time.Time
+	destination := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, time.Now())
+	}))
+	defer destination.Close()
+	service := health.NewHealthService(client, storageService, destination.Client(), health.Options{
+		Interval: time.Hour, Concurrency: 3, HostDelay: 50 * time.Millisecond, Threshold: 1,
+	})
+	for _, code := range []string{"a", "b", "c"} {
+		assert.NoError(t, storageService.SaveLink(ctx, domain.Link{Code: code, OriginalURL: destination.URL + "/" + code}))
+	}
+
+	checked, err := service.CheckDue(ctx)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, checked)
+	assert.Len(t, requests, 3)
+	for i := 1; i < len(requests); i++ {
+		assert.GreaterOrEqual(t, requests[i].Sub(requests[i-1]), 40*time.Millisecond)
+	}
+}
+
+func TestCheckDueWhenConcurrencyIsNotPositive_ThenChecksOneAtATime(t *testing.T) {
+	for _, concurrency := range []int{0, -1} {
+		ctx := context.Background()
+		server := miniredis.RunT(t)
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		storageService := storage.NewStorageService(client)
+		c := newChecker(t)
+		service := health.NewHealthService(client, storageService, c.destination.Client(), health.Options{
+			Interval: time.Hour, Concurrency: concurrency,
+		})
+		assert.NoError(t, storageService.SaveLink(ctx, domain.Link{Code: "a", OriginalURL: c.destination.URL + "/a"}))
+
+		checked, err := service.CheckDue(ctx)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, checked)
+	}
+}
@@ -0,0 +1,82 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/ports"
+)
+
+const keyPrefix = "idempotency:"
+
+type IdempotencyService struct {
+	client ports.StorageClient
+	window time.Duration
+}
+
+// NewIdempotencyService stores responses for window, after which a key can be
+// reused with any payload.
+func NewIdempotencyService(client ports.StorageClient, window time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		client: client,
+		window: window,
+	}
+}
+
+// Reserve claims key for a request whose payload hashes to fingerprint. It
+// returns nil when the caller owns the key and must process the request, or
+// the stored response when the same request was already answered.
+func (s IdempotencyService) Reserve(ctx context.Context, key string, fingerprint string) (*domain.IdempotentResponse, error) {
+	pending, err := json.Marshal(domain.IdempotentResponse{Fingerprint: fingerprint, Pending: true})
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred encoding the idempotency record --> %w", err)
+	}
+
+	reserved, err := s.client.SetNX(ctx, keyPrefix+key, pending, s.window).Result()
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred reserving the idempotency key --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	raw, err := s.client.Get(ctx, keyPrefix+key).Bytes()
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred retrieving the idempotency key --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	var stored domain.IdempotentResponse
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, fmt.Errorf("an error has occurred decoding the idempotency record --> %w", err)
+	}
+
+	if stored.Fingerprint != fingerprint {
+		return nil, domain.ErrIdempotencyKeyReused
+	}
+	if stored.Pending {
+		return nil, domain.ErrIdempotencyKeyInProgress
+	}
+	return &stored, nil
+}
+
+// Save stores the final response of a reserved key for the rest of the window.
+func (s IdempotencyService) Save(ctx context.Context, key string, response domain.IdempotentResponse) error {
+	raw, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("an error has occurred encoding the idempotency record --> %w", err)
+	}
+	if err := s.client.Set(ctx, keyPrefix+key, raw, s.window).Err(); err != nil {
+		return fmt.Errorf("an error has occurred saving the idempotency record --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Release forgets a reserved key so that a failed request can be retried.
+func (s IdempotencyService) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, keyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("an error has occurred releasing the idempotency key --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
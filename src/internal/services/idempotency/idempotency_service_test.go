@@ -0,0 +1,187 @@
+package idempotency_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/mocks"
+	"github.com/dariomba/url-shortener/src/internal/services/idempotency"
+	"github.com/golang/mock/gomock"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+const window = time.Hour
+
+type mocksIdempotency struct {
+	storageClient *mocks.MockStorageClient
+}
+
+func TestReserve(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name             string
+		fingerprint      string
+		expectedResponse *domain.IdempotentResponse
+		expectedError    error
+		expectError      bool
+		mocks            func(m mocksIdempotency)
+	}{
+		{
+			name:        "WhenKeyIsNew_ThenReservesItAndReturnsNil",
+			fingerprint: "abc",
+			mocks: func(m mocksIdempotency) {
+				boolCmd := redis.NewBoolCmd(ctx)
+				boolCmd.SetVal(true)
+				m.storageClient.EXPECT().SetNX(ctx, "idempotency:key", []byte(`{"fingerprint":"abc","pending":true}`), window).Return(boolCmd)
+			},
+		},
+		{
+			name:        "WhenKeyWasAnswered_ThenReturnsStoredResponse",
+			fingerprint: "abc",
+			expectedResponse: &domain.IdempotentResponse{Fingerprint: "abc", StatusCode: 200,
+				ContentType: "application/json", Body: []byte(`{"url":"http://localhost/x"}`)},
+			mocks: func(m mocksIdempotency) {
+				boolCmd := redis.NewBoolCmd(ctx)
+				boolCmd.SetVal(false)
+				m.storageClient.EXPECT().SetNX(ctx, "idempotency:key", gomock.Any(), window).Return(boolCmd)
+				stringCmd := redis.NewStringCmd(ctx)
+				stringCmd.SetVal(`{"fingerprint":"abc","status_code":200,"content_type":"application/json","body":"eyJ1cmwiOiJodHRwOi8vbG9jYWxob3N0L3gifQ=="}`)
+				m.storageClient.EXPECT().Get(ctx, "idempotency:key").Return(stringCmd)
+			},
+		},
+		{
+			name:          "WhenKeyWasUsedWithAnotherPayload_ThenReturnsKeyReused",
+			fingerprint:   "abc",
+			expectedError: domain.ErrIdempotencyKeyReused,
+			mocks: func(m mocksIdempotency) {
+				boolCmd := redis.NewBoolCmd(ctx)
+				boolCmd.SetVal(false)
+				m.storageClient.EXPECT().SetNX(ctx, "idempotency:key", gomock.Any(), window).Return(boolCmd)
+				stringCmd := redis.NewStringCmd(ctx)
+				stringCmd.SetVal(`{"fingerprint":"other","status_code":200}`)
+				m.storageClient.EXPECT().Get(ctx, "idempotency:key").Return(stringCmd)
+			},
+		},
+		{
+			name:          "WhenFirstRequestIsPending_ThenReturnsInProgress",
+			fingerprint:   "abc",
+			expectedError: domain.ErrIdempotencyKeyInProgress,
+			mocks: func(m mocksIdempotency) {
+				boolCmd := redis.NewBoolCmd(ctx)
+				boolCmd.SetVal(false)
+				m.storageClient.EXPECT().SetNX(ctx, "idempotency:key", gomock.Any(), window).Return(boolCmd)
+				stringCmd := redis.NewStringCmd(ctx)
+				stringCmd.SetVal(`{"fingerprint":"abc","pending":true}`)
+				m.storageClient.EXPECT().Get(ctx, "idempotency:key").Return(stringCmd)
+			},
+		},
+		{
+			name:        "WhenSetNXFails_ThenReturnsError",
+			fingerprint: "abc",
+			expectError: true,
+			mocks: func(m mocksIdempotency) {
+				boolCmd := redis.NewBoolCmd(ctx)
+				boolCmd.SetErr(errors.New("weird error"))
+				m.storageClient.EXPECT().SetNX(ctx, "idempotency:key", gomock.Any(), window).Return(boolCmd)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksIdempotency{
+				storageClient: mocks.NewMockStorageClient(ctrl),
+			}
+
+			tt.mocks(m)
+
+			service := idempotency.NewIdempotencyService(m.storageClient, window)
+
+			response, err := service.Reserve(ctx, "key", tt.fingerprint)
+			switch {
+			case tt.expectedError != nil:
+				assert.ErrorIs(t, err, tt.expectedError)
+			case tt.expectError:
+				assert.Error(t, err)
+			default:
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedResponse, response)
+		})
+	}
+}
+
+func TestSave(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		expectError bool
+		mocks       func(m mocksIdempotency)
+	}{
+		{
+			name:        "WhenSetFails_ThenReturnsError",
+			expectError: true,
+			mocks: func(m mocksIdempotency) {
+				statusCmd := redis.NewStatusCmd(ctx)
+				statusCmd.SetErr(errors.New("weird error"))
+				m.storageClient.EXPECT().Set(ctx, "idempotency:key", gomock.Any(), window).Return(statusCmd)
+			},
+		},
+		{
+			name:        "WhenEverythingOK_ThenStoresTheResponse",
+			expectError: false,
+			mocks: func(m mocksIdempotency) {
+				statusCmd := redis.NewStatusCmd(ctx)
+				statusCmd.SetVal("OK")
+				m.storageClient.EXPECT().Set(ctx, "idempotency:key", []byte(`{"fingerprint":"abc","status_code":201}`), window).Return(statusCmd)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksIdempotency{
+				storageClient: mocks.NewMockStorageClient(ctrl),
+			}
+
+			tt.mocks(m)
+
+			service := idempotency.NewIdempotencyService(m.storageClient, window)
+
+			err := service.Save(ctx, "key", domain.IdempotentResponse{Fingerprint: "abc", StatusCode: 201})
+			if tt.expectError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	storageClient := mocks.NewMockStorageClient(ctrl)
+	intCmd := redis.NewIntCmd(ctx)
+	intCmd.SetVal(1)
+	storageClient.EXPECT().Del(ctx, "idempotency:key").Return(intCmd)
+
+	service := idempotency.NewIdempotencyService(storageClient, window)
+
+	assert.NoError(t, service.Release(ctx, "key"))
+}
@@ -0,0 +1,143 @@
+package keys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/ports"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// workspaceKeysPrefix holds the keys of each workspace by name.
+	workspaceKeysPrefix = "api_keys:"
+	// hashesKey finds every key, with its workspace, by hash.
+	hashesKey = "api_key_hashes"
+)
+
+// createScript adds a key to its workspace and to the index of hashes,
+// returning 0 without writing when the workspace has a key with that name.
+//
+// KEYS: workspace keys, hashes.
+// ARGV: name, key, hash, indexed key.
+var createScript = redis.NewScript(`
+if redis.call("HSETNX", KEYS[1], ARGV[1], ARGV[2]) == 0 then
+	return 0
+end
+redis.call("HSET", KEYS[2], ARGV[3], ARGV[4])
+return 1
+`)
+
+// revokeScript removes a key from its workspace and from the index of hashes,
+// returning 0 when the workspace has no key with that name.
+//
+// KEYS: workspace keys, hashes.
+// ARGV: name.
+var revokeScript = redis.NewScript(`
+local key = redis.call("HGET", KEYS[1], ARGV[1])
+if not key then
+	return 0
+end
+redis.call("HDEL", KEYS[1], ARGV[1])
+redis.call("HDEL", KEYS[2], cjson.decode(key).hash)
+return 1
+`)
+
+// indexedKey is a key as the index of hashes holds it.
+type indexedKey struct {
+	Workspace string `json:"workspace"`
+	domain.APIKey
+}
+
+// KeyService keeps the API keys created through the API, next to those of the
+// workspaces configuration.
+type KeyService struct {
+	client ports.StorageClient
+}
+
+func NewKeyService(client ports.StorageClient) *KeyService {
+	return &KeyService{
+		client: client,
+	}
+}
+
+// Create stores key, which must have its Hash, in workspace. It returns
+// domain.ErrKeyNameTaken when the workspace already has a key with its name.
+func (s KeyService) Create(ctx context.Context, workspace string, key domain.APIKey) error {
+	value, err := json.Marshal(key)
+	if err != nil {
+		return fmt.Errorf("encoding the key | Name %s --> %w", key.Name, err)
+	}
+	indexed, err := json.Marshal(indexedKey{Workspace: workspace, APIKey: key})
+	if err != nil {
+		return fmt.Errorf("encoding the key | Name %s --> %w", key.Name, err)
+	}
+
+	created, err := createScript.Run(ctx, s.client, []string{workspaceKeysPrefix + workspace, hashesKey},
+		key.Name, value, key.Hash, indexed).Int64()
+	if err != nil {
+		return fmt.Errorf("an error has occurred creating the key | Workspace %s --> %w: %w",
+			workspace, domain.ErrStorageUnavailable, err)
+	}
+	if created == 0 {
+		return domain.Detailed(domain.ErrKeyNameTaken, "%s", key.Name)
+	}
+	return nil
+}
+
+// Find returns the workspace and the key with hash. It returns
+// domain.ErrUnauthorized when no stored key has it.
+func (s KeyService) Find(ctx context.Context, hash string) (string, *domain.APIKey, error) {
+	value, err := s.client.HGet(ctx, hashesKey, hash).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil, domain.ErrUnauthorized
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("an error has occurred finding the key --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+
+	var key indexedKey
+	if err := json.Unmarshal([]byte(value), &key); err != nil {
+		return "", nil, fmt.Errorf("decoding the key --> %w", err)
+	}
+	return key.Workspace, &key.APIKey, nil
+}
+
+// List returns the keys stored for workspace by name, without their hashes.
+func (s KeyService) List(ctx context.Context, workspace string) ([]domain.APIKey, error) {
+	values, err := s.client.HGetAll(ctx, workspaceKeysPrefix+workspace).Result()
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred listing the keys | Workspace %s --> %w: %w",
+			workspace, domain.ErrStorageUnavailable, err)
+	}
+
+	keys := make([]domain.APIKey, 0, len(values))
+	for name, value := range values {
+		var key domain.APIKey
+		if err := json.Unmarshal([]byte(value), &key); err != nil {
+			return nil, fmt.Errorf("decoding the key | Name %s --> %w", name, err)
+		}
+		key.Hash = ""
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Name < keys[j].Name })
+	return keys, nil
+}
+
+// Revoke deletes the key called name from workspace, after which it no longer
+// authenticates. It returns domain.ErrKeyNotFound when there is no such key.
+func (s KeyService) Revoke(ctx context.Context, workspace string, name string) error {
+	revoked, err := revokeScript.Run(ctx, s.client, []string{workspaceKeysPrefix + workspace, hashesKey}, name).Int64()
+	if err != nil {
+		return fmt.Errorf("an error has occurred revoking the key | Workspace %s --> %w: %w",
+			workspace, domain.ErrStorageUnavailable, err)
+	}
+	if revoked == 0 {
+		return domain.Detailed(domain.ErrKeyNotFound, "%s", name)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package keys_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/services/keys"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyService(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := keys.NewKeyService(client)
+
+	key := domain.APIKey{Name: "ci", Role: domain.RoleEditor, Team: "growth", Hash: domain.HashAPIKey("ci-key")}
+	assert.NoError(t, service.Create(ctx, "marketing", key))
+	assert.NoError(t, service.Create(ctx, "sales", domain.APIKey{Name: "ci", Role: domain.RoleViewer, Hash: domain.HashAPIKey("other-key")}))
+
+	err := service.Create(ctx, "marketing", domain.APIKey{Name: "ci", Role: domain.RoleAdmin, Hash: domain.HashAPIKey("third-key")})
+	assert.ErrorIs(t, err, domain.ErrKeyNameTaken)
+
+	workspace, found, err := service.Find(ctx, key.Hash)
+	assert.NoError(t, err)
+	assert.Equal(t, "marketing", workspace)
+	assert.Equal(t, &key, found)
+
+	listed, err := service.List(ctx, "marketing")
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.APIKey{{Name: "ci", Role: domain.RoleEditor, Team: "growth"}}, listed)
+
+	assert.NoError(t, service.Revoke(ctx, "marketing", "ci"))
+	_, _, err = service.Find(ctx, key.Hash)
+	assert.ErrorIs(t, err, domain.ErrUnauthorized)
+	err = service.Revoke(ctx, "marketing", "ci")
+	assert.ErrorIs(t, err, domain.ErrKeyNotFound)
+
+	workspace, _, err = service.Find(ctx, domain.HashAPIKey("other-key"))
+	assert.NoError(t, err)
+	assert.Equal(t, "sales", workspace)
+}
@@ -0,0 +1,106 @@
+package lockout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/ports"
+	"github.com/redis/go-redis/v9"
+)
+
+const keyPrefix = "lockout:"
+
+// LockoutService counts failed attempts per key, such as a link or a client
+// IP, and locks a key once it reaches maxAttempts within window. The window
+// starts with the first attempt, so a locked key opens again once it ends.
+type LockoutService struct {
+	client      ports.StorageClient
+	maxAttempts int64
+	window      time.Duration
+}
+
+func NewLockoutService(client ports.StorageClient, maxAttempts int, window time.Duration) *LockoutService {
+	return &LockoutService{
+		client:      client,
+		maxAttempts: int64(maxAttempts),
+		window:      window,
+	}
+}
+
+// reserveAttemptScript counts an attempt against every key, unless any of
+// them already reached the limit, in which case it returns 0 without counting.
+// Running it as a script makes the check and the count atomic, so parallel
+// attempts can never go over the limit. The window of a key starts with its
+// first attempt.
+//
+// KEYS: counters.
+// ARGV: limit, window in milliseconds.
+var reserveAttemptScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	local attempts = tonumber(redis.call("GET", key))
+	if attempts and attempts >= tonumber(ARGV[1]) then
+		return 0
+	end
+end
+for _, key in ipairs(KEYS) do
+	if redis.call("INCR", key) == 1 then
+		redis.call("PEXPIRE", key, ARGV[2])
+	end
+end
+return 1
+`)
+
+// releaseAttemptScript takes back an attempt counted against every key that
+// still has one.
+//
+// KEYS: counters.
+var releaseAttemptScript = redis.NewScript(`
+for _, key in ipairs(KEYS) do
+	local attempts = tonumber(redis.call("GET", key))
+	if attempts and attempts > 0 then
+		redis.call("DECR", key)
+	end
+end
+return 1
+`)
+
+// Reserve counts an attempt against every key before it is made, so that it
+// counts as failed unless released. It returns domain.ErrTooManyAttempts,
+// without counting, when any of keys is locked.
+func (s LockoutService) Reserve(ctx context.Context, keys ...string) error {
+	reserved, err := reserveAttemptScript.Run(ctx, s.client, prefixed(keys), s.maxAttempts, s.window.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("an error has occurred reserving the attempt --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	if reserved == 0 {
+		return domain.ErrTooManyAttempts
+	}
+	return nil
+}
+
+// Release takes back the attempt reserved against keys, for an attempt that
+// succeeded.
+func (s LockoutService) Release(ctx context.Context, keys ...string) error {
+	if err := releaseAttemptScript.Run(ctx, s.client, prefixed(keys)).Err(); err != nil {
+		return fmt.Errorf("an error has occurred releasing the attempt --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Reset clears the failed attempts of keys.
+func (s LockoutService) Reset(ctx context.Context, keys ...string) error {
+	if err := s.client.Del(ctx, prefixed(keys)...).Err(); err != nil {
+		return fmt.Errorf("an error has occurred resetting the failed attempts --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+func prefixed(keys []string) []string {
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = keyPrefix + key
+	}
+	return prefixed
+}
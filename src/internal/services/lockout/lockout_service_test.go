@@ -0,0 +1,118 @@
+package lockout_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/services/lockout"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockout(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		failures    []string
+		released    []string
+		reset       []string
+		fastForward time.Duration
+		expectedErr error
+	}{
+		{
+			name: "WhenThereAreNoFailures_ThenIsNotLocked",
+		},
+		{
+			name:     "WhenFailuresAreBelowTheLimit_ThenIsNotLocked",
+			failures: []string{"link:abc", "link:abc"},
+		},
+		{
+			name:        "WhenAnyKeyReachesTheLimit_ThenIsLocked",
+			failures:    []string{"ip:10.0.0.1", "ip:10.0.0.1", "ip:10.0.0.1"},
+			expectedErr: domain.ErrTooManyAttempts,
+		},
+		{
+			name:     "WhenAnAttemptIsReleased_ThenDoesNotCount",
+			failures: []string{"link:abc", "link:abc", "link:abc"},
+			released: []string{"link:abc"},
+		},
+		{
+			name:        "WhenTheWindowEnds_ThenIsUnlocked",
+			failures:    []string{"link:abc", "link:abc", "link:abc"},
+			fastForward: time.Minute,
+		},
+		{
+			name:     "WhenKeyIsReset_ThenIsUnlocked",
+			failures: []string{"link:abc", "link:abc", "link:abc"},
+			reset:    []string{"link:abc"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+			service := lockout.NewLockoutService(client, 3, time.Minute)
+
+			for _, key := range tt.failures {
+				assert.NoError(t, service.Reserve(ctx, key))
+			}
+			if tt.released != nil {
+				assert.NoError(t, service.Release(ctx, tt.released...))
+			}
+			if tt.reset != nil {
+				assert.NoError(t, service.Reset(ctx, tt.reset...))
+			}
+			server.FastForward(tt.fastForward)
+
+			err := service.Reserve(ctx, "link:abc", "ip:10.0.0.1")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReserveWhenAttemptsRace_ThenNeverExceedsTheLimit(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := lockout.NewLockoutService(client, 3, time.Minute)
+
+	var wg sync.WaitGroup
+	var reserved atomic.Int64
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if service.Reserve(context.Background(), "link:abc") == nil {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(3), reserved.Load())
+	attempts, err := server.Get("lockout:link:abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "3", attempts)
+}
+
+func TestLockoutWhenStorageIsDown_ThenReturnsStorageUnavailable(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	server.Close()
+
+	service := lockout.NewLockoutService(client, 3, time.Minute)
+
+	assert.ErrorIs(t, service.Reserve(context.Background(), "link:abc"), domain.ErrStorageUnavailable)
+	assert.ErrorIs(t, service.Release(context.Background(), "link:abc"), domain.ErrStorageUnavailable)
+}
@@ -0,0 +1,176 @@
+package qrcode
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"sync"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	goqrcode "github.com/skip2/go-qrcode"
+)
+
+var recoveryLevels = map[string]goqrcode.RecoveryLevel{
+	"L": goqrcode.Low,
+	"M": goqrcode.Medium,
+	"Q": goqrcode.High,
+	"H": goqrcode.Highest,
+}
+
+type cacheEntry struct {
+	key   string
+	image []byte
+}
+
+// QRCodeService renders QR codes as PNG or SVG and keeps the most recently
+// rendered images in an in-memory LRU cache.
+type QRCodeService struct {
+	mu        sync.Mutex
+	cacheSize int
+	entries   *list.List
+	index     map[string]*list.Element
+}
+
+// NewQRCodeService caches up to cacheSize images. A size of zero disables the
+// cache.
+func NewQRCodeService(cacheSize int) *QRCodeService {
+	return &QRCodeService{
+		cacheSize: cacheSize,
+		entries:   list.New(),
+		index:     make(map[string]*list.Element),
+	}
+}
+
+func (s *QRCodeService) Render(content string, options domain.QROptions) ([]byte, error) {
+	if err := options.Validate(); err != nil {
+		return nil, err
+	}
+
+	key := options.CacheKey(content)
+	if cached, ok := s.cached(key); ok {
+		return cached, nil
+	}
+
+	code, err := goqrcode.New(content, recoveryLevels[options.Level])
+	if err != nil {
+		return nil, fmt.Errorf("error encoding the QR code | Content %s --> %w", content, err)
+	}
+	code.DisableBorder = true
+	modules := withMargin(code.Bitmap(), options.Margin)
+
+	// Colors were checked by Validate.
+	foreground, _ := domain.ParseHexColor(options.Foreground)
+	background, _ := domain.ParseHexColor(options.Background)
+
+	var rendered []byte
+	if options.Format == domain.QRFormatSVG {
+		rendered = renderSVG(modules, options.Size, foreground, background)
+	} else {
+		rendered, err = renderPNG(modules, options.Size, foreground, background)
+		if err != nil {
+			return nil, fmt.Errorf("error encoding the QR code PNG --> %w", err)
+		}
+	}
+
+	s.store(key, rendered)
+	return rendered, nil
+}
+
+func (s *QRCodeService) cached(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.index[key]
+	if !ok {
+		return nil, false
+	}
+	s.entries.MoveToFront(element)
+	return element.Value.(*cacheEntry).image, true
+}
+
+func (s *QRCodeService) store(key string, rendered []byte) {
+	if s.cacheSize <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.index[key]; ok {
+		s.entries.MoveToFront(element)
+		return
+	}
+	s.index[key] = s.entries.PushFront(&cacheEntry{key: key, image: rendered})
+	if s.entries.Len() > s.cacheSize {
+		oldest := s.entries.Back()
+		s.entries.Remove(oldest)
+		delete(s.index, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// withMargin surrounds the symbol with a quiet zone of margin light modules.
+func withMargin(bitmap [][]bool, margin int) [][]bool {
+	side := len(bitmap) + 2*margin
+	modules := make([][]bool, side)
+	for y := range modules {
+		modules[y] = make([]bool, side)
+		if y < margin || y >= margin+len(bitmap) {
+			continue
+		}
+		copy(modules[y][margin:], bitmap[y-margin])
+	}
+	return modules
+}
+
+// renderPNG scales the modules to an exactly size x size pixel image.
+func renderPNG(modules [][]bool, size int, foreground color.RGBA, background color.RGBA) ([]byte, error) {
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{background, foreground})
+	side := len(modules)
+	for y := 0; y < size; y++ {
+		row := modules[y*side/size]
+		for x := 0; x < size; x++ {
+			if row[x*side/size] {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderSVG draws one unit per module and lets the viewBox scale it to size.
+// Runs of dark modules in a row are merged into a single rectangle.
+func renderSVG(modules [][]bool, size int, foreground color.RGBA, background color.RGBA) []byte {
+	side := len(modules)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, side, side)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="%s"/>`, side, side, hexColor(background))
+	fmt.Fprintf(&buf, `<path fill="%s" d="`, hexColor(foreground))
+	for y, row := range modules {
+		for x := 0; x < side; x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < side && row[x] {
+				x++
+			}
+			fmt.Fprintf(&buf, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	buf.WriteString(`"/></svg>`)
+	return buf.Bytes()
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
@@ -0,0 +1,98 @@
+package qrcode_test
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/services/qrcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+
+	tests := []struct {
+		name        string
+		options     func(o *domain.QROptions)
+		expectError bool
+		check       func(t *testing.T, rendered []byte)
+	}{
+		{
+			name:        "WhenOptionsAreInvalid_ThenReturnsError",
+			options:     func(o *domain.QROptions) { o.Format = "gif" },
+			expectError: true,
+		},
+		{
+			name: "WhenFormatIsPNG_ThenRendersAnImageOfTheRequestedSizeAndColors",
+			options: func(o *domain.QROptions) {
+				o.Size = 128
+				o.Foreground = "#ff0000"
+			},
+			check: func(t *testing.T, rendered []byte) {
+				img, err := png.Decode(bytes.NewReader(rendered))
+				assert.NoError(t, err)
+				assert.Equal(t, 128, img.Bounds().Dx())
+				assert.Equal(t, 128, img.Bounds().Dy())
+				// The corner lies in the quiet zone and the finder pattern
+				// starts right after it.
+				assert.Equal(t, white, color.RGBAModel.Convert(img.At(0, 0)))
+				assert.Equal(t, red, color.RGBAModel.Convert(img.At(17, 17)))
+			},
+		},
+		{
+			name: "WhenFormatIsSVG_ThenRendersAScalableDocument",
+			options: func(o *domain.QROptions) {
+				o.Format = domain.QRFormatSVG
+				o.Margin = 0
+				o.Background = "00ff00"
+			},
+			check: func(t *testing.T, rendered []byte) {
+				svg := string(rendered)
+				assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="256" height="256" viewBox="0 0 25 25"`))
+				assert.Contains(t, svg, `fill="#00ff00"`)
+				assert.Contains(t, svg, `<path fill="#000000" d="M0 0h7v1h-7z`)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := domain.DefaultQROptions()
+			tt.options(&options)
+
+			service := qrcode.NewQRCodeService(10)
+
+			rendered, err := service.Render("http://localhost/abc", options)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.check(t, rendered)
+		})
+	}
+}
+
+func TestRenderWhenCalledTwice_ThenServesTheCachedImage(t *testing.T) {
+	service := qrcode.NewQRCodeService(1)
+	options := domain.DefaultQROptions()
+
+	first, err := service.Render("http://localhost/abc", options)
+	assert.NoError(t, err)
+	second, err := service.Render("http://localhost/abc", options)
+	assert.NoError(t, err)
+	assert.Same(t, &first[0], &second[0])
+
+	// Rendering another image evicts the only cache slot.
+	_, err = service.Render("http://localhost/def", options)
+	assert.NoError(t, err)
+	third, err := service.Render("http://localhost/abc", options)
+	assert.NoError(t, err)
+	assert.NotSame(t, &first[0], &third[0])
+	assert.Equal(t, first, third)
+}
@@ -0,0 +1,190 @@
+package quota
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/ports"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// creationsKeyPrefix counts the links each workspace created in the
+	// current hour.
+	creationsKeyPrefix = "workspace_creations:"
+	creationsWindow    = time.Hour
+)
+
+// Results of reserveScript other than 0, which reserved the quota.
+const (
+	linksExceeded     = 1
+	creationsExceeded = 2
+)
+
+// reservationsTTL is how long the placeholders of a reservation count as live
+// links when they are never released, such as when the server stops between
+// the reservation and the save.
+const reservationsTTL = time.Minute
+
+// reserveScript drops the expired links of a workspace from its live links,
+// then checks both quotas and, when they allow the links, adds a placeholder
+// per link to the live links and counts the creations. Both checks and their
+// writes happen in the script, so concurrent requests can never both pass a
+// quota that has room for only one of them: the second one already counts
+// the placeholders of the first.
+//
+// KEYS: workspace links, creations of the hour.
+// ARGV: now in Unix milliseconds, max links, max creations, window in
+// milliseconds, placeholder expiry in Unix milliseconds, then one
+// placeholder member per link.
+var reserveScript = redis.NewScript(`
+redis.call("ZREMRANGEBYSCORE", KEYS[1], "-inf", ARGV[1])
+local count = #ARGV - 5
+if tonumber(ARGV[2]) > 0 and redis.call("ZCARD", KEYS[1]) + count > tonumber(ARGV[2]) then
+	return 1
+end
+local created = tonumber(redis.call("GET", KEYS[2]) or "0")
+if tonumber(ARGV[3]) > 0 and created + count > tonumber(ARGV[3]) then
+	return 2
+end
+for i = 6, #ARGV do
+	redis.call("ZADD", KEYS[1], ARGV[5], ARGV[i])
+end
+redis.call("INCRBY", KEYS[2], count)
+redis.call("PEXPIRE", KEYS[2], ARGV[4])
+return 0
+`)
+
+// releaseScript removes the placeholders of a reservation and gives back the
+// creations it did not use, never going below zero.
+//
+// KEYS: workspace links, creations of the hour of the reservation.
+// ARGV: unused creations, then the placeholder members.
+var releaseScript = redis.NewScript(`
+for i = 2, #ARGV do
+	redis.call("ZREM", KEYS[1], ARGV[i])
+end
+local unused = tonumber(ARGV[1])
+local created = tonumber(redis.call("GET", KEYS[2]) or "0")
+if unused > 0 and created > 0 then
+	redis.call("DECRBY", KEYS[2], math.min(unused, created))
+end
+return 0
+`)
+
+// QuotaService enforces the quotas of workspaces: how many live links they
+// may have, read from the links the storage records for each workspace, and
+// how many they may create per clock hour.
+type QuotaService struct {
+	client ports.StorageClient
+}
+
+func NewQuotaService(client ports.StorageClient) *QuotaService {
+	return &QuotaService{
+		client: client,
+	}
+}
+
+// Reserve holds room in the quotas of workspace for as many new links as
+// links until Release is called. It returns domain.ErrQuotaExceeded, holding nothing,
+// when either quota has no room for them.
+func (s QuotaService) Reserve(ctx context.Context, workspace domain.Workspace, links int) (*domain.QuotaReservation, error) {
+	now := time.Now()
+	reservation := &domain.QuotaReservation{Workspace: workspace.ID, ID: newReservationID(), Links: links, At: now}
+	keys := []string{domain.WorkspaceLinksKey(workspace.ID), creationsKey(workspace.ID, now)}
+	args := []interface{}{now.UnixMilli(), workspace.MaxLinks, workspace.MaxCreationsPerHour,
+		creationsWindow.Milliseconds(), now.Add(reservationsTTL).UnixMilli()}
+	for _, member := range placeholders(reservation) {
+		args = append(args, member)
+	}
+	result, err := reserveScript.Run(ctx, s.client, keys, args...).Int()
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred reserving the quota | Workspace %s --> %w: %w",
+			workspace.ID, domain.ErrStorageUnavailable, err)
+	}
+
+	switch result {
+	case linksExceeded:
+		return nil, domain.Detailed(domain.ErrQuotaExceeded, "the workspace can have at most %d links", workspace.MaxLinks)
+	case creationsExceeded:
+		return nil, domain.Detailed(domain.ErrQuotaExceeded, "the workspace can create at most %d links per hour", workspace.MaxCreationsPerHour)
+	}
+	return reservation, nil
+}
+
+// Release ends reservation once its links are saved, or failed to be. The
+// saved links count as live links from then on by themselves; the unused
+// ones, those that failed, are given back to the hourly quota.
+func (s QuotaService) Release(ctx context.Context, reservation *domain.QuotaReservation, unused int) error {
+	keys := []string{domain.WorkspaceLinksKey(reservation.Workspace), creationsKey(reservation.Workspace, reservation.At)}
+	args := []interface{}{unused}
+	for _, member := range placeholders(reservation) {
+		args = append(args, member)
+	}
+	if err := releaseScript.Run(ctx, s.client, keys, args...).Err(); err != nil {
+		return fmt.Errorf("an error has occurred releasing the quota | Workspace %s --> %w: %w",
+			reservation.Workspace, domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// Usage reports the live links of workspace and its creations in the current
+// hour against its quotas.
+func (s QuotaService) Usage(ctx context.Context, workspace domain.Workspace) (*domain.WorkspaceUsage, error) {
+	now := time.Now()
+	linksKey := domain.WorkspaceLinksKey(workspace.ID)
+
+	var links *redis.IntCmd
+	var created *redis.StringCmd
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, linksKey, "-inf", strconv.FormatInt(now.UnixMilli(), 10))
+		links = pipe.ZCard(ctx, linksKey)
+		created = pipe.Get(ctx, creationsKey(workspace.ID, now))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("an error has occurred reading the usage | Workspace %s --> %w: %w",
+			workspace.ID, domain.ErrStorageUnavailable, err)
+	}
+
+	creations, err := created.Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("an error has occurred reading the usage | Workspace %s --> %w: %w",
+			workspace.ID, domain.ErrStorageUnavailable, err)
+	}
+
+	return &domain.WorkspaceUsage{
+		Workspace:           workspace.ID,
+		Links:               links.Val(),
+		MaxLinks:            workspace.MaxLinks,
+		CreationsThisHour:   creations,
+		MaxCreationsPerHour: workspace.MaxCreationsPerHour,
+	}, nil
+}
+
+// placeholders are the members standing for the links of reservation among
+// the live links of its workspace. They never collide with link keys, which
+// contain no spaces.
+func placeholders(reservation *domain.QuotaReservation) []string {
+	members := make([]string, reservation.Links)
+	for i := range members {
+		members[i] = "reservation " + reservation.ID + " " + strconv.Itoa(i)
+	}
+	return members
+}
+
+func newReservationID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+func creationsKey(workspace string, now time.Time) string {
+	return creationsKeyPrefix + workspace + ":" + strconv.FormatInt(now.Truncate(creationsWindow).Unix(), 10)
+}
@@ -0,0 +1,161 @@
+package quota_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/services/quota"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReserve(t *testing.T) {
+	ctx := context.Background()
+	live := float64(time.Now().Add(time.Hour).UnixMilli())
+	expired := float64(time.Now().Add(-time.Hour).UnixMilli())
+
+	tests := []struct {
+		name        string
+		workspace   domain.Workspace
+		links       map[string]float64
+		reserves    []int
+		expectedErr string
+	}{
+		{
+			name:      "WhenQuotasHaveRoom_ThenReserves",
+			workspace: domain.Workspace{ID: "marketing", MaxLinks: 3, MaxCreationsPerHour: 3},
+			links:     map[string]float64{"ws:marketing:a": live},
+			reserves:  []int{2},
+		},
+		{
+			name:        "WhenTheLinksWouldExceedTheQuota_ThenReturnsQuotaExceeded",
+			workspace:   domain.Workspace{ID: "marketing", MaxLinks: 2},
+			links:       map[string]float64{"ws:marketing:a": live, "ws:marketing:b": live},
+			reserves:    []int{1},
+			expectedErr: "workspace quota exceeded: the workspace can have at most 2 links",
+		},
+		{
+			name:      "WhenLinksHaveExpired_ThenTheyDoNotCount",
+			workspace: domain.Workspace{ID: "marketing", MaxLinks: 2},
+			links:     map[string]float64{"ws:marketing:a": expired, "ws:marketing:b": expired},
+			reserves:  []int{2},
+		},
+		{
+			name:        "WhenTheCreationsWouldExceedTheHourlyQuota_ThenReturnsQuotaExceeded",
+			workspace:   domain.Workspace{ID: "marketing", MaxCreationsPerHour: 3},
+			reserves:    []int{2, 2},
+			expectedErr: "workspace quota exceeded: the workspace can create at most 3 links per hour",
+		},
+		{
+			name:      "WhenQuotasAreUnlimited_ThenAlwaysReserves",
+			workspace: domain.Workspace{ID: "marketing"},
+			reserves:  []int{1000, 1000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+			for key, expiry := range tt.links {
+				_, err := server.ZAdd(domain.WorkspaceLinksKey(tt.workspace.ID), expiry, key)
+				assert.NoError(t, err)
+			}
+
+			service := quota.NewQuotaService(client)
+
+			var err error
+			for _, links := range tt.reserves {
+				_, err = service.Reserve(ctx, tt.workspace, links)
+			}
+			if tt.expectedErr != "" {
+				assert.ErrorIs(t, err, domain.ErrQuotaExceeded)
+				assert.EqualError(t, err, tt.expectedErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestUsage(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	workspace := domain.Workspace{ID: "marketing", MaxLinks: 10, MaxCreationsPerHour: 5}
+
+	service := quota.NewQuotaService(client)
+
+	usage, err := service.Usage(ctx, workspace)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.WorkspaceUsage{Workspace: "marketing", MaxLinks: 10, MaxCreationsPerHour: 5}, usage)
+
+	_, err = server.ZAdd(domain.WorkspaceLinksKey("marketing"), float64(time.Now().Add(time.Hour).UnixMilli()), "ws:marketing:a")
+	assert.NoError(t, err)
+	_, err = server.ZAdd(domain.WorkspaceLinksKey("marketing"), float64(time.Now().Add(-time.Hour).UnixMilli()), "ws:marketing:b")
+	assert.NoError(t, err)
+	_, err = service.Reserve(ctx, workspace, 2)
+	assert.NoError(t, err)
+
+	// The reserved links count as live until they are released.
+	usage, err = service.Usage(ctx, workspace)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.WorkspaceUsage{Workspace: "marketing", Links: 3, MaxLinks: 10,
+		CreationsThisHour: 2, MaxCreationsPerHour: 5}, usage)
+}
+
+func TestReserveWhenRequestsRace_ThenNeverExceedsTheQuota(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	workspace := domain.Workspace{ID: "marketing", MaxLinks: 5}
+
+	service := quota.NewQuotaService(client)
+
+	var reserved atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.Reserve(ctx, workspace, 1); err == nil {
+				reserved.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(5), reserved.Load())
+}
+
+func TestRelease(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	workspace := domain.Workspace{ID: "marketing", MaxLinks: 3, MaxCreationsPerHour: 3}
+
+	service := quota.NewQuotaService(client)
+
+	reservation, err := service.Reserve(ctx, workspace, 3)
+	assert.NoError(t, err)
+	_, err = service.Reserve(ctx, workspace, 1)
+	assert.ErrorIs(t, err, domain.ErrQuotaExceeded)
+
+	// One of the three links was saved, and added to the live links by the
+	// storage; the other two failed.
+	_, err = server.ZAdd(domain.WorkspaceLinksKey("marketing"), float64(time.Now().Add(time.Hour).UnixMilli()), "ws:marketing:a")
+	assert.NoError(t, err)
+	assert.NoError(t, service.Release(ctx, reservation, 2))
+
+	usage, err := service.Usage(ctx, workspace)
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.WorkspaceUsage{Workspace: "marketing", Links: 1, MaxLinks: 3,
+		CreationsThisHour: 1, MaxCreationsPerHour: 3}, usage)
+	_, err = service.Reserve(ctx, workspace, 2)
+	assert.NoError(t, err)
+}
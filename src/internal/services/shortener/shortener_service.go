@@ -4,18 +4,50 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"math/big"
+	"strconv"
 
+	"github.com/dariomba/url-shortener/src/internal/domain"
 	"github.com/itchyny/base58-go"
 )
 
-type ShortenerService struct{}
+// maxAttempts bounds how many times a code is re-derived when it collides
+// with a reserved word.
+const maxAttempts = 5
 
+type ShortenerService struct {
+	reserved *domain.ReservedWords
+}
+
+func NewShortenerService(reserved *domain.ReservedWords) *ShortenerService {
+	return &ShortenerService{
+		reserved: reserved,
+	}
+}
+
+// GenerateShortLink derives the code from a hash of the URL. When the code is
+// a reserved word the hash input is salted with the attempt number, so the
+// result stays deterministic for a given URL.
 func (s *ShortenerService) GenerateShortLink(originalURL string) (string, error) {
-	urlHashBytes := sha256Of(originalURL)
+	input := originalURL
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		code, err := shortCode(input)
+		if err != nil {
+			return "", fmt.Errorf("error generating the short url | OriginalURL %s --> %w: %w", originalURL, domain.ErrCodeGeneration, err)
+		}
+		if !s.reserved.Contains(code) {
+			return code, nil
+		}
+		input = originalURL + "#" + strconv.Itoa(attempt)
+	}
+	return "", fmt.Errorf("error generating the short url | OriginalURL %s --> %w: every candidate is reserved", originalURL, domain.ErrCodeGeneration)
+}
+
+func shortCode(input string) (string, error) {
+	urlHashBytes := sha256Of(input)
 	generatedNumber := new(big.Int).SetBytes(urlHashBytes).Uint64()
 	finalString, err := base58Encoded([]byte(fmt.Sprintf("%d", generatedNumber)))
 	if err != nil {
-		return "", fmt.Errorf("error generating the short url | OriginalURL %s --> %w", originalURL, err)
+		return "", err
 	}
 	return finalString[:8], nil
 }
@@ -3,6 +3,7 @@ package shortener_test
 import (
 	"testing"
 
+	"github.com/dariomba/url-shortener/src/internal/domain"
 	"github.com/dariomba/url-shortener/src/internal/services/shortener"
 	"github.com/stretchr/testify/assert"
 )
@@ -11,6 +12,7 @@ func TestGenerateShortLink(t *testing.T) {
 	tests := []struct {
 		name         string
 		originalURL  string
+		reserved     *domain.ReservedWords
 		expectedLink string
 	}{
 		{
@@ -18,11 +20,17 @@ func TestGenerateShortLink(t *testing.T) {
 			originalURL:  "https://github.com/dariomba/url-shortener/blob/master/cmd/main.go",
 			expectedLink: "CkpsxkQq",
 		},
+		{
+			name:         "WhenCodeIsReserved_ThenReturnsAnotherDeterministicLink",
+			originalURL:  "https://github.com/dariomba/url-shortener/blob/master/cmd/main.go",
+			reserved:     domain.NewReservedWords("CkpsxkQq"),
+			expectedLink: "fkrASGHr",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			shortener := shortener.ShortenerService{}
+			shortener := shortener.NewShortenerService(tt.reserved)
 			shortLink, _ := shortener.GenerateShortLink(tt.originalURL)
 			assert.Equal(t, tt.expectedLink, shortLink)
 		})
@@ -0,0 +1,318 @@
+package storage
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// listBatch is how many links a listing reads per round trip, and
+	// maxListed how many it reads at most before returning a page, full or
+	// not, with a cursor to continue.
+	listBatch = 200
+	maxListed = 10000
+)
+
+// indexLinks adds links to the indexes listings are read from, see
+// domain.LinkIndexKey, and to those of their tags and campaign. Links already
+// in the clicks index keep their clicks. Their destination is due a health
+// check right away, see domain.HealthScheduleKey, so that an edited link is
+// checked again, and their tombstone is written again, see buryLink.
+func (s StorageService) indexLinks(ctx context.Context, links ...domain.Link) error {
+	if len(links) == 0 {
+		return nil
+	}
+	now := time.Now()
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, link := range links {
+			created := now
+			if link.CreatedAt != nil {
+				created = *link.CreatedAt
+			}
+			entry := redis.Z{Score: float64(created.UnixMilli()), Member: link.Key()}
+			pipe.ZAdd(ctx, domain.LinkIndexKey(link.Workspace, domain.SortCreated), entry)
+			pipe.ZAddNX(ctx, domain.LinkIndexKey(link.Workspace, domain.SortClicks), redis.Z{Score: 0, Member: link.Key()})
+			for _, tag := range link.Tags {
+				pipe.ZAdd(ctx, domain.TagIndexKey(link.Workspace, tag), entry)
+			}
+			if link.Campaign != "" {
+				pipe.ZAdd(ctx, domain.CampaignIndexKey(link.Workspace, link.Campaign), entry)
+			}
+			pipe.ZAdd(ctx, domain.HealthScheduleKey, redis.Z{Score: float64(now.UnixMilli()), Member: link.Key()})
+			if err := buryLink(ctx, pipe, link); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("an error has occurred indexing the urls --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// ListLinks returns the links of query.Workspace that match query, newest or
+// most clicked first. The index of the query is read in batches, see
+// domain.LinkQuery.Index, and the other filters are applied to the links as
+// they are read, so a page may come back short, with a cursor, when few links
+// match. Links that expired since they were indexed, or left the tag or
+// campaign read, are dropped from the indexes on the way.
+func (s StorageService) ListLinks(ctx context.Context, query domain.LinkQuery) (*domain.LinkPage, error) {
+	index := query.Index()
+	scores := &redis.ZRangeBy{Min: "-inf", Max: "+inf", Count: listBatch}
+	if query.Sort == domain.SortCreated && query.CreatedFrom != nil {
+		scores.Min = strconv.FormatInt(query.CreatedFrom.UnixMilli(), 10)
+	}
+	if query.Sort == domain.SortCreated && query.CreatedTo != nil {
+		scores.Max = strconv.FormatInt(query.CreatedTo.UnixMilli(), 10)
+	}
+
+	var after *listCursor
+	if query.Cursor != "" {
+		cursor, err := decodeListCursor(query.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		after = &cursor
+		scores.Max = strconv.FormatFloat(cursor.score, 'f', -1, 64)
+		if scores.Offset, err = s.tiesBefore(ctx, index, cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	page := &domain.LinkPage{Links: []domain.ListedLink{}}
+	var missing, strays []string
+	defer func() { s.unindex(ctx, query.Workspace, index, missing, strays) }()
+
+	now := time.Now()
+	for scanned := 0; scanned < maxListed; scanned += listBatch {
+		entries, err := s.client.ZRevRangeByScoreWithScores(ctx, index, scores).Result()
+		if err != nil {
+			return nil, fmt.Errorf("an error has occurred listing the urls | Workspace %s --> %w: %w",
+				query.Workspace, domain.ErrStorageUnavailable, err)
+		}
+		scores.Offset += int64(len(entries))
+
+		listed, err := s.readListed(ctx, query, entries)
+		if err != nil {
+			return nil, err
+		}
+		for i, entry := range entries {
+			member, _ := entry.Member.(string)
+			last := listCursor{score: entry.Score, member: member}
+			if after != nil && last.notAfter(*after) {
+				continue
+			}
+			if listed[i] == nil {
+				missing = append(missing, member)
+				continue
+			}
+			if !belongs(index, query, listed[i].Link) {
+				strays = append(strays, member)
+				continue
+			}
+			if !query.Matches(*listed[i], now) {
+				continue
+			}
+			page.Links = append(page.Links, *listed[i])
+			if len(page.Links) == query.Limit {
+				page.NextCursor = last.encode()
+				return page, nil
+			}
+		}
+		if len(entries) < listBatch {
+			return page, nil
+		}
+		last := entries[len(entries)-1]
+		member, _ := last.Member.(string)
+		page.NextCursor = listCursor{score: last.Score, member: member}.encode()
+	}
+	return page, nil
+}
+
+// readListed reads the links of entries of the index of query, nil for
+// those that are gone.
+func (s StorageService) readListed(ctx context.Context, query domain.LinkQuery, entries []redis.Z) ([]*domain.ListedLink, error) {
+	values := make([]*redis.StringCmd, len(entries))
+	counters := make([]*redis.StringCmd, len(entries))
+	clicks := make([]*redis.FloatCmd, len(entries))
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, entry := range entries {
+			key, _ := entry.Member.(string)
+			values[i] = pipe.Get(ctx, key)
+			counters[i] = pipe.Get(ctx, clicksKeyPrefix+key)
+			if query.Sort != domain.SortClicks {
+				clicks[i] = pipe.ZScore(ctx, domain.LinkIndexKey(query.Workspace, domain.SortClicks), key)
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("an error has occurred listing the urls | Workspace %s --> %w: %w",
+			query.Workspace, domain.ErrStorageUnavailable, err)
+	}
+
+	listed := make([]*domain.ListedLink, len(entries))
+	for i, entry := range entries {
+		if values[i].Err() != nil {
+			continue
+		}
+		key, _ := entry.Member.(string)
+		link, err := decodeLink(key, values[i].Val())
+		if err != nil {
+			return nil, err
+		}
+		remaining, counterErr := counters[i].Int64()
+		listed[i] = &domain.ListedLink{
+			Link:      *link,
+			Clicks:    int64(entry.Score),
+			Exhausted: link.MaxClicks > 0 && (counterErr != nil || remaining <= 0),
+		}
+		if clicks[i] != nil {
+			listed[i].Clicks = int64(clicks[i].Val())
+		}
+	}
+	return listed, nil
+}
+
+// tiesBefore counts the entries of index that share the score of cursor and
+// come before it, up to and including it, which a listing continuing after
+// cursor skips. Entries are compared to cursor instead when it is no longer
+// in the index at its score.
+func (s StorageService) tiesBefore(ctx context.Context, index string, cursor listCursor) (int64, error) {
+	var score *redis.FloatCmd
+	var rank, above *redis.IntCmd
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		score = pipe.ZScore(ctx, index, cursor.member)
+		rank = pipe.ZRevRank(ctx, index, cursor.member)
+		above = pipe.ZCount(ctx, index, "("+strconv.FormatFloat(cursor.score, 'f', -1, 64), "+inf")
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("an error has occurred listing the urls --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	if score.Val() != cursor.score {
+		return 0, nil
+	}
+	return rank.Val() - above.Val() + 1, nil
+}
+
+// belongs reports whether link still has the tag, or is still in the
+// campaign, whose index it was read from.
+func belongs(index string, query domain.LinkQuery, link domain.Link) bool {
+	switch index {
+	case domain.TagIndexKey(query.Workspace, query.Tag):
+		return slices.Contains(link.Tags, query.Tag)
+	case domain.CampaignIndexKey(query.Workspace, query.Campaign):
+		return link.Campaign == query.Campaign
+	}
+	return true
+}
+
+// unindex drops the links under missing, which are gone, from the indexes of
+// workspace and from index, and strays from index only. It is best effort: a
+// link left behind is dropped by the next listing.
+func (s StorageService) unindex(ctx context.Context, workspace string, index string, missing []string, strays []string) {
+	if len(missing)+len(strays) == 0 {
+		return
+	}
+	_, _ = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		if len(missing) > 0 {
+			members := toMembers(missing)
+			pipe.ZRem(ctx, domain.LinkIndexKey(workspace, domain.SortCreated), members...)
+			pipe.ZRem(ctx, domain.LinkIndexKey(workspace, domain.SortClicks), members...)
+		}
+		pipe.ZRem(ctx, index, toMembers(append(missing, strays...))...)
+		return nil
+	})
+}
+
+func toMembers(keys []string) []interface{} {
+	members := make([]interface{}, len(keys))
+	for i, key := range keys {
+		members[i] = key
+	}
+	return members
+}
+
+// CampaignLinks returns every link of campaign in workspace, with its clicks.
+// Links that left the campaign are dropped from its index on the way.
+func (s StorageService) CampaignLinks(ctx context.Context, workspace string, campaign string) ([]domain.ListedLink, error) {
+	query := domain.LinkQuery{Workspace: workspace, Campaign: campaign, Sort: domain.SortCreated}
+	index := query.Index()
+	var missing, strays []string
+	defer func() { s.unindex(ctx, workspace, index, missing, strays) }()
+
+	links := []domain.ListedLink{}
+	scores := &redis.ZRangeBy{Min: "-inf", Max: "+inf", Count: listBatch}
+	for {
+		entries, err := s.client.ZRevRangeByScoreWithScores(ctx, index, scores).Result()
+		if err != nil {
+			return nil, fmt.Errorf("an error has occurred reading the campaign | Campaign %s --> %w: %w",
+				campaign, domain.ErrStorageUnavailable, err)
+		}
+		scores.Offset += int64(len(entries))
+
+		listed, err := s.readListed(ctx, query, entries)
+		if err != nil {
+			return nil, err
+		}
+		for i, entry := range entries {
+			member, _ := entry.Member.(string)
+			switch {
+			case listed[i] == nil:
+				missing = append(missing, member)
+			case !belongs(index, query, listed[i].Link):
+				strays = append(strays, member)
+			default:
+				links = append(links, *listed[i])
+			}
+		}
+		if len(entries) < listBatch {
+			return links, nil
+		}
+	}
+}
+
+// listCursor is the index entry a listing stopped at. Clients get it
+// encoded, as an opaque string.
+type listCursor struct {
+	score  float64
+	member string
+}
+
+func (c listCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatFloat(c.score, 'f', -1, 64) + "|" + c.member))
+}
+
+// notAfter reports whether c comes before other, or is other, in an index
+// read in reverse: higher scores first and, among equal scores, members in
+// reverse lexicographical order.
+func (c listCursor) notAfter(other listCursor) bool {
+	return c.score > other.score || (c.score == other.score && c.member >= other.member)
+}
+
+func decodeListCursor(cursor string) (listCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return listCursor{}, domain.Detailed(domain.ErrInvalidLinkQuery, "cursor is not valid")
+	}
+	rawScore, member, found := strings.Cut(string(decoded), "|")
+	score, err := strconv.ParseFloat(rawScore, 64)
+	if !found || err != nil {
+		return listCursor{}, domain.Detailed(domain.ErrInvalidLinkQuery, "cursor is not valid")
+	}
+	return listCursor{score: score, member: member}, nil
+}
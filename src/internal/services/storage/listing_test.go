@@ -0,0 +1,192 @@
+package storage_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/services/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func listedCodes(page *domain.LinkPage) []string {
+	codes := []string{}
+	for _, listed := range page.Links {
+		codes = append(codes, listed.Link.Code)
+	}
+	return codes
+}
+
+func TestListLinks(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	start := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	ended := time.Now().Add(-time.Hour)
+	links := []domain.Link{
+		{Code: "link0", OriginalURL: "https://shop.example.com/sale", CreatedBy: "ci", Note: "Printed on the spring flyer"},
+		{Code: "link1", OriginalURL: "https://blog.example.com/post", CreatedBy: "ops", Title: "Team Blog"},
+		{Code: "link2", OriginalURL: "https://shop.example.com/SUMMER", CreatedBy: "ci", ActiveUntil: &ended},
+		{Code: "link3", OriginalURL: "https://example.org", CreatedBy: "ci", Disabled: true},
+		{Code: "link4", OriginalURL: "https://shop.example.com/winter", CreatedBy: "ci", MaxClicks: 1},
+	}
+	for i, link := range links {
+		created := start.Add(time.Duration(i) * time.Minute)
+		link.Workspace = "marketing"
+		link.CreatedAt = &created
+		assert.NoError(t, service.SaveLink(ctx, link))
+	}
+	_, err := service.ConsumeClick(ctx, "ws:marketing:link4")
+	assert.NoError(t, err)
+	assert.NoError(t, client.ZIncrBy(ctx, domain.LinkIndexKey("marketing", domain.SortClicks), 7, "ws:marketing:link1").Err())
+	assert.NoError(t, service.SaveLink(ctx, domain.Link{Code: "other", OriginalURL: "https://shop.example.com", Workspace: "sales"}))
+
+	from, to := start.Add(time.Minute), start.Add(3*time.Minute)
+	tests := []struct {
+		name  string
+		query domain.LinkQuery
+		want  []string
+	}{
+		{name: "WhenNoFilterIsSet_ThenListsTheNewestFirst", query: domain.LinkQuery{}, want: []string{"link4", "link3", "link2", "link1", "link0"}},
+		{name: "WhenSortedByClicks_ThenListsTheMostClickedFirst", query: domain.LinkQuery{Sort: domain.SortClicks, Limit: 2}, want: []string{"link1", "link4"}},
+		{name: "WhenFilteredByOwner_ThenListsTheirLinks", query: domain.LinkQuery{Owner: "ops"}, want: []string{"link1"}},
+		{name: "WhenFilteredByCreationTime_ThenListsTheLinksInRange", query: domain.LinkQuery{CreatedFrom: &from, CreatedTo: &to}, want: []string{"link3", "link2", "link1"}},
+		{name: "WhenFilteredByHostAndSearched_ThenMatchesTheDestination", query: domain.LinkQuery{Host: "Shop.Example.com", Search: "summer"}, want: []string{"link2"}},
+		{name: "WhenSearched_ThenMatchesTitlesAndNotesToo", query: domain.LinkQuery{Search: "team BLOG"}, want: []string{"link1"}},
+		{name: "WhenSearchedForANote_ThenListsItsLink", query: domain.LinkQuery{Search: "flyer"}, want: []string{"link0"}},
+		{name: "WhenFilteredByExpired_ThenListsEndedAndExhaustedLinks", query: domain.LinkQuery{Status: domain.StatusExpired}, want: []string{"link4", "link2"}},
+		{name: "WhenFilteredByActive_ThenLeavesOutDisabledLinks", query: domain.LinkQuery{Status: domain.StatusActive}, want: []string{"link1", "link0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := tt.query
+			query.Workspace = "marketing"
+			assert.NoError(t, query.Validate())
+
+			page, err := service.ListLinks(ctx, query)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, listedCodes(page))
+		})
+	}
+
+	page, err := service.ListLinks(ctx, domain.LinkQuery{Workspace: "marketing", Sort: domain.SortClicks, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), page.Links[0].Clicks)
+	assert.True(t, page.Links[1].Exhausted)
+}
+
+func TestListLinksWhenPaging_ThenContinuesAfterTheCursorAcrossTies(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	var want []string
+	for i := 0; i < 450; i++ {
+		code := fmt.Sprintf("link%03d", i)
+		assert.NoError(t, service.SaveLink(ctx, domain.Link{Code: code, OriginalURL: "https://example.com"}))
+		want = append([]string{code}, want...)
+	}
+	server.Del("link100")
+
+	var got []string
+	query := domain.LinkQuery{Sort: domain.SortClicks, Limit: 150}
+	for {
+		page, err := service.ListLinks(ctx, query)
+		assert.NoError(t, err)
+		got = append(got, listedCodes(page)...)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	assert.Equal(t, slices.DeleteFunc(want, func(code string) bool { return code == "link100" }), got)
+	members, err := client.ZCard(ctx, domain.LinkIndexKey("", domain.SortClicks)).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(449), members)
+
+	_, err = service.ListLinks(ctx, domain.LinkQuery{Sort: domain.SortCreated, Limit: 1, Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, domain.ErrInvalidLinkQuery)
+}
+
+func TestListLinksWhenFilteredByTagOrCampaign_ThenDropsLinksThatLeftIt(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	start := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	links := []domain.Link{
+		{Code: "link0", OriginalURL: "https://example.com/0", Tags: []string{"summer", "email"}, Campaign: "launch"},
+		{Code: "link1", OriginalURL: "https://example.com/1", Tags: []string{"summer"}},
+		{Code: "link2", OriginalURL: "https://example.com/2", Campaign: "launch"},
+	}
+	for i, link := range links {
+		created := start.Add(time.Duration(i) * time.Minute)
+		link.CreatedAt = &created
+		assert.NoError(t, service.SaveLink(ctx, link))
+		links[i] = link
+	}
+
+	list := func(query domain.LinkQuery) []string {
+		assert.NoError(t, query.Validate())
+		page, err := service.ListLinks(ctx, query)
+		assert.NoError(t, err)
+		return listedCodes(page)
+	}
+	assert.Equal(t, []string{"link1", "link0"}, list(domain.LinkQuery{Tag: "summer"}))
+
+	updateLink(t, service, links[1].Key(), func(link *domain.Link) { link.Tags = nil })
+	updateLink(t, service, links[0].Key(), func(link *domain.Link) { link.Campaign = "relaunch" })
+
+	assert.Equal(t, []string{"link0"}, list(domain.LinkQuery{Tag: "summer"}))
+	assert.Equal(t, []string{"link2"}, list(domain.LinkQuery{Campaign: "launch", Sort: domain.SortClicks}))
+	assert.Equal(t, []string{"link0"}, list(domain.LinkQuery{Campaign: "relaunch"}))
+
+	members, err := client.ZRange(ctx, domain.TagIndexKey("", "summer"), 0, -1).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"link0"}, members)
+}
+
+func TestCampaignLinks(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	for i := 0; i < 250; i++ {
+		link := domain.Link{Code: fmt.Sprintf("link%03d", i), OriginalURL: "https://example.com", Workspace: "marketing", Campaign: "launch"}
+		assert.NoError(t, service.SaveLink(ctx, link))
+	}
+	assert.NoError(t, service.SaveLink(ctx, domain.Link{Code: "other", OriginalURL: "https://example.com", Workspace: "sales", Campaign: "launch"}))
+	assert.NoError(t, client.ZIncrBy(ctx, domain.LinkIndexKey("marketing", domain.SortClicks), 3, "ws:marketing:link007").Err())
+	assert.NoError(t, service.DeleteLink(ctx, domain.Link{Code: "link001", Workspace: "marketing", Campaign: "launch"}))
+	server.Del("ws:marketing:link002")
+
+	links, err := service.CampaignLinks(ctx, "marketing", "launch")
+	assert.NoError(t, err)
+	assert.Len(t, links, 248)
+	for _, listed := range links {
+		assert.Equal(t, "marketing", listed.Link.Workspace)
+		if listed.Link.Code == "link007" {
+			assert.Equal(t, int64(3), listed.Clicks)
+		}
+	}
+
+	members, err := client.ZCard(ctx, domain.CampaignIndexKey("marketing", "launch")).Result()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(248), members)
+
+	links, err = service.CampaignLinks(ctx, "marketing", "unknown")
+	assert.NoError(t, err)
+	assert.Empty(t, links)
+}
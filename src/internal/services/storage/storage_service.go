@@ -2,13 +2,120 @@ package storage
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/dariomba/url-shortener/src/internal/domain"
 	"github.com/dariomba/url-shortener/src/internal/ports"
+	"github.com/redis/go-redis/v9"
 )
 
-const CacheDuration = 8 * time.Hour
+const (
+	CacheDuration = 8 * time.Hour
+	// clicksKeyPrefix holds the remaining clicks of links with MaxClicks.
+	clicksKeyPrefix = "clicks:"
+	// scanBatch is how many keys each SCAN call looks at.
+	scanBatch = 1000
+	// versionsKeyPrefix holds the past versions of each link, newest first.
+	versionsKeyPrefix = "versions:"
+)
+
+// consumeClickScript takes one click from a counter and returns what is left,
+// or -1 when the counter is exhausted or gone. Running it as a script makes
+// the check and the decrement atomic, so concurrent redirects can never go
+// over the limit.
+var consumeClickScript = redis.NewScript(`
+local remaining = tonumber(redis.call("GET", KEYS[1]))
+if remaining == nil or remaining <= 0 then
+	return -1
+end
+return redis.call("DECR", KEYS[1])
+`)
+
+// saveScopedLinkScript saves a link of a workspace under its workspace key
+// and points its address, the key redirects look up, to the workspace. The
+// pointer claims the code for the workspace: the script returns 0 without
+// writing when the address already belongs to another workspace or to a link
+// outside any, or holds anything at all and the link is an alias. It also
+// records the link among the live links of the workspace, for its quota.
+//
+// KEYS: address, link key, workspace links.
+// ARGV: pointer, link, TTL in milliseconds, "1" for aliases, expiry time in
+// Unix milliseconds.
+var saveScopedLinkScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if current and (current ~= ARGV[1] or ARGV[4] == "1") then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[3])
+redis.call("SET", KEYS[2], ARGV[2], "PX", ARGV[3])
+redis.call("ZADD", KEYS[3], ARGV[5], KEYS[2])
+return 1
+`)
+
+// reviseLinkScript replaces a link with its next version and keeps the one it
+// replaces in the history of the link, trimmed to the newest ones. It returns
+// -1 when the link is gone and 0 without writing when the link is no longer
+// at the version it was read at, so that concurrent changes are never lost.
+// A version with another activation window moves the expiry of the link, and
+// of the keys expiring with it, by the change of lifetime. The history
+// expires with the link.
+//
+// KEYS: link key, versions key, clicks key, address, workspace links.
+// ARGV: link, previous version number, previous version, versions kept,
+// change of lifetime in milliseconds, current time in Unix milliseconds.
+var reviseLinkScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return -1
+end
+local version = 1
+if string.sub(current, 1, 1) == "{" then
+	version = cjson.decode(current).version or 1
+end
+if version ~= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "KEEPTTL")
+redis.call("LPUSH", KEYS[2], ARGV[3])
+redis.call("LTRIM", KEYS[2], 0, tonumber(ARGV[4]) - 1)
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl > 0 and tonumber(ARGV[5]) ~= 0 then
+	ttl = math.max(ttl + tonumber(ARGV[5]), 1)
+	redis.call("PEXPIRE", KEYS[1], ttl)
+	redis.call("PEXPIRE", KEYS[3], ttl)
+	if KEYS[4] ~= KEYS[1] then
+		redis.call("PEXPIRE", KEYS[4], ttl)
+		redis.call("ZADD", KEYS[5], "XX", tonumber(ARGV[6]) + ttl, KEYS[1])
+	end
+end
+if ttl > 0 then
+	redis.call("PEXPIRE", KEYS[2], ttl)
+end
+return 1
+`)
+
+// replaceLinkScript replaces a link with another value, keeping its expiry,
+// only when it still holds the value it was read with. It returns -1 when the
+// link is gone and 0 without writing when it was changed meanwhile.
+//
+// KEYS: link key.
+// ARGV: value read, new value.
+var replaceLinkScript = redis.NewScript(`
+local current = redis.call("GET", KEYS[1])
+if not current then
+	return -1
+end
+if current ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL")
+return 1
+`)
 
 type StorageService struct {
 	client ports.StorageClient
@@ -20,18 +127,490 @@ func NewStorageService(client ports.StorageClient) *StorageService {
 	}
 }
 
-func (s StorageService) SaveURL(ctx context.Context, shortURL string, originalURL string) error {
-	err := s.client.Set(ctx, shortURL, originalURL, CacheDuration).Err()
+// SaveLink stores a single link. Aliases are written with SETNX so they never
+// overwrite an existing code. Links of a workspace are kept in its namespace,
+// see saveScopedLinkScript. Links without a creation time are stamped with
+// the current one.
+func (s StorageService) SaveLink(ctx context.Context, link domain.Link) error {
+	stampCreation(&link, time.Now())
+	value, err := encodeLink(link)
+	if err != nil {
+		return err
+	}
+
+	if link.Workspace != "" {
+		keys, args, err := scopedLinkArgs(link, value)
+		if err != nil {
+			return err
+		}
+		saved, err := saveScopedLinkScript.Run(ctx, s.client, keys, args...).Int64()
+		if err != nil {
+			return fmt.Errorf("an error has occurred saving the url --> %w: %w", domain.ErrStorageUnavailable, err)
+		}
+		if saved == 0 {
+			return codeTaken(link)
+		}
+		return s.finishSave(ctx, link)
+	}
+
+	if link.Alias {
+		saved, err := s.client.SetNX(ctx, link.Key(), value, linkTTL(link)).Result()
+		if err != nil {
+			return fmt.Errorf("an error has occurred saving the url --> %w: %w", domain.ErrStorageUnavailable, err)
+		}
+		if !saved {
+			return domain.ErrAliasTaken
+		}
+		return s.finishSave(ctx, link)
+	}
+
+	err = s.client.Set(ctx, link.Key(), value, linkTTL(link)).Err()
+	if err != nil {
+		return fmt.Errorf("an error has occurred saving the url --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	return s.finishSave(ctx, link)
+}
+
+// finishSave sets up the click counter of a link just saved and indexes it
+// for listings.
+func (s StorageService) finishSave(ctx context.Context, link domain.Link) error {
+	if err := s.saveClickCounter(ctx, link); err != nil {
+		return err
+	}
+	return s.indexLinks(ctx, link)
+}
+
+func (s StorageService) saveClickCounter(ctx context.Context, link domain.Link) error {
+	if link.MaxClicks <= 0 {
+		return nil
+	}
+	err := s.client.Set(ctx, clicksKeyPrefix+link.Key(), link.MaxClicks, linkTTL(link)).Err()
+	if err != nil {
+		return fmt.Errorf("an error has occurred saving the click limit | Code %s --> %w: %w",
+			link.Code, domain.ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// SaveURLs stores every link in a single pipelined round trip. The returned
+// slice holds one entry per link, nil when that link was saved. Aliases are
+// written with SETNX so they never overwrite an existing code.
+func (s StorageService) SaveURLs(ctx context.Context, links []domain.Link) []error {
+	links = slices.Clone(links)
+	now := time.Now()
+	for i := range links {
+		stampCreation(&links[i], now)
+	}
+
+	cmds := make([]redis.Cmder, len(links))
+	_, pipeErr := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, link := range links {
+			value, err := encodeLink(link)
+			if err != nil {
+				return err
+			}
+			switch {
+			case link.Workspace != "":
+				keys, args, err := scopedLinkArgs(link, value)
+				if err != nil {
+					return err
+				}
+				cmds[i] = saveScopedLinkScript.Eval(ctx, pipe, keys, args...)
+			case link.Alias:
+				cmds[i] = pipe.SetNX(ctx, link.Key(), value, linkTTL(link))
+			default:
+				cmds[i] = pipe.Set(ctx, link.Key(), value, linkTTL(link))
+			}
+		}
+		return nil
+	})
+
+	// Pipelined reports the first failed command, but when the round trip
+	// itself fails no command carries an error and every link must fail.
+	if pipeErr != nil && !anyCmdFailed(cmds) {
+		errs := make([]error, len(links))
+		for i := range links {
+			errs[i] = fmt.Errorf("an error has occurred saving the url | Code %s --> %w: %w",
+				links[i].Code, domain.ErrStorageUnavailable, pipeErr)
+		}
+		return errs
+	}
+
+	errs := make([]error, len(links))
+	for i, cmd := range cmds {
+		if err := cmd.Err(); err != nil {
+			errs[i] = fmt.Errorf("an error has occurred saving the url | Code %s --> %w: %w",
+				links[i].Code, domain.ErrStorageUnavailable, err)
+			continue
+		}
+		if boolCmd, ok := cmd.(*redis.BoolCmd); ok && !boolCmd.Val() {
+			errs[i] = domain.ErrAliasTaken
+		}
+		if scriptCmd, ok := cmd.(*redis.Cmd); ok {
+			if saved, _ := scriptCmd.Int64(); saved == 0 {
+				errs[i] = codeTaken(links[i])
+			}
+		}
+	}
+
+	var saved []domain.Link
+	for i, link := range links {
+		if errs[i] == nil {
+			saved = append(saved, link)
+		}
+	}
+	if err := s.indexLinks(ctx, saved...); err != nil {
+		for i := range links {
+			if errs[i] == nil {
+				errs[i] = err
+			}
+		}
+	}
+	return errs
+}
+
+// scopedLinkArgs builds the keys and arguments of saveScopedLinkScript.
+func scopedLinkArgs(link domain.Link, value string) ([]string, []interface{}, error) {
+	pointer, err := encodeLink(domain.Link{Code: link.Code, Workspace: link.Workspace})
+	if err != nil {
+		return nil, nil, err
+	}
+	alias := "0"
+	if link.Alias {
+		alias = "1"
+	}
+	ttl := linkTTL(link)
+	keys := []string{link.Address(), link.Key(), domain.WorkspaceLinksKey(link.Workspace)}
+	args := []interface{}{pointer, value, ttl.Milliseconds(), alias, time.Now().Add(ttl).UnixMilli()}
+	return keys, args, nil
+}
+
+// codeTaken is the error of a link whose address is already in use. Generated
+// codes are seeded with the workspace, so they only clash on a hash collision.
+func codeTaken(link domain.Link) error {
+	if link.Alias {
+		return domain.ErrAliasTaken
+	}
+	return fmt.Errorf("the code %s is taken by another workspace --> %w", link.Code, domain.ErrCodeGeneration)
+}
+
+func anyCmdFailed(cmds []redis.Cmder) bool {
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateLink replaces previous, a link as it was read, with link, keeping
+// its expiry, and indexes it under its tags and campaign. It returns
+// domain.ErrLinkChanged when the link was changed since it was read and
+// domain.ErrLinkNotFound when it is gone.
+func (s StorageService) UpdateLink(ctx context.Context, link domain.Link, previous domain.Link) error {
+	read, err := encodeLink(previous)
+	if err != nil {
+		return err
+	}
+	value, err := s.client.Get(ctx, link.Key()).Result()
+	if errors.Is(err, redis.Nil) {
+		return fmt.Errorf("an error has occurred updating the url | Code %s --> %w", link.Code, domain.ErrLinkNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("an error has occurred updating the url --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	current, err := decodeLink(link.Key(), value)
+	if err != nil {
+		return err
+	}
+	stored, err := encodeLink(*current)
+	if err != nil {
+		return err
+	}
+	if stored != read {
+		return fmt.Errorf("an error has occurred updating the url | Code %s --> %w", link.Code, domain.ErrLinkChanged)
+	}
+
+	updated, err := encodeLink(link)
+	if err != nil {
+		return err
+	}
+	replaced, err := replaceLinkScript.Run(ctx, s.client, []string{link.Key()}, value, updated).Int()
+	if err != nil {
+		return fmt.Errorf("an error has occurred updating the url --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	switch replaced {
+	case -1:
+		return fmt.Errorf("an error has occurred updating the url | Code %s --> %w", link.Code, domain.ErrLinkNotFound)
+	case 0:
+		return fmt.Errorf("an error has occurred updating the url | Code %s --> %w", link.Code, domain.ErrLinkChanged)
+	}
+	return s.indexLinks(ctx, link)
+}
+
+// SetLinkTitle gives title to the link stored under key, as long as it still
+// has no title and still goes to url, the page the title was read from. It
+// returns domain.ErrLinkChanged when either changed and
+// domain.ErrLinkNotFound when the link is gone.
+func (s StorageService) SetLinkTitle(ctx context.Context, key string, url string, title string) error {
+	value, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return fmt.Errorf("an error has occurred titling the url | Code %s --> %w", key, domain.ErrLinkNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("an error has occurred titling the url --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	link, err := decodeLink(key, value)
+	if err != nil {
+		return err
+	}
+	if link.Title != "" || link.OriginalURL != url {
+		return fmt.Errorf("an error has occurred titling the url | Code %s --> %w", key, domain.ErrLinkChanged)
+	}
+
+	link.Title = title
+	titled, err := encodeLink(*link)
+	if err != nil {
+		return err
+	}
+	replaced, err := replaceLinkScript.Run(ctx, s.client, []string{key}, value, titled).Int()
+	if err != nil {
+		return fmt.Errorf("an error has occurred titling the url --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	switch replaced {
+	case -1:
+		return fmt.Errorf("an error has occurred titling the url | Code %s --> %w", key, domain.ErrLinkNotFound)
+	case 0:
+		return fmt.Errorf("an error has occurred titling the url | Code %s --> %w", key, domain.ErrLinkChanged)
+	}
+	return nil
+}
+
+// ReviseLink saves link, the next version of a link read at version
+// previous, keeps previous in its history and indexes link like UpdateLink.
+// When the activation window changes, the link is kept for the lifetime it
+// had left after the previous one, see linkTTL. It returns
+// domain.ErrLinkChanged when the link was changed since it was read and
+// domain.ErrLinkNotFound when it is gone.
+func (s StorageService) ReviseLink(ctx context.Context, link domain.Link, previous domain.LinkVersion) error {
+	value, err := encodeLink(link)
 	if err != nil {
-		return fmt.Errorf("an error has ocurred saving the url --> %w", err)
+		return err
+	}
+	version, err := json.Marshal(previous)
+	if err != nil {
+		return fmt.Errorf("encoding the link version | Code %s --> %w", link.Code, err)
+	}
+
+	now := time.Now()
+	change := scheduleWait(link.ActiveFrom, link.ActiveUntil, now) - scheduleWait(previous.ActiveFrom, previous.ActiveUntil, now)
+	keys := []string{link.Key(), versionsKeyPrefix + link.Key(), clicksKeyPrefix + link.Key(), link.Address(),
+		domain.WorkspaceLinksKey(link.Workspace)}
+	saved, err := reviseLinkScript.Run(ctx, s.client, keys, value, previous.Version, string(version), domain.MaxLinkVersions,
+		change.Milliseconds(), now.UnixMilli()).Int()
+	if err != nil {
+		return fmt.Errorf("an error has occurred revising the url | Code %s --> %w: %w", link.Code, domain.ErrStorageUnavailable, err)
+	}
+	switch saved {
+	case -1:
+		return fmt.Errorf("an error has occurred revising the url | Code %s --> %w", link.Code, domain.ErrLinkNotFound)
+	case 0:
+		return fmt.Errorf("an error has occurred revising the url | Code %s --> %w", link.Code, domain.ErrLinkChanged)
+	}
+	return s.indexLinks(ctx, link)
+}
+
+// LinkVersions returns the past versions of link, newest first. The current
+// version is the link itself.
+func (s StorageService) LinkVersions(ctx context.Context, link domain.Link) ([]domain.LinkVersion, error) {
+	values, err := s.client.LRange(ctx, versionsKeyPrefix+link.Key(), 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred retrieving the versions | Code %s --> %w: %w",
+			link.Code, domain.ErrStorageUnavailable, err)
+	}
+
+	versions := make([]domain.LinkVersion, 0, len(values))
+	for _, value := range values {
+		var version domain.LinkVersion
+		if err := json.Unmarshal([]byte(value), &version); err != nil {
+			return nil, fmt.Errorf("decoding the link version | Code %s --> %w", link.Code, err)
+		}
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// DeleteLink removes link, its click counter, its history, its health and its
+// tombstone, and drops it from the listings and the health checks. A link of
+// a workspace also releases its address and stops counting against the quota
+// of the workspace.
+func (s StorageService) DeleteLink(ctx context.Context, link domain.Link) error {
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, link.Key(), clicksKeyPrefix+link.Key(), versionsKeyPrefix+link.Key(), domain.HealthKey(link.Key()),
+			tombstoneKeyPrefix+link.Address())
+		pipe.ZRem(ctx, domain.LinkIndexKey(link.Workspace, domain.SortCreated), link.Key())
+		pipe.ZRem(ctx, domain.LinkIndexKey(link.Workspace, domain.SortClicks), link.Key())
+		for _, tag := range link.Tags {
+			pipe.ZRem(ctx, domain.TagIndexKey(link.Workspace, tag), link.Key())
+		}
+		if link.Campaign != "" {
+			pipe.ZRem(ctx, domain.CampaignIndexKey(link.Workspace, link.Campaign), link.Key())
+		}
+		pipe.ZRem(ctx, domain.HealthScheduleKey, link.Key())
+		pipe.ZRem(ctx, domain.BrokenIndexKey(link.Workspace), link.Key())
+		if link.Workspace != "" {
+			pipe.Del(ctx, link.Address())
+			pipe.ZRem(ctx, domain.WorkspaceLinksKey(link.Workspace), link.Key())
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("an error has occurred deleting the url | Code %s --> %w: %w", link.Code, domain.ErrStorageUnavailable, err)
 	}
 	return nil
 }
 
+// GetLink returns the link stored under key, see domain.LinkKey and
+// domain.WorkspaceKey. The address of a link of a workspace leads to the link
+// itself.
+func (s StorageService) GetLink(ctx context.Context, key string) (*domain.Link, error) {
+	value, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("an error has occurred retrieving the url | Code %s --> %w", key, domain.ErrLinkNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred retrieving the url --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	link, err := decodeLink(key, value)
+	if err != nil {
+		return nil, err
+	}
+	if workspace, _ := domain.SplitWorkspaceKey(key); workspace == "" && link.Workspace != "" {
+		return s.GetLink(ctx, link.Key())
+	}
+	return link, nil
+}
+
 func (s StorageService) GetURL(ctx context.Context, shortURL string) (string, error) {
-	url, err := s.client.Get(ctx, shortURL).Result()
+	link, err := s.GetLink(ctx, shortURL)
 	if err != nil {
-		return "", fmt.Errorf("an error has ocurred retrieving the url --> %w", err)
+		return "", err
+	}
+	return link.OriginalURL, nil
+}
+
+// FindCodes returns the codes stored on the domain with namespace that equal
+// code when case is ignored.
+func (s StorageService) FindCodes(ctx context.Context, namespace string, code string) ([]string, error) {
+	var codes []string
+	iter := s.client.Scan(ctx, 0, foldPattern(domain.LinkKey(namespace, code)), scanBatch).Iterator()
+	for iter.Next(ctx) {
+		_, found := domain.SplitLinkKey(iter.Val())
+		codes = append(codes, found)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("an error has occurred looking for the code %s --> %w: %w", code, domain.ErrStorageUnavailable, err)
+	}
+	return codes, nil
+}
+
+// foldPattern is a SCAN pattern matching key exactly but in any case.
+func foldPattern(key string) string {
+	var pattern strings.Builder
+	for _, r := range key {
+		lower, upper := strings.ToLower(string(r)), strings.ToUpper(string(r))
+		switch {
+		case lower != upper:
+			pattern.WriteString("[" + lower + upper + "]")
+		case strings.ContainsRune(`*?[]\`, r):
+			pattern.WriteString(`\` + string(r))
+		default:
+			pattern.WriteRune(r)
+		}
+	}
+	return pattern.String()
+}
+
+// ConsumeClick takes one click from the link with MaxClicks stored under key
+// and returns how many are left. It returns domain.ErrLinkExhausted once none
+// are.
+func (s StorageService) ConsumeClick(ctx context.Context, key string) (int64, error) {
+	remaining, err := consumeClickScript.Run(ctx, s.client, []string{clicksKeyPrefix + key}).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("an error has occurred consuming a click | Code %s --> %w: %w", key, domain.ErrStorageUnavailable, err)
+	}
+	if remaining < 0 {
+		return 0, domain.ErrLinkExhausted
+	}
+	return remaining, nil
+}
+
+// RemainingClicks returns how many clicks the link with MaxClicks stored under
+// key has left.
+func (s StorageService) RemainingClicks(ctx context.Context, key string) (int64, error) {
+	remaining, err := s.client.Get(ctx, clicksKeyPrefix+key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("an error has occurred retrieving the remaining clicks | Code %s --> %w: %w",
+			key, domain.ErrStorageUnavailable, err)
+	}
+	return remaining, nil
+}
+
+// linkTTL is how long a link is kept. The lifetime of a scheduled link counts
+// from the end of its window, or from its start when it has no end, so that
+// it is still there to answer when the window opens and after it closes.
+func linkTTL(link domain.Link) time.Duration {
+	ttl := link.TTL
+	if ttl <= 0 {
+		ttl = CacheDuration
+	}
+	return ttl + scheduleWait(link.ActiveFrom, link.ActiveUntil, time.Now())
+}
+
+// scheduleWait is how long after now the lifetime of a link with the
+// activation window from, until starts counting, see linkTTL.
+func scheduleWait(from *time.Time, until *time.Time, now time.Time) time.Duration {
+	switch {
+	case until != nil:
+		return max(until.Sub(now), 0)
+	case from != nil:
+		return max(from.Sub(now), 0)
+	}
+	return 0
+}
+
+func stampCreation(link *domain.Link, now time.Time) {
+	if link.CreatedAt == nil {
+		created := now.UTC().Truncate(time.Millisecond)
+		link.CreatedAt = &created
+	}
+}
+
+func encodeLink(link domain.Link) (string, error) {
+	value, err := json.Marshal(link)
+	if err != nil {
+		return "", fmt.Errorf("encoding the link | Code %s --> %w", link.Code, err)
+	}
+	return string(value), nil
+}
+
+// decodeLink reads the link stored under key. Links saved before links became
+// JSON records hold the bare original URL, which is read as a public link
+// shared by everyone shortening that URL, as they all were.
+func decodeLink(key string, value string) (*domain.Link, error) {
+	_, address := domain.SplitWorkspaceKey(key)
+	namespace, code := domain.SplitLinkKey(address)
+	if !strings.HasPrefix(value, "{") {
+		return &domain.Link{Code: code, Domain: namespace, OriginalURL: value, Shared: true}, nil
+	}
+	var link domain.Link
+	if err := json.Unmarshal([]byte(value), &link); err != nil {
+		return nil, fmt.Errorf("decoding the link | Code %s --> %w", key, err)
 	}
-	return url, nil
+	link.Code = code
+	link.Domain = namespace
+	return &link, nil
 }
@@ -3,8 +3,14 @@ package storage_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dariomba/url-shortener/src/internal/domain"
 	"github.com/dariomba/url-shortener/src/internal/mocks"
 	"github.com/dariomba/url-shortener/src/internal/services/storage"
 	"github.com/golang/mock/gomock"
@@ -16,39 +22,59 @@ type mocksStorage struct {
 	storageClient *mocks.MockStorageClient
 }
 
-func TestSaveURL(t *testing.T) {
+func TestSaveLink(t *testing.T) {
 	ctx := context.Background()
 
-	shortURL := "http://example.com/jhdsjkfh3"
-	ogURL := "http://original.url.domain.too.long.url/directory/other/files/example/file"
+	created := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	link := domain.Link{Code: "jhdsjkfh3", OriginalURL: "http://original.url.domain.too.long.url/directory/other/files/example/file", CreatedAt: &created}
+	value := `{"code":"jhdsjkfh3","url":"http://original.url.domain.too.long.url/directory/other/files/example/file","created_at":"2030-06-01T09:00:00Z"}`
 
 	tests := []struct {
 		name        string
-		shortURL    string
-		originalURL string
-		expectError bool
+		link        domain.Link
+		expectedErr error
 		mocks       func(m mocksStorage)
 	}{
 		{
 			name:        "WhenSetFails_ThenReturnsError",
-			shortURL:    shortURL,
-			originalURL: ogURL,
-			expectError: true,
+			link:        link,
+			expectedErr: domain.ErrStorageUnavailable,
 			mocks: func(m mocksStorage) {
 				statusCmd := redis.NewStatusCmd(ctx)
 				statusCmd.SetErr(errors.New("weird error"))
-				m.storageClient.EXPECT().Set(ctx, shortURL, ogURL, storage.CacheDuration).Return(statusCmd)
+				m.storageClient.EXPECT().Set(ctx, link.Code, value, storage.CacheDuration).Return(statusCmd)
 			},
 		},
 		{
-			name:        "WhenEverythingOK_ThenReturnsNil",
-			shortURL:    shortURL,
-			originalURL: ogURL,
-			expectError: false,
+			name: "WhenEverythingOK_ThenReturnsNil",
+			link: link,
+			mocks: func(m mocksStorage) {
+				statusCmd := redis.NewStatusCmd(ctx)
+				statusCmd.SetVal("OK")
+				m.storageClient.EXPECT().Set(ctx, link.Code, value, storage.CacheDuration).Return(statusCmd)
+				m.storageClient.EXPECT().Pipelined(ctx, gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name: "WhenLinkIsProtected_ThenStoresThePasswordHash",
+			link: domain.Link{Code: "secret", OriginalURL: "http://example.com", CreatedAt: &created, PasswordHash: "$2a$10$hash", TTL: time.Hour},
 			mocks: func(m mocksStorage) {
 				statusCmd := redis.NewStatusCmd(ctx)
 				statusCmd.SetVal("OK")
-				m.storageClient.EXPECT().Set(ctx, shortURL, ogURL, storage.CacheDuration).Return(statusCmd)
+				m.storageClient.EXPECT().Set(ctx, "secret",
+					`{"code":"secret","url":"http://example.com","created_at":"2030-06-01T09:00:00Z","password_hash":"$2a$10$hash"}`, time.Hour).Return(statusCmd)
+				m.storageClient.EXPECT().Pipelined(ctx, gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name:        "WhenAliasIsTaken_ThenReturnsErrAliasTaken",
+			link:        domain.Link{Code: "taken", OriginalURL: "http://example.com", CreatedAt: &created, Alias: true},
+			expectedErr: domain.ErrAliasTaken,
+			mocks: func(m mocksStorage) {
+				boolCmd := redis.NewBoolCmd(ctx)
+				boolCmd.SetVal(false)
+				m.storageClient.EXPECT().SetNX(ctx, "taken", `{"code":"taken","url":"http://example.com","created_at":"2030-06-01T09:00:00Z"}`,
+					storage.CacheDuration).Return(boolCmd)
 			},
 		},
 	}
@@ -67,9 +93,9 @@ func TestSaveURL(t *testing.T) {
 			service := storage.NewStorageService(m.storageClient)
 			ctx := context.Background()
 
-			err := service.SaveURL(ctx, tt.shortURL, tt.originalURL)
-			if tt.expectError {
-				assert.Error(t, err)
+			err := service.SaveLink(ctx, tt.link)
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
 			} else {
 				assert.NoError(t, err)
 			}
@@ -85,6 +111,7 @@ func TestGetURL(t *testing.T) {
 		shortURL    string
 		expectedURL string
 		expectError bool
+		expectedErr error
 		mocks       func(m mocksStorage)
 	}{
 		{
@@ -103,12 +130,25 @@ func TestGetURL(t *testing.T) {
 			shortURL:    "nonexistent",
 			expectedURL: "",
 			expectError: true,
+			expectedErr: domain.ErrLinkNotFound,
 			mocks: func(m mocksStorage) {
 				stringCmd := redis.NewStringCmd(ctx)
 				stringCmd.SetErr(redis.Nil)
 				m.storageClient.EXPECT().Get(ctx, "nonexistent").Return(stringCmd)
 			},
 		},
+		{
+			name:        "WhenGetFails_ThenReturnsStorageUnavailable",
+			shortURL:    "short123",
+			expectedURL: "",
+			expectError: true,
+			expectedErr: domain.ErrStorageUnavailable,
+			mocks: func(m mocksStorage) {
+				stringCmd := redis.NewStringCmd(ctx)
+				stringCmd.SetErr(errors.New("weird error"))
+				m.storageClient.EXPECT().Get(ctx, "short123").Return(stringCmd)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -127,7 +167,7 @@ func TestGetURL(t *testing.T) {
 
 			retrievedURL, err := service.GetURL(ctx, tt.shortURL)
 			if tt.expectError {
-				assert.Error(t, err)
+				assert.ErrorIs(t, err, tt.expectedErr)
 				assert.Equal(t, tt.expectedURL, retrievedURL)
 			} else {
 				assert.NoError(t, err)
@@ -136,3 +176,518 @@ func TestGetURL(t *testing.T) {
 		})
 	}
 }
+
+func TestGetLink(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		stored   string
+		expected *domain.Link
+	}{
+		{
+			name:     "WhenValueIsALinkRecord_ThenDecodesIt",
+			stored:   `{"code":"short123","url":"http://original.url","password_hash":"$2a$10$hash"}`,
+			expected: &domain.Link{Code: "short123", OriginalURL: "http://original.url", PasswordHash: "$2a$10$hash"},
+		},
+		{
+			name:     "WhenValueIsABareURL_ThenReadsItAsAPublicLink",
+			stored:   "http://original.url",
+			expected: &domain.Link{Code: "short123", OriginalURL: "http://original.url", Shared: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocksStorage{
+				storageClient: mocks.NewMockStorageClient(ctrl),
+			}
+
+			stringCmd := redis.NewStringCmd(ctx)
+			stringCmd.SetVal(tt.stored)
+			m.storageClient.EXPECT().Get(ctx, "short123").Return(stringCmd)
+
+			service := storage.NewStorageService(m.storageClient)
+
+			link, err := service.GetLink(ctx, "short123")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, link)
+		})
+	}
+}
+
+func TestSaveURLs(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		links          []domain.Link
+		existing       map[string]string
+		expectedErrors []error
+		expectedValues map[string]string
+	}{
+		{
+			name: "WhenEveryLinkIsNew_ThenSavesAllOfThem",
+			links: []domain.Link{
+				{Code: "gen12345", OriginalURL: "http://example.com/a", CreatedAt: &created},
+				{Code: "my-alias", OriginalURL: "http://example.com/b", CreatedAt: &created, Alias: true, TTL: time.Hour},
+			},
+			expectedErrors: []error{nil, nil},
+			expectedValues: map[string]string{
+				"gen12345": `{"code":"gen12345","url":"http://example.com/a","created_at":"2030-06-01T09:00:00Z"}`,
+				"my-alias": `{"code":"my-alias","url":"http://example.com/b","created_at":"2030-06-01T09:00:00Z"}`,
+			},
+		},
+		{
+			name: "WhenAliasAlreadyExists_ThenReportsItAndSavesTheRest",
+			links: []domain.Link{
+				{Code: "taken", OriginalURL: "http://example.com/new", CreatedAt: &created, Alias: true},
+				{Code: "gen12345", OriginalURL: "http://example.com/a", CreatedAt: &created},
+			},
+			existing:       map[string]string{"taken": "http://example.com/old"},
+			expectedErrors: []error{domain.ErrAliasTaken, nil},
+			expectedValues: map[string]string{
+				"taken":    "http://example.com/old",
+				"gen12345": `{"code":"gen12345","url":"http://example.com/a","created_at":"2030-06-01T09:00:00Z"}`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			for key, value := range tt.existing {
+				server.Set(key, value)
+			}
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+			service := storage.NewStorageService(client)
+
+			errs := service.SaveURLs(ctx, tt.links)
+			assert.Len(t, errs, len(tt.expectedErrors))
+			for i, expectedErr := range tt.expectedErrors {
+				if expectedErr == nil {
+					assert.NoError(t, errs[i])
+				} else {
+					assert.ErrorIs(t, errs[i], expectedErr)
+				}
+			}
+			for key, value := range tt.expectedValues {
+				stored, err := server.Get(key)
+				assert.NoError(t, err)
+				assert.Equal(t, value, stored)
+			}
+		})
+	}
+}
+
+func TestSaveURLsWhenStorageIsDown_ThenReturnsAnErrorPerLink(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr(), MaxRetries: -1})
+	server.Close()
+
+	service := storage.NewStorageService(client)
+
+	errs := service.SaveURLs(context.Background(), []domain.Link{
+		{Code: "gen12345", OriginalURL: "http://example.com/a"},
+		{Code: "my-alias", OriginalURL: "http://example.com/b", Alias: true},
+	})
+	assert.Len(t, errs, 2)
+	assert.Error(t, errs[0])
+	assert.Error(t, errs[1])
+}
+
+func TestConsumeClick(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name              string
+		counter           string
+		expectedRemaining int64
+		expectedErr       error
+	}{
+		{
+			name:              "WhenClicksAreLeft_ThenTakesOne",
+			counter:           "3",
+			expectedRemaining: 2,
+		},
+		{
+			name:              "WhenTheLastClickIsTaken_ThenReturnsZero",
+			counter:           "1",
+			expectedRemaining: 0,
+		},
+		{
+			name:        "WhenNoClicksAreLeft_ThenReturnsErrLinkExhausted",
+			counter:     "0",
+			expectedErr: domain.ErrLinkExhausted,
+		},
+		{
+			name:        "WhenTheCounterIsGone_ThenReturnsErrLinkExhausted",
+			expectedErr: domain.ErrLinkExhausted,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := miniredis.RunT(t)
+			if tt.counter != "" {
+				server.Set("clicks:once", tt.counter)
+			}
+			client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+			service := storage.NewStorageService(client)
+
+			remaining, err := service.ConsumeClick(ctx, "once")
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expectedRemaining, remaining)
+			}
+		})
+	}
+}
+
+func TestConsumeClickWhenRedirectsRace_ThenNeverExceedsTheLimit(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	err := service.SaveLink(context.Background(), domain.Link{Code: "limited", OriginalURL: "http://example.com", MaxClicks: 10})
+	assert.NoError(t, err)
+
+	var consumed atomic.Int64
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := service.ConsumeClick(context.Background(), "limited"); err == nil {
+				consumed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(10), consumed.Load())
+}
+
+func TestRemainingClicks(t *testing.T) {
+	server := miniredis.RunT(t)
+	server.Set("clicks:limited", "4")
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	remaining, err := service.RemainingClicks(context.Background(), "limited")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), remaining)
+
+	remaining, err = service.RemainingClicks(context.Background(), "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), remaining)
+}
+
+func TestSaveLinkWhenLinkIsScheduled_ThenKeepsItUntilAfterTheWindow(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	from := time.Now().Add(24 * time.Hour)
+	until := from.Add(48 * time.Hour)
+	err := service.SaveLink(context.Background(), domain.Link{Code: "launch", OriginalURL: "http://example.com",
+		ActiveFrom: &from, ActiveUntil: &until})
+	assert.NoError(t, err)
+
+	ttl := server.TTL("launch")
+	assert.Greater(t, ttl, 72*time.Hour)
+	assert.LessOrEqual(t, ttl, 72*time.Hour+storage.CacheDuration)
+}
+
+// updateLink reads the link stored under key and saves it with change.
+func updateLink(t *testing.T, service *storage.StorageService, key string, change func(link *domain.Link)) {
+	previous, err := service.GetLink(context.Background(), key)
+	assert.NoError(t, err)
+	link := *previous
+	change(&link)
+	assert.NoError(t, service.UpdateLink(context.Background(), link, *previous))
+}
+
+func TestUpdateLink(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	server.Set("split", `{"code":"split","url":"http://example.com"}`)
+	server.SetTTL("split", time.Hour)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	previous, err := service.GetLink(ctx, "split")
+	assert.NoError(t, err)
+	link := *previous
+	link.Variants = []domain.Variant{
+		{Name: "a", URL: "http://example.com/a", Weight: 70},
+		{Name: "b", URL: "http://example.com/b", Weight: 30},
+	}
+	err = service.UpdateLink(ctx, link, *previous)
+	assert.NoError(t, err)
+
+	saved, err := service.GetLink(ctx, "split")
+	assert.NoError(t, err)
+	assert.Equal(t, link.Variants, saved.Variants)
+	assert.Equal(t, time.Hour, server.TTL("split"))
+
+	stale := *previous
+	stale.Disabled = true
+	err = service.UpdateLink(ctx, stale, *previous)
+	assert.ErrorIs(t, err, domain.ErrLinkChanged)
+	saved, err = service.GetLink(ctx, "split")
+	assert.NoError(t, err)
+	assert.False(t, saved.Disabled)
+
+	missing := domain.Link{Code: "missing", OriginalURL: "http://example.com"}
+	err = service.UpdateLink(ctx, missing, missing)
+	assert.ErrorIs(t, err, domain.ErrLinkNotFound)
+	assert.False(t, server.Exists("missing"))
+}
+
+func TestReviseLink(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	server.Set("promo", `{"code":"promo","url":"http://example.com"}`)
+	server.SetTTL("promo", time.Hour)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	link, err := service.GetLink(ctx, "promo")
+	assert.NoError(t, err)
+	for i, url := range []string{"http://example.org", "http://example.net"} {
+		previous := *link
+		link.OriginalURL = url
+		link.Revise(previous, "ci", time.Date(2030, 6, 1, 9, i, 0, 0, time.UTC))
+		assert.NoError(t, service.ReviseLink(ctx, *link, previous.Snapshot()))
+	}
+
+	saved, err := service.GetLink(ctx, "promo")
+	assert.NoError(t, err)
+	assert.Equal(t, link, saved)
+	assert.Equal(t, time.Hour, server.TTL("promo"))
+
+	versions, err := service.LinkVersions(ctx, *link)
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.LinkVersion{
+		{Version: 2, Time: versions[0].Time, Actor: "ci", URL: "http://example.org"},
+		{Version: 1, URL: "http://example.com"},
+	}, versions)
+	assert.Equal(t, time.Hour, server.TTL("versions:promo"))
+
+	stale := *link
+	stale.Version = 2
+	err = service.ReviseLink(ctx, stale, domain.LinkVersion{Version: 2, URL: "http://example.org"})
+	assert.ErrorIs(t, err, domain.ErrLinkChanged)
+
+	err = service.ReviseLink(ctx, domain.Link{Code: "missing", OriginalURL: "http://example.com", Version: 2}, domain.LinkVersion{Version: 1})
+	assert.ErrorIs(t, err, domain.ErrLinkNotFound)
+	assert.False(t, server.Exists("missing"))
+}
+
+func TestReviseLinkWhenActivationWindowChanges_ThenMovesTheExpiry(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	until := time.Now().Add(48 * time.Hour)
+	link := domain.Link{Code: "launch", Workspace: "marketing", OriginalURL: "http://example.com", MaxClicks: 5,
+		ActiveUntil: &until, TTL: time.Hour}
+	assert.NoError(t, service.SaveLink(ctx, link))
+
+	expiresIn := func(want time.Duration) {
+		for _, key := range []string{"ws:marketing:launch", "clicks:ws:marketing:launch", "launch", "versions:ws:marketing:launch"} {
+			assert.InDelta(t, want, server.TTL(key), float64(time.Minute), key)
+		}
+		score, err := client.ZScore(ctx, domain.WorkspaceLinksKey("marketing"), "ws:marketing:launch").Result()
+		assert.NoError(t, err)
+		assert.InDelta(t, time.Now().Add(want).UnixMilli(), score, float64(time.Minute.Milliseconds()))
+	}
+
+	earlier := time.Now().Add(24 * time.Hour)
+	previous := link
+	link.ActiveUntil = &earlier
+	link.Revise(previous, "ci", time.Now())
+	assert.NoError(t, service.ReviseLink(ctx, link, previous.Snapshot()))
+	expiresIn(25 * time.Hour)
+
+	previous = link
+	link.ActiveUntil = nil
+	link.Revise(previous, "ci", time.Now())
+	assert.NoError(t, service.ReviseLink(ctx, link, previous.Snapshot()))
+	expiresIn(time.Hour)
+}
+
+func TestSetLinkTitle(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	server.Set("promo", `{"code":"promo","url":"http://example.com"}`)
+	server.SetTTL("promo", time.Hour)
+	server.Set("legacy", "http://example.com/legacy")
+	server.Set("titled", `{"code":"titled","url":"http://example.com","title":"Mine"}`)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	assert.NoError(t, service.SetLinkTitle(ctx, "promo", "http://example.com", "Example Domain"))
+	value, _ := server.Get("promo")
+	assert.JSONEq(t, `{"code":"promo","url":"http://example.com","title":"Example Domain"}`, value)
+	assert.Equal(t, time.Hour, server.TTL("promo"))
+
+	assert.NoError(t, service.SetLinkTitle(ctx, "legacy", "http://example.com/legacy", "Legacy"))
+	link, err := service.GetLink(ctx, "legacy")
+	assert.NoError(t, err)
+	assert.Equal(t, "Legacy", link.Title)
+
+	err = service.SetLinkTitle(ctx, "titled", "http://example.com", "Example Domain")
+	assert.ErrorIs(t, err, domain.ErrLinkChanged)
+	err = service.SetLinkTitle(ctx, "promo", "http://example.org", "Example Domain")
+	assert.ErrorIs(t, err, domain.ErrLinkChanged)
+	err = service.SetLinkTitle(ctx, "missing", "http://example.com", "Example Domain")
+	assert.ErrorIs(t, err, domain.ErrLinkNotFound)
+}
+
+func TestReviseLinkWhenHistoryIsFull_ThenDropsTheOldestVersion(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	server.Set("promo", `{"code":"promo","url":"http://example.com"}`)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	link := domain.Link{Code: "promo", OriginalURL: "http://example.com"}
+	for i := 0; i <= domain.MaxLinkVersions; i++ {
+		previous := link
+		link.OriginalURL = fmt.Sprintf("http://example.com/%d", i)
+		link.Revise(previous, "ci", time.Now())
+		assert.NoError(t, service.ReviseLink(ctx, link, previous.Snapshot()))
+	}
+
+	versions, err := service.LinkVersions(ctx, link)
+	assert.NoError(t, err)
+	assert.Len(t, versions, domain.MaxLinkVersions)
+	assert.Equal(t, int64(domain.MaxLinkVersions+1), versions[0].Version)
+	assert.Equal(t, int64(2), versions[len(versions)-1].Version)
+}
+
+func TestSaveLinkWhenLinkIsOnAnotherDomain_ThenKeepsItApartFromTheSameCode(t *testing.T) {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	err := service.SaveLink(context.Background(), domain.Link{Code: "promo", OriginalURL: "http://example.com"})
+	assert.NoError(t, err)
+	created := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	err = service.SaveLink(context.Background(), domain.Link{Code: "promo", Domain: "acme.link", OriginalURL: "http://example.org", CreatedAt: &created, MaxClicks: 3})
+	assert.NoError(t, err)
+
+	link, err := service.GetLink(context.Background(), "promo")
+	assert.NoError(t, err)
+	assert.Equal(t, "http://example.com", link.OriginalURL)
+
+	link, err = service.GetLink(context.Background(), "acme.link/promo")
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Link{Code: "promo", Domain: "acme.link", OriginalURL: "http://example.org", CreatedAt: &created, MaxClicks: 3}, link)
+
+	remaining, err := service.RemainingClicks(context.Background(), link.Key())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), remaining)
+}
+
+func TestSaveLinkWhenLinkBelongsToAWorkspace_ThenKeepsItInItsNamespace(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	created := time.Date(2030, 6, 1, 9, 0, 0, 0, time.UTC)
+	link := domain.Link{Code: "promo", OriginalURL: "http://example.com", Workspace: "marketing", CreatedAt: &created, Alias: true}
+	assert.NoError(t, service.SaveLink(ctx, link))
+	assert.True(t, server.Exists("ws:marketing:promo"))
+	members, err := server.ZMembers(domain.WorkspaceLinksKey("marketing"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ws:marketing:promo"}, members)
+
+	found, err := service.GetLink(ctx, "promo")
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Link{Code: "promo", OriginalURL: "http://example.com", Workspace: "marketing", CreatedAt: &created}, found)
+
+	_, err = service.GetLink(ctx, "ws:sales:promo")
+	assert.ErrorIs(t, err, domain.ErrLinkNotFound)
+
+	err = service.SaveLink(ctx, domain.Link{Code: "promo", OriginalURL: "http://example.org", Workspace: "sales", Alias: true})
+	assert.ErrorIs(t, err, domain.ErrAliasTaken)
+	err = service.SaveLink(ctx, domain.Link{Code: "promo", OriginalURL: "http://example.org", Workspace: "sales"})
+	assert.ErrorIs(t, err, domain.ErrCodeGeneration)
+
+	errs := service.SaveURLs(ctx, []domain.Link{
+		{Code: "promo", OriginalURL: "http://example.com", Workspace: "marketing"},
+		{Code: "promo", OriginalURL: "http://example.org", Workspace: "sales", Alias: true},
+		{Code: "sale", OriginalURL: "http://example.org", Workspace: "sales", Alias: true},
+	})
+	assert.Equal(t, []error{nil, domain.ErrAliasTaken, nil}, errs)
+	assert.True(t, server.Exists("ws:sales:sale"))
+}
+
+func TestDeleteLink(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	link := domain.Link{Code: "promo", OriginalURL: "http://example.com", Workspace: "marketing", MaxClicks: 3}
+	assert.NoError(t, service.SaveLink(ctx, link))
+	scheduled, err := server.ZMembers(domain.HealthScheduleKey)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ws:marketing:promo"}, scheduled)
+	assert.NoError(t, server.Set(domain.HealthKey("ws:marketing:promo"), `{"status":404}`))
+	_, err = server.ZAdd(domain.BrokenIndexKey("marketing"), 1, "ws:marketing:promo")
+	assert.NoError(t, err)
+	assert.NoError(t, service.DeleteLink(ctx, link))
+
+	assert.False(t, server.Exists("promo"))
+	assert.False(t, server.Exists("ws:marketing:promo"))
+	assert.False(t, server.Exists("clicks:ws:marketing:promo"))
+	assert.False(t, server.Exists(domain.WorkspaceLinksKey("marketing")))
+	assert.False(t, server.Exists(domain.HealthKey("ws:marketing:promo")))
+	assert.False(t, server.Exists(domain.HealthScheduleKey))
+	assert.False(t, server.Exists(domain.BrokenIndexKey("marketing")))
+
+	// The code is free again, for any workspace.
+	assert.NoError(t, service.SaveLink(ctx, domain.Link{Code: "promo", OriginalURL: "http://example.org", Workspace: "sales", Alias: true}))
+}
+
+func TestFindCodes(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	for _, link := range []domain.Link{
+		{Code: "Docs", OriginalURL: "http://example.com"},
+		{Code: "docsite", OriginalURL: "http://example.com"},
+		{Code: "DOCS", OriginalURL: "http://example.com", Domain: "acme.link"},
+		{Code: "docs", OriginalURL: "http://example.com", Workspace: "marketing", Domain: "acme.link"},
+	} {
+		assert.NoError(t, service.SaveLink(ctx, link))
+	}
+
+	codes, err := service.FindCodes(ctx, "", "docs")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Docs"}, codes)
+
+	codes, err = service.FindCodes(ctx, "acme.link", "docs")
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"DOCS", "docs"}, codes)
+
+	codes, err = service.FindCodes(ctx, "", "favicon.ico")
+	assert.NoError(t, err)
+	assert.Empty(t, codes)
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// TombstoneDuration is how long what is left of a link, its tombstone,
+	// is kept after the link expires, so that its visitors can still be sent
+	// to its fallback URL.
+	TombstoneDuration = 30 * 24 * time.Hour
+	// tombstoneKeyPrefix holds the tombstone of each link under its address.
+	tombstoneKeyPrefix = "expired:"
+)
+
+// buryLinkScript writes the tombstone of a link so that it outlives the link
+// by TombstoneDuration. It returns 0 without writing when the link is gone
+// or never expires.
+//
+// KEYS: link key, tombstone key.
+// ARGV: tombstone, TombstoneDuration in milliseconds.
+var buryLinkScript = redis.NewScript(`
+local ttl = redis.call("PTTL", KEYS[1])
+if ttl < 0 then
+	return 0
+end
+redis.call("SET", KEYS[2], ARGV[1], "PX", ttl + tonumber(ARGV[2]))
+return 1
+`)
+
+// buryLink queues on pipe the tombstone of link: the little of it needed to
+// send its visitors to its fallback URL once it expired. Links without a
+// fallback URL get none, and lose the one they had.
+func buryLink(ctx context.Context, pipe redis.Pipeliner, link domain.Link) error {
+	if link.FallbackURL == "" {
+		pipe.Del(ctx, tombstoneKeyPrefix+link.Address())
+		return nil
+	}
+	tombstone, err := encodeLink(domain.Link{
+		Code:        link.Code,
+		Workspace:   link.Workspace,
+		FallbackURL: link.FallbackURL,
+		Wildcard:    link.Wildcard,
+	})
+	if err != nil {
+		return err
+	}
+	buryLinkScript.Eval(ctx, pipe, []string{link.Key(), tombstoneKeyPrefix + link.Address()},
+		tombstone, TombstoneDuration.Milliseconds())
+	return nil
+}
+
+// ExpiredLink returns the tombstone of the link that expired from address,
+// see domain.LinkKey: a link with only its code, domain, workspace, fallback
+// URL and whether it is a wildcard. It returns domain.ErrLinkNotFound when
+// there is none.
+func (s StorageService) ExpiredLink(ctx context.Context, address string) (*domain.Link, error) {
+	key := tombstoneKeyPrefix + address
+	value, err := s.client.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("an error has occurred retrieving the expired url | Code %s --> %w", address, domain.ErrLinkNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("an error has occurred retrieving the expired url --> %w: %w", domain.ErrStorageUnavailable, err)
+	}
+	return decodeLink(strings.TrimPrefix(key, tombstoneKeyPrefix), value)
+}
@@ -0,0 +1,72 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/services/storage"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpiredLink(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	link := domain.Link{Code: "promo", Domain: "acme.link", Workspace: "marketing", OriginalURL: "http://example.com",
+		FallbackURL: "http://example.com/over", Wildcard: true, TTL: time.Hour}
+	assert.NoError(t, service.SaveLink(ctx, link))
+
+	updateLink(t, service, link.Key(), func(link *domain.Link) { link.FallbackURL = "http://example.com/sorry" })
+
+	_, err := service.ExpiredLink(ctx, "missing")
+	assert.ErrorIs(t, err, domain.ErrLinkNotFound)
+
+	server.FastForward(time.Hour)
+	_, err = service.GetLink(ctx, "acme.link/promo")
+	assert.ErrorIs(t, err, domain.ErrLinkNotFound)
+
+	expired, err := service.ExpiredLink(ctx, "acme.link/promo")
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Link{Code: "promo", Domain: "acme.link", Workspace: "marketing",
+		FallbackURL: "http://example.com/sorry", Wildcard: true}, expired)
+	assert.Equal(t, "ws:marketing:acme.link/promo", expired.Key())
+
+	server.FastForward(storage.TombstoneDuration)
+	_, err = service.ExpiredLink(ctx, "acme.link/promo")
+	assert.ErrorIs(t, err, domain.ErrLinkNotFound)
+}
+
+func TestDeleteLinkWhenLinkHasATombstone_ThenRemovesIt(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	link := domain.Link{Code: "promo", OriginalURL: "http://example.com", FallbackURL: "http://example.com/over"}
+	assert.NoError(t, service.SaveLink(ctx, link))
+	assert.NoError(t, service.DeleteLink(ctx, link))
+
+	_, err := service.ExpiredLink(ctx, "promo")
+	assert.ErrorIs(t, err, domain.ErrLinkNotFound)
+}
+
+func TestExpiredLinkWhenLinkHasNoFallback_ThenReturnsNotFound(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	service := storage.NewStorageService(client)
+
+	link := domain.Link{Code: "promo", OriginalURL: "http://example.com", FallbackURL: "http://example.com/over", TTL: time.Hour}
+	assert.NoError(t, service.SaveLink(ctx, link))
+	updateLink(t, service, link.Key(), func(link *domain.Link) { link.FallbackURL = "" })
+
+	server.FastForward(time.Hour)
+	_, err := service.ExpiredLink(ctx, "promo")
+	assert.ErrorIs(t, err, domain.ErrLinkNotFound)
+}
@@ -0,0 +1,199 @@
+package titles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/dariomba/url-shortener/src/internal/domain"
+	"github.com/dariomba/url-shortener/src/internal/ports"
+	"github.com/dariomba/url-shortener/src/internal/safehttp"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+	"golang.org/x/net/html/charset"
+)
+
+const userAgent = "Mozilla/5.0 (compatible; url-shortener title fetcher)"
+
+type titleJob struct {
+	key string
+	url string
+}
+
+// TitleService gives links created without a title the one of their
+// destination page. Pages are fetched in the background, by the workers
+// started with Run, so that creating a link never waits for its destination.
+type TitleService struct {
+	storage  ports.StorageService
+	client   *http.Client
+	maxBytes int64
+	jobs     chan titleJob
+}
+
+// NewTitleService fetches pages with client, see safehttp.NewClient, reading
+// at most maxBytes of each. Up to queueSize links wait for their title.
+func NewTitleService(storage ports.StorageService, client *http.Client, maxBytes int64, queueSize int) *TitleService {
+	return &TitleService{
+		storage:  storage,
+		client:   client,
+		maxBytes: maxBytes,
+		jobs:     make(chan titleJob, queueSize),
+	}
+}
+
+// Enqueue asks for the title of the page at url to be given to the link
+// stored under key. It never blocks: when the queue is full the link is left
+// without a title.
+func (s *TitleService) Enqueue(key string, url string) {
+	select {
+	case s.jobs <- titleJob{key: key, url: url}:
+	default:
+		log.Warnf("the title queue is full, link %s is left without a title", key)
+	}
+}
+
+// Run fetches the queued titles with workers goroutines until ctx is done.
+func (s *TitleService) Run(ctx context.Context, workers int) {
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-s.jobs:
+					s.title(ctx, job)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *TitleService) title(ctx context.Context, job titleJob) {
+	title, err := s.Fetch(ctx, job.url)
+	if err != nil {
+		log.Warn(fmt.Errorf("fetching the title | Code %s --> %w", job.key, err))
+		return
+	}
+	if title == "" {
+		return
+	}
+
+	err = s.storage.SetLinkTitle(ctx, job.key, job.url, title)
+	if errors.Is(err, domain.ErrLinkChanged) || errors.Is(err, domain.ErrLinkNotFound) {
+		// The link got a title, another destination or was deleted while
+		// the page was fetched.
+		return
+	}
+	if err != nil {
+		log.Error(fmt.Errorf("saving the title --> %w", err))
+	}
+}
+
+// Fetch returns the title of the HTML page at target: its og:title, or else
+// its <title>. Pages that are not HTML have none, and only the first maxBytes
+// of a page are read. Pages on private addresses are never requested when
+// the client comes from safehttp.NewClient.
+func (s *TitleService) Fetch(ctx context.Context, target string) (string, error) {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return "", fmt.Errorf("parsing the url --> %w", err)
+	}
+	if err := safehttp.CheckURL(parsed); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("building the request --> %w", err)
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("requesting the page --> %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("the page answered %s", resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return "", nil
+	}
+	// Pages in other encodings than UTF-8 say so in their Content-Type or
+	// in a <meta> tag near their start.
+	body, err := charset.NewReader(io.LimitReader(resp.Body, s.maxBytes), resp.Header.Get("Content-Type"))
+	if err != nil {
+		return "", fmt.Errorf("decoding the page --> %w", err)
+	}
+	return parseTitle(body), nil
+}
+
+// parseTitle reads the head of an HTML document up to its end, or the end of
+// r, for its og:title or else its <title>.
+func parseTitle(r io.Reader) string {
+	tokenizer := html.NewTokenizer(r)
+	var title strings.Builder
+	inTitle, titled := false, false
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return domain.CleanTitle(title.String())
+		case html.TextToken:
+			if inTitle {
+				title.Write(tokenizer.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = !titled
+			case atom.Meta:
+				if og := openGraphTitle(tokenizer, hasAttr); og != "" {
+					return og
+				}
+			case atom.Body:
+				return domain.CleanTitle(title.String())
+			}
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle, titled = false, true
+			case atom.Head:
+				return domain.CleanTitle(title.String())
+			}
+		}
+	}
+}
+
+// openGraphTitle returns the content of a <meta property="og:title"> tag.
+func openGraphTitle(tokenizer *html.Tokenizer, hasAttr bool) string {
+	var property, content string
+	for hasAttr {
+		var key, value []byte
+		key, value, hasAttr = tokenizer.TagAttr()
+		switch string(key) {
+		case "property", "name":
+			property = strings.ToLower(string(value))
+		case "content":
+			content = string(value)
+		}
+	}
+	if property != "og:title" {
+		return ""
+	}
+	return domain.CleanTitle(content)
+}
@@ -0,0 +1,143 @@
+package titles_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dariomba/url-shortener/src/internal/mocks"
+	"github.com/dariomba/url-shortener/src/internal/safehttp"
+	"github.com/dariomba/url-shortener/src/internal/services/titles"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetch(t *testing.T) {
+	pages := map[string]struct {
+		contentType string
+		body        string
+	}{
+		"/og":       {contentType: "text/html", body: `<html><head><title>Plain</title><meta property="og:title" content="Summer &amp; Sale"></head></html>`},
+		"/title":    {contentType: "text/html; charset=utf-8", body: "<!doctype html><html><head><title>\n  Acme &mdash; Home\n</title></head><body><title>Other</title></body></html>"},
+		"/latin1":   {contentType: "text/html; charset=iso-8859-1", body: "<title>Caf\xe9</title>"},
+		"/pdf":      {contentType: "application/pdf", body: "%PDF-1.4 <title>Not a page</title>"},
+		"/untitled": {contentType: "text/html", body: "<html><body><h1>No title</h1></body></html>"},
+		"/long":     {contentType: "text/html", body: "<html><head>" + strings.Repeat("<!-- padding -->", 100) + "<title>Too far</title></head></html>"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/moved" {
+			http.Redirect(w, r, "/title", http.StatusFound)
+			return
+		}
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", page.contentType)
+		w.Write([]byte(page.body))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{name: "WhenPageHasAnOpenGraphTitle_ThenPrefersIt", path: "/og", want: "Summer & Sale"},
+		{name: "WhenPageHasOnlyATitle_ThenReturnsItCleaned", path: "/title", want: "Acme — Home"},
+		{name: "WhenPageIsNotUTF8_ThenDecodesIt", path: "/latin1", want: "Café"},
+		{name: "WhenPageRedirects_ThenFollowsTheRedirect", path: "/moved", want: "Acme — Home"},
+		{name: "WhenPageIsNotHTML_ThenHasNoTitle", path: "/pdf", want: ""},
+		{name: "WhenPageHasNoTitle_ThenReturnsEmpty", path: "/untitled", want: ""},
+		{name: "WhenTitleIsPastTheSizeLimit_ThenIsNotRead", path: "/long", want: ""},
+		{name: "WhenPageIsMissing_ThenFails", path: "/missing", wantErr: true},
+	}
+
+	service := titles.NewTitleService(nil, server.Client(), 1024, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			title, err := service.Fetch(context.Background(), server.URL+tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, title)
+		})
+	}
+}
+
+func TestFetchWhenURLIsNotHTTP_ThenFails(t *testing.T) {
+	service := titles.NewTitleService(nil, http.DefaultClient, 1024, 1)
+
+	_, err := service.Fetch(context.Background(), "file:///etc/passwd")
+
+	assert.ErrorIs(t, err, safehttp.ErrBlockedScheme)
+}
+
+func TestFetchWhenClientIsSafe_ThenRefusesLocalPages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<title>Internal</title>"))
+	}))
+	defer server.Close()
+	service := titles.NewTitleService(nil, safehttp.NewClient(time.Second), 1024, 1)
+
+	_, err := service.Fetch(context.Background(), server.URL)
+
+	assert.ErrorIs(t, err, safehttp.ErrBlockedAddress)
+}
+
+func TestFetchWhenPageIsSlow_ThenTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+	client := server.Client()
+	client.Timeout = 50 * time.Millisecond
+	service := titles.NewTitleService(nil, client, 1024, 1)
+
+	_, err := service.Fetch(context.Background(), server.URL)
+
+	assert.ErrorContains(t, err, "Client.Timeout exceeded")
+}
+
+func TestRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<title>Example Domain</title>"))
+	}))
+	defer server.Close()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	storage := mocks.NewMockStorageService(ctrl)
+	ctx, cancel := context.WithCancel(context.Background())
+	storage.EXPECT().SetLinkTitle(gomock.Any(), "ws:marketing:promo", server.URL, "Example Domain").
+		DoAndReturn(func(context.Context, string, string, string) error {
+			cancel()
+			return nil
+		})
+
+	service := titles.NewTitleService(storage, server.Client(), 1024, 1)
+	service.Enqueue("ws:marketing:promo", server.URL)
+	// The queue holds a single link, so this one is dropped.
+	service.Enqueue("ws:marketing:other", server.URL)
+
+	done := make(chan struct{})
+	go func() {
+		service.Run(ctx, 2)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return once its context was done")
+	}
+}